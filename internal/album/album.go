@@ -0,0 +1,70 @@
+// Package album adds exported photos to a Yandex Disk album, so the remote
+// library itself shows what has already been backed up.
+package album
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
+)
+
+// PickerTimeout is how long to wait for the album picker to list the album.
+const PickerTimeout = 5 * time.Second
+
+// ErrButtonNotFound is returned when the selection toolbar has no "Add to album" action.
+var ErrButtonNotFound = errors.New("add to album button not found")
+
+var (
+	// addNamePattern matches the accessible name of the "Add to album" action.
+	addNamePattern = regexp.MustCompile(`(?i)add to album|в альбом`)
+	// moreNamePattern matches the toolbar's overflow menu button.
+	moreNamePattern = regexp.MustCompile(`(?i)^(more|ещё|еще)$`)
+)
+
+// AddSelection adds the selected photos to the album called name, which must
+// already exist on Yandex Disk. The action is looked up in the selection
+// toolbar and, failing that, in its overflow menu.
+func AddSelection(ctx context.Context, name string) error {
+	if err := openPicker(ctx); err != nil {
+		return err
+	}
+	if err := browser.WaitFor(ctx, scripts.Call("album_option", name), PickerTimeout); err != nil {
+		if browser.IsBrowserClosed(err) {
+			return err
+		}
+		browser.KeyEvent(ctx, "\x1b") // Close the picker
+		return fmt.Errorf("album %q not found; create it in Yandex Disk first", name)
+	}
+	// Give Yandex a moment to save before the selection is cleared
+	browser.Pause(ctx, time.Second)
+	return nil
+}
+
+// openPicker clicks the "Add to album" action of the selection toolbar.
+func openPicker(ctx context.Context) error {
+	_, err := browser.ClickByRole(ctx, "button", addNamePattern.MatchString)
+	if err == nil || !errors.Is(err, browser.ErrElementNotFound) {
+		return err
+	}
+
+	// Narrow windows move the action into the overflow menu
+	if _, err := browser.ClickByRole(ctx, "button", moreNamePattern.MatchString); err != nil {
+		if errors.Is(err, browser.ErrElementNotFound) {
+			return ErrButtonNotFound
+		}
+		return err
+	}
+	browser.Pause(ctx, 500*time.Millisecond)
+	if _, err := browser.ClickByRole(ctx, "menuitem", addNamePattern.MatchString); err != nil {
+		if errors.Is(err, browser.ErrElementNotFound) {
+			return ErrButtonNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Package annotations saves the titles and descriptions users gave their
+// photos on Yandex into a CSV file keyed by file name, since the archives
+// Yandex builds don't carry them.
+//
+// All methods are safe to call on a nil *File, which saves nothing.
+package annotations
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/locale"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/selection"
+)
+
+// FileName is the name of the annotations file in the download directory.
+const FileName = "yandex-annotations.csv"
+
+// header names the columns of the file.
+var header = []string{"file", "date", "title", "description"}
+
+// Annotation is the title and description of one photo or video.
+type Annotation struct {
+	Name        string `json:"name"` // File name, from the preview's alt text
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// Collect returns the annotations of the photos shown for date that have a
+// title or description. Yandex only renders the photos near the viewport,
+// so very large dates may be partially covered.
+func Collect(ctx context.Context, date *selection.DateInfo) ([]Annotation, error) {
+	var list []Annotation
+	if err := browser.Evaluate(ctx, scripts.Call("date_annotations", date.YPosition, locale.Current().Months), &list); err != nil {
+		return nil, fmt.Errorf("could not read titles and descriptions: %w", err)
+	}
+	return list, nil
+}
+
+// File is a CSV file of annotations, appended to across runs.
+type File struct {
+	path string
+
+	mu    sync.Mutex
+	known map[string]bool // Rows already in the file
+}
+
+// Open opens the annotations file at path, creating it with a header row if
+// needed.
+func Open(path string) (*File, error) {
+	f := &File{path: path, known: make(map[string]bool)}
+	in, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, f.append([][]string{header})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open annotations: %w", err)
+	}
+	defer in.Close()
+	r := csv.NewReader(in)
+	r.FieldsPerRecord = len(header)
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return f, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read annotations %s: %w", path, err)
+		}
+		f.known[key(row)] = true
+	}
+}
+
+// Add appends the annotations of date that aren't in the file yet. A photo
+// whose title or description changed gets a new row; the last one wins.
+func (f *File) Add(date string, list []Annotation) error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var rows [][]string
+	for _, a := range list {
+		row := []string{a.Name, date, a.Title, a.Description}
+		if !f.known[key(row)] {
+			f.known[key(row)] = true
+			rows = append(rows, row)
+		}
+	}
+	return f.append(rows)
+}
+
+// append writes rows at the end of the file.
+func (f *File) append(rows [][]string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	out, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(out)
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// key identifies a row by all of its fields.
+func key(row []string) string {
+	return fmt.Sprintf("%q", row)
+}
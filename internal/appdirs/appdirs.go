@@ -0,0 +1,158 @@
+// Package appdirs places the exporter's own files (configuration, catalogs,
+// the progress file, logs and debug bundles) where each OS expects them:
+// the XDG base directories on Linux, AppData on Windows and ~/Library on
+// macOS, or in a single directory given with -data-dir or -portable.
+package appdirs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/catalog"
+)
+
+// appName names the exporter's folder in each base directory.
+const appName = "yandex-disk-photo-exporter"
+
+// Dirs are the directories of the exporter's own files.
+type Dirs struct {
+	Config string // Files named by -labels and -pipeline
+	State  string // Catalogs and the progress file
+	Logs   string // errors.log and the per-date logs
+	Debug  string // Debug bundles
+}
+
+// Default returns the directories of the current OS:
+//
+//   - Linux and other Unixes: $XDG_CONFIG_HOME (~/.config) for the
+//     configuration and $XDG_STATE_HOME (~/.local/state) for the rest
+//   - Windows: %APPDATA% for the configuration and %LOCALAPPDATA% for the rest
+//   - macOS: ~/Library/Application Support, and ~/Library/Logs for the logs
+func Default() (Dirs, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return Dirs{}, err
+	}
+	config = filepath.Join(config, appName)
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Dirs{}, err
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		local := os.Getenv("LOCALAPPDATA")
+		if local == "" {
+			local = filepath.Join(home, "AppData", "Local")
+		}
+		state := filepath.Join(local, appName)
+		return Dirs{Config: config, State: state, Logs: filepath.Join(state, "logs"), Debug: filepath.Join(state, "debug")}, nil
+	case "darwin":
+		return Dirs{Config: config, State: config, Logs: filepath.Join(home, "Library", "Logs", appName), Debug: filepath.Join(config, "debug")}, nil
+	default:
+		state := os.Getenv("XDG_STATE_HOME")
+		if !filepath.IsAbs(state) { // The spec says to ignore relative paths
+			state = filepath.Join(home, ".local", "state")
+		}
+		state = filepath.Join(state, appName)
+		return Dirs{Config: config, State: state, Logs: filepath.Join(state, "logs"), Debug: filepath.Join(state, "debug")}, nil
+	}
+}
+
+// In returns the directories for -data-dir and -portable: folders of root.
+func In(root string) Dirs {
+	return Dirs{
+		Config: filepath.Join(root, "config"),
+		State:  filepath.Join(root, "state"),
+		Logs:   filepath.Join(root, "logs"),
+		Debug:  filepath.Join(root, "debug"),
+	}
+}
+
+// PortableName is the folder next to the executable that -portable keeps
+// the exporter's files and the browser profile in.
+const PortableName = "yandex-exporter-data"
+
+// PortableRoot returns the -portable folder next to the running executable.
+func PortableRoot() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("could not find the executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return filepath.Join(filepath.Dir(exe), PortableName), nil
+}
+
+// FindPortable returns the -portable folder if it exists, so a drive set
+// up once keeps running in portable mode without the flag, or "".
+func FindPortable() string {
+	root, err := PortableRoot()
+	if err != nil {
+		return ""
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return ""
+	}
+	return root
+}
+
+// Catalog returns the catalog file of downloadDir in the state directory,
+// one per download directory, named after it. A catalog that an earlier
+// version kept in downloadDir is moved there first, reporting moved; if it
+// can't be moved, its path is returned so it keeps being used.
+func (d Dirs) Catalog(downloadDir string) (path string, moved bool, err error) {
+	abs, err := filepath.Abs(downloadDir)
+	if err != nil {
+		return "", false, err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	name := strings.TrimSuffix(catalog.FileName, ".json") + "-" + filepath.Base(abs) + "-" + hex.EncodeToString(sum[:4]) + ".json"
+	path = filepath.Join(d.State, "catalogs", name)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", false, fmt.Errorf("could not create the catalog directory: %w", err)
+	}
+
+	legacy := filepath.Join(downloadDir, catalog.FileName)
+	if !exists(legacy) {
+		return path, false, nil
+	}
+	if exists(path) {
+		return "", false, fmt.Errorf("both %s and %s exist; remove the one that is out of date", legacy, path)
+	}
+	if err := os.Rename(legacy, path); err != nil {
+		return legacy, false, nil
+	}
+	return path, true, nil
+}
+
+// ConfigFile returns name as given if it exists or is absolute, and
+// otherwise its place in the configuration directory if it exists there,
+// so -labels and -pipeline files can be kept there and named without a
+// path.
+func (d Dirs) ConfigFile(name string) string {
+	if name == "" || filepath.IsAbs(name) {
+		return name
+	}
+	if _, err := os.Stat(name); !errors.Is(err, os.ErrNotExist) {
+		return name
+	}
+	if path := filepath.Join(d.Config, name); exists(path) {
+		return path
+	}
+	return name
+}
+
+// exists reports whether path exists.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
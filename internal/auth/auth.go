@@ -3,12 +3,14 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
-	"github.com/chromedp/chromedp"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
 )
 
 const (
@@ -16,14 +18,16 @@ const (
 	LoginCheckInterval = 10 * time.Second
 	// LoginTimeout is the maximum time to wait for user login.
 	LoginTimeout = 5 * time.Minute
+	// DiskElementsTimeout is how long to wait for logged-in page elements to render.
+	DiskElementsTimeout = 5 * time.Second
 )
 
 // CheckLoginStatus verifies if the user is logged into Yandex.
 // Returns true if logged in, false if on login page.
 func CheckLoginStatus(ctx context.Context) (bool, error) {
 	// First check URL
-	var url string
-	if err := chromedp.Run(ctx, chromedp.Location(&url)); err != nil {
+	url, err := browser.GetCurrentURL(ctx)
+	if err != nil {
 		return false, fmt.Errorf("could not get current URL: %w", err)
 	}
 
@@ -35,44 +39,7 @@ func CheckLoginStatus(ctx context.Context) (bool, error) {
 
 	// Check for login page elements in the DOM
 	var isLoginPage bool
-	err := chromedp.Run(ctx,
-		chromedp.Evaluate(`
-			(function() {
-				// Check for Yandex ID login page elements
-				const pageText = document.body?.innerText || '';
-				const pageHTML = document.body?.innerHTML || '';
-				
-				// Login page indicators
-				const loginIndicators = [
-					// Text content checks
-					pageText.includes('Log in with Yandex ID'),
-					pageText.includes('Войти с Яндекс ID'),
-					pageText.includes('Yandex ID'),
-					pageText.includes('Username or email'),
-					pageText.includes('Логин или email'),
-					pageText.includes('Create ID'),
-					pageText.includes('Создать ID'),
-					pageText.includes('Face or fingerprint login'),
-					
-					// Element checks
-					!!document.querySelector('input[name="login"]'),
-					!!document.querySelector('input[placeholder*="Username"]'),
-					!!document.querySelector('input[placeholder*="email"]'),
-					!!document.querySelector('button[data-t="button:pseudo"]'),
-					!!document.querySelector('[class*="AuthLoginInputToggle"]'),
-					!!document.querySelector('[class*="Passport"]'),
-					!!document.querySelector('[data-t="login"]'),
-					
-					// Login form check
-					!!document.querySelector('form[action*="passport"]'),
-					!!document.querySelector('form[action*="auth"]'),
-				];
-				
-				// If any login indicator is found, user is on login page
-				return loginIndicators.some(indicator => indicator === true);
-			})()
-		`, &isLoginPage),
-	)
+	err = browser.Evaluate(ctx, scripts.Call("login_page"), &isLoginPage)
 
 	if err != nil {
 		return false, fmt.Errorf("could not check login elements: %w", err)
@@ -83,44 +50,17 @@ func CheckLoginStatus(ctx context.Context) (bool, error) {
 		return false, nil
 	}
 
-	// Additional check: verify Yandex Disk elements are present (indicates logged in)
-	var hasDiskElements bool
-	err = chromedp.Run(ctx,
-		chromedp.Evaluate(`
-			(function() {
-				// Check for Yandex Disk logged-in elements
-				const diskIndicators = [
-					// Photo section elements
-					!!document.querySelector('[class*="photo"]'),
-					!!document.querySelector('[class*="Photo"]'),
-					!!document.querySelector('[class*="listing"]'),
-					!!document.querySelector('[class*="Listing"]'),
-					
-					// User avatar or account elements
-					!!document.querySelector('[class*="user"]'),
-					!!document.querySelector('[class*="User"]'),
-					!!document.querySelector('[class*="avatar"]'),
-					!!document.querySelector('[class*="Avatar"]'),
-					
-					// Disk navigation elements
-					!!document.querySelector('[class*="sidebar"]'),
-					!!document.querySelector('[class*="Sidebar"]'),
-					!!document.querySelector('[href*="/client/"]'),
-				];
-				
-				return diskIndicators.filter(i => i === true).length >= 2;
-			})()
-		`, &hasDiskElements),
-	)
+	// Additional check: wait for Yandex Disk elements to render (indicates logged in)
+	err = browser.WaitFor(ctx, scripts.Call("disk_elements"), DiskElementsTimeout)
 
-	if err != nil {
-		log.Printf("Warning: could not verify disk elements: %v", err)
-		// If we can't verify but URL looks OK, assume logged in
+	if err == nil {
+		log.Println("✓ Yandex Disk elements detected - user is logged in")
 		return true, nil
 	}
 
-	if hasDiskElements {
-		log.Println("✓ Yandex Disk elements detected - user is logged in")
+	if !errors.Is(err, browser.ErrWaitTimeout) {
+		log.Printf("Warning: could not verify disk elements: %v", err)
+		// If we can't verify but URL looks OK, assume logged in
 		return true, nil
 	}
 
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+)
+
+// TokenEnv is the environment variable read for the OAuth token when
+// -token is not given, which keeps the token out of the process list.
+const TokenEnv = "YANDEX_TOKEN"
+
+// client sends the requests to the Yandex APIs.
+var client = &http.Client{Timeout: 15 * time.Second}
+
+const (
+	// diskAPIURL answers with the account's Disk information for a valid
+	// token.
+	diskAPIURL = "https://cloud-api.yandex.net/v1/disk/"
+	// sessionAPIURL exchanges a token for a one-time track that opens a
+	// Yandex web session, as Yandex's own apps do.
+	sessionAPIURL = "https://mobileproxy.passport.yandex.net/1/bundle/auth/x_token/"
+)
+
+var (
+	// ErrInvalidToken is returned when Yandex rejects the token.
+	ErrInvalidToken = errors.New("the OAuth token is invalid or expired")
+	// ErrNoDiskAccess is returned when the token is valid but was not
+	// granted access to Yandex Disk.
+	ErrNoDiskAccess = errors.New("the OAuth token has no access to Yandex Disk")
+)
+
+// Account is the Yandex account a token belongs to.
+type Account struct {
+	Login string `json:"login"`
+	Name  string `json:"display_name"`
+}
+
+// ValidateToken checks the token against the Yandex Disk API and returns
+// the account it belongs to.
+func ValidateToken(ctx context.Context, token string) (Account, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, diskAPIURL, nil)
+	if err != nil {
+		return Account{}, err
+	}
+	req.Header.Set("Authorization", "OAuth "+token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return Account{}, fmt.Errorf("could not reach the Yandex Disk API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return Account{}, ErrInvalidToken
+	case http.StatusForbidden:
+		return Account{}, ErrNoDiskAccess
+	default:
+		return Account{}, fmt.Errorf("the Yandex Disk API answered %s", resp.Status)
+	}
+	var disk struct {
+		User Account `json:"user"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&disk); err != nil {
+		return Account{}, fmt.Errorf("unexpected answer from the Yandex Disk API: %w", err)
+	}
+	return disk.User, nil
+}
+
+// LoginWithToken opens a Yandex web session in the browser from the token,
+// without the login page, and checks that pageURL is then logged in. Only
+// tokens issued to Yandex's own apps can open a web session; Yandex refuses
+// others, and the caller falls back to logging in in the browser.
+func LoginWithToken(ctx context.Context, token, pageURL string) error {
+	track, err := sessionTrack(ctx, token, pageURL)
+	if err != nil {
+		return err
+	}
+	log.Println("Opening a Yandex session from the OAuth token...")
+	if err := browser.Navigate(ctx, track); err != nil {
+		return fmt.Errorf("could not open the session: %w", err)
+	}
+	if err := browser.Navigate(ctx, pageURL); err != nil {
+		return err
+	}
+	loggedIn, err := CheckLoginStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("could not verify the session: %w", err)
+	}
+	if !loggedIn {
+		return errors.New("the session was opened, but Yandex Disk still asks for a login")
+	}
+	log.Println("✓ Logged in with the OAuth token")
+	return nil
+}
+
+// sessionTrack asks Yandex Passport for the URL that sets the session
+// cookies of the token's account and then returns to retpath. The cookies
+// are set for the Yandex domain of retpath, so it must be the page the
+// export opens (disk.yandex.com, not disk.yandex.ru).
+func sessionTrack(ctx context.Context, token, retpath string) (string, error) {
+	form := url.Values{"type": {"x-token"}, "retpath": {retpath}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sessionAPIURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Ya-Consumer-Authorization", "OAuth "+token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not reach Yandex Passport: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Status       string   `json:"status"`
+		Errors       []string `json:"errors"`
+		PassportHost string   `json:"passport_host"`
+		TrackID      string   `json:"track_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", fmt.Errorf("unexpected answer from Yandex Passport (%s): %w", resp.Status, err)
+	}
+	if res.Status != "ok" || res.PassportHost == "" || res.TrackID == "" {
+		return "", fmt.Errorf("Yandex Passport refused to open a session with the token: %s", strings.Join(res.Errors, ", "))
+	}
+	return res.PassportHost + "/auth/session/?" + url.Values{"track_id": {res.TrackID}}.Encode(), nil
+}
@@ -0,0 +1,125 @@
+// Package browser provides Chrome/Chromedp initialization and configuration.
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// ErrElementNotFound is returned when no element matches a role and name query.
+var ErrElementNotFound = errors.New("no element with matching role and name")
+
+// Element is a DOM element located through the accessibility tree.
+type Element struct {
+	ID   cdp.BackendNodeID
+	Name string // Computed accessible name
+}
+
+// FindByRole returns the elements exposed to assistive technology with the
+// given ARIA role (e.g. "button", "checkbox", "option") whose accessible name
+// satisfies match (nil matches any name). Roles and names are computed by
+// Chrome, so they are stable across CSS class changes.
+func FindByRole(ctx context.Context, role string, match func(name string) bool) ([]Element, error) {
+	var elements []Element
+	err := Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		root, err := dom.GetDocument().WithDepth(0).Do(ctx)
+		if err != nil {
+			return err
+		}
+		nodes, err := accessibility.QueryAXTree().WithNodeID(root.NodeID).WithRole(role).Do(ctx)
+		if err != nil {
+			return err
+		}
+		for _, n := range nodes {
+			if n.Ignored || n.BackendDOMNodeID == 0 {
+				continue
+			}
+			var name string
+			if n.Name != nil {
+				json.Unmarshal([]byte(n.Name.Value), &name)
+			}
+			if match == nil || match(name) {
+				elements = append(elements, Element{ID: n.BackendDOMNodeID, Name: name})
+			}
+		}
+		return nil
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("accessibility query for role %q failed: %w", role, err)
+	}
+	return elements, nil
+}
+
+// CallOn runs a JavaScript function declaration with `this` bound to the
+// element and stores its JSON result in res (may be nil).
+func CallOn(ctx context.Context, el Element, function string, res any) error {
+	return Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		obj, err := dom.ResolveNode().WithBackendNodeID(el.ID).Do(ctx)
+		if err != nil {
+			return err
+		}
+		out, exc, err := runtime.CallFunctionOn(function).
+			WithObjectID(obj.ObjectID).
+			WithReturnByValue(true).
+			Do(ctx)
+		if err != nil {
+			return err
+		}
+		if exc != nil {
+			return fmt.Errorf("script error: %s", exc.Text)
+		}
+		if res == nil || out == nil || len(out.Value) == 0 {
+			return nil
+		}
+		return json.Unmarshal([]byte(out.Value), res)
+	}))
+}
+
+// ClickElement scrolls the element into view and clicks it. On a humanized
+// ctx, the pointer first moves onto the element. In keyboard mode (see
+// WithKeyboard), the element is activated with key presses instead when it
+// can take the focus or declares a shortcut.
+func ClickElement(ctx context.Context, el Element) error {
+	if Keyboard(ctx) {
+		if ok, err := activateByKeyboard(ctx, el); ok || err != nil {
+			return err
+		}
+	}
+	if d := humanizer(ctx); d != nil {
+		var center struct{ X, Y float64 }
+		err := CallOn(ctx, el, `function() {
+			this.scrollIntoView({block: 'nearest'});
+			const r = this.getBoundingClientRect();
+			return {X: r.left + r.width / 2, Y: r.top + r.height / 2};
+		}`, &center)
+		if err != nil {
+			return err
+		}
+		if err := d.moveTo(ctx, center.X, center.Y); err != nil {
+			return err
+		}
+		d.pause(ctx)
+	}
+	return CallOn(ctx, el, `function() { this.scrollIntoView({block: 'nearest'}); this.click(); }`, nil)
+}
+
+// ClickByRole clicks the first element with the given role whose accessible
+// name satisfies match. It returns ErrElementNotFound if there is none.
+func ClickByRole(ctx context.Context, role string, match func(name string) bool) (Element, error) {
+	elements, err := FindByRole(ctx, role, match)
+	if err != nil {
+		return Element{}, err
+	}
+	if len(elements) == 0 {
+		return Element{}, ErrElementNotFound
+	}
+	return elements[0], ClickElement(ctx, elements[0])
+}
@@ -18,6 +18,8 @@ type Config struct {
 	WindowWidth int
 	WindowHeight int
 	Timeout     time.Duration
+	Language    string // UI language requested from websites, e.g. "en-US" (empty keeps the browser default)
+	WindowPosition string // "x,y" of the window's top-left corner, e.g. off-screen (empty leaves it to the window manager)
 }
 
 // DefaultConfig returns default browser configuration.
@@ -39,14 +41,32 @@ type Context struct {
 
 // New creates a new browser context with the given configuration.
 func New(cfg Config) (*Context, error) {
+	// A previous crash must not bring up the "Restore pages?" bubble
+	if err := markCleanExit(cfg.ProfilePath); err != nil {
+		log.Printf("Warning: could not reset the profile's exit state: %v", err)
+	}
+
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.ExecPath(cfg.ExecPath),
 		chromedp.UserDataDir(cfg.ProfilePath),
 		chromedp.Flag("headless", false),
 		chromedp.Flag("no-sandbox", true),
 		chromedp.Flag("disable-dev-shm-usage", true),
+		chromedp.Flag("hide-crash-restore-bubble", true),
+		chromedp.Flag("disable-session-crashed-bubble", true),
+		chromedp.Flag("disable-infobars", true),
 		chromedp.WindowSize(cfg.WindowWidth, cfg.WindowHeight),
 	)
+	if cfg.WindowPosition != "" {
+		opts = append(opts, chromedp.Flag("window-position", cfg.WindowPosition))
+	}
+	if cfg.Language != "" {
+		// Sets both the browser UI language and the Accept-Language header
+		opts = append(opts,
+			chromedp.Flag("lang", cfg.Language),
+			chromedp.Flag("accept-lang", cfg.Language),
+		)
+	}
 
 	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
 
@@ -77,17 +97,28 @@ func (c *Context) Close() {
 	}
 }
 
-// Navigate navigates to the given URL.
+// Shutdown closes the browser gracefully, so it writes the profile's
+// cookies and session to disk before exiting, then releases the contexts.
+func (c *Context) Shutdown() error {
+	err := chromedp.Cancel(c.Ctx)
+	c.Close()
+	return err
+}
+
+// PageLoadTimeout is the maximum time to wait for a page to finish loading.
+const PageLoadTimeout = 30 * time.Second
+
+// Navigate navigates to the given URL and waits for the page to finish loading.
 func Navigate(ctx context.Context, url string) error {
-	return chromedp.Run(ctx,
-		chromedp.Navigate(url),
-		chromedp.Sleep(5*time.Second),
-	)
+	if err := DriverFrom(ctx).Navigate(ctx, url); err != nil {
+		return err
+	}
+	return WaitFor(ctx, `document.readyState === 'complete' && !!document.body && document.body.innerText.trim().length > 0`, PageLoadTimeout)
 }
 
 // ConfigureDownloads sets up the download directory for the browser.
 func ConfigureDownloads(ctx context.Context, downloadDir string) error {
-	if err := chromedp.Run(ctx,
+	if err := Run(ctx,
 		browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorAllow).
 			WithDownloadPath(downloadDir).
 			WithEventsEnabled(true),
@@ -100,9 +131,5 @@ func ConfigureDownloads(ctx context.Context, downloadDir string) error {
 
 // GetCurrentURL returns the current page URL.
 func GetCurrentURL(ctx context.Context) (string, error) {
-	var url string
-	if err := chromedp.Run(ctx, chromedp.Location(&url)); err != nil {
-		return "", err
-	}
-	return url, nil
+	return DriverFrom(ctx).Location(ctx)
 }
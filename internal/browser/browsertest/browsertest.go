@@ -0,0 +1,185 @@
+// Package browsertest provides a scripted browser.Driver, so the automation
+// packages can be tested without Chrome.
+package browsertest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/chromedp"
+)
+
+// ErrNotScripted is returned for actions the Driver has no answer for. Raw
+// chromedp actions (Run) are never scripted, so the helpers built on them,
+// such as browser.FindByRole, fail and callers take their fallback path.
+var ErrNotScripted = errors.New("browsertest: not scripted")
+
+// Driver is a browser.Driver that answers Evaluate with scripted results and
+// records every action. Install it with Context or browser.WithDriver.
+type Driver struct {
+	mu      sync.Mutex
+	results map[string][]any
+	url     string
+	actions []string
+}
+
+var _ browser.Driver = (*Driver)(nil)
+
+// New creates a Driver with nothing scripted.
+func New() *Driver {
+	return &Driver{results: make(map[string][]any)}
+}
+
+// Context returns a copy of ctx that routes browser actions through d.
+func (d *Driver) Context(ctx context.Context) context.Context {
+	return browser.WithDriver(ctx, d)
+}
+
+// On scripts the results of key: the name of a page script called with
+// scripts.Call ("!name" for its negation, as in WaitFor conditions), or the
+// start of any other expression. Each Evaluate takes the next result and
+// the last one repeats; a result that is an error is returned as such.
+func (d *Driver) On(key string, results ...any) *Driver {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.results[key] = results
+	return d
+}
+
+// Actions returns the actions performed so far, e.g. "Evaluate
+// first_visible_date", "MouseClickXY 70,120 left" or "KeyEvent \x1b".
+func (d *Driver) Actions() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.actions...)
+}
+
+// Count returns how many recorded actions are action.
+func (d *Driver) Count(action string) int {
+	n := 0
+	for _, a := range d.Actions() {
+		if a == action {
+			n++
+		}
+	}
+	return n
+}
+
+// record appends an action. The caller must hold d.mu.
+func (d *Driver) record(format string, args ...any) {
+	d.actions = append(d.actions, fmt.Sprintf(format, args...))
+}
+
+// key returns the scripted key an expression answers to, and whether one
+// matches: the page script it calls, or the longest key it starts with.
+func (d *Driver) key(expr string) (string, bool) {
+	negated := strings.HasPrefix(expr, "!")
+	call := strings.TrimPrefix(expr, "!")
+	for _, name := range scripts.Names() {
+		if strings.HasPrefix(call, "("+scripts.Source(name)+")(") {
+			if negated {
+				name = "!" + name
+			}
+			_, ok := d.results[name]
+			return name, ok
+		}
+	}
+	best, found := expr, false
+	for key := range d.results {
+		if strings.HasPrefix(expr, key) && (!found || len(key) > len(best)) {
+			best, found = key, true
+		}
+	}
+	return best, found
+}
+
+func (d *Driver) Evaluate(ctx context.Context, expression string, res any) error {
+	d.mu.Lock()
+	key, ok := d.key(expression)
+	d.record("Evaluate %s", key)
+	var result any
+	if ok {
+		results := d.results[key]
+		result = results[0]
+		if len(results) > 1 {
+			d.results[key] = results[1:]
+		}
+	}
+	d.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotScripted, key)
+	}
+	if err, isErr := result.(error); isErr {
+		return err
+	}
+	if res == nil {
+		return nil
+	}
+	// Round-trip through JSON like a value returned by the page
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, res)
+}
+
+func (d *Driver) Run(ctx context.Context, actions ...chromedp.Action) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.record("Run")
+	return ErrNotScripted
+}
+
+func (d *Driver) Location(ctx context.Context) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.url, nil
+}
+
+func (d *Driver) Navigate(ctx context.Context, url string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.record("Navigate %s", url)
+	d.url = url
+	return nil
+}
+
+func (d *Driver) WaitVisible(ctx context.Context, sel string, opts ...chromedp.QueryOption) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.record("WaitVisible %s", sel)
+	return nil
+}
+
+func (d *Driver) Click(ctx context.Context, sel string, opts ...chromedp.QueryOption) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.record("Click %s", sel)
+	return nil
+}
+
+func (d *Driver) MouseClickXY(ctx context.Context, x, y float64, opts ...chromedp.MouseOption) error {
+	p := &input.DispatchMouseEventParams{Button: input.Left}
+	for _, o := range opts {
+		p = o(p)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.record("MouseClickXY %.0f,%.0f %s", x, y, p.Button)
+	return nil
+}
+
+func (d *Driver) KeyEvent(ctx context.Context, keys string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.record("KeyEvent %s", keys)
+	return nil
+}
@@ -5,7 +5,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 // DetectBrowser attempts to find a Chrome/Chromium executable on the system.
@@ -22,6 +26,9 @@ func DetectBrowser() string {
 		candidates = getLinuxCandidates()
 	}
 
+	// Desktop browsers first: a testing build is usually there for tooling
+	candidates = append(candidates, getTestingCandidates()...)
+
 	// Check each candidate path
 	for _, path := range candidates {
 		if path == "" {
@@ -35,7 +42,7 @@ func DetectBrowser() string {
 	}
 
 	// Fallback: try to find in PATH
-	for _, name := range []string{"chrome", "chromium", "chromium-browser", "google-chrome"} {
+	for _, name := range []string{"chrome", "chromium", "chromium-browser", "google-chrome", "chrome-headless-shell", "headless_shell"} {
 		if path, err := exec.LookPath(name); err == nil {
 			return path
 		}
@@ -123,6 +130,97 @@ func getLinuxCandidates() []string {
 	}
 }
 
+// versionPattern matches the numbers of a version or revision in a path,
+// e.g. 131.0.6778.85 or chromium-1148.
+var versionPattern = regexp.MustCompile(`\d+`)
+
+// getTestingCandidates returns the Chrome for Testing and
+// chrome-headless-shell builds installed by Puppeteer, @puppeteer/browsers
+// and Playwright, newest first within each tool. Servers often have these
+// instead of a desktop browser.
+// Priority: Chrome for Testing > Chromium (Playwright) > headless shell
+func getTestingCandidates() []string {
+	home, _ := os.UserHomeDir()
+	cache, _ := os.UserCacheDir() // ~/.cache, ~/Library/Caches or %LOCALAPPDATA%
+	var puppeteer, playwright []string
+	if dir := os.Getenv("PUPPETEER_CACHE_DIR"); dir != "" {
+		puppeteer = append(puppeteer, dir)
+	}
+	puppeteer = append(puppeteer, filepath.Join(home, ".cache", "puppeteer"))
+	if wd, err := os.Getwd(); err == nil {
+		puppeteer = append(puppeteer, wd) // npx @puppeteer/browsers installs here
+	}
+	if dir := os.Getenv("PLAYWRIGHT_BROWSERS_PATH"); dir != "" && dir != "0" {
+		playwright = append(playwright, dir)
+	}
+	playwright = append(playwright, filepath.Join(cache, "ms-playwright"))
+
+	// Executables inside a build folder, by OS
+	var chrome, chromium, shell []string
+	switch runtime.GOOS {
+	case "windows":
+		chrome = []string{filepath.Join("chrome-win64", "chrome.exe"), filepath.Join("chrome-win32", "chrome.exe")}
+		chromium = []string{filepath.Join("chrome-win", "chrome.exe")}
+		shell = []string{filepath.Join("chrome-headless-shell-win64", "chrome-headless-shell.exe"), filepath.Join("chrome-win", "headless_shell.exe")}
+	case "darwin":
+		app := filepath.Join("Google Chrome for Testing.app", "Contents", "MacOS", "Google Chrome for Testing")
+		chrome = []string{filepath.Join("chrome-mac-arm64", app), filepath.Join("chrome-mac-x64", app), filepath.Join("chrome-mac", app)}
+		chromium = []string{filepath.Join("chrome-mac", "Chromium.app", "Contents", "MacOS", "Chromium")}
+		shell = []string{filepath.Join("chrome-headless-shell-mac-arm64", "chrome-headless-shell"), filepath.Join("chrome-headless-shell-mac-x64", "chrome-headless-shell"), filepath.Join("chrome-mac", "headless_shell")}
+	default:
+		chrome = []string{filepath.Join("chrome-linux64", "chrome")}
+		chromium = []string{filepath.Join("chrome-linux", "chrome")}
+		shell = []string{filepath.Join("chrome-headless-shell-linux64", "chrome-headless-shell"), filepath.Join("chrome-linux", "headless_shell")}
+	}
+
+	var candidates []string
+	add := func(roots []string, dir string, exes []string) {
+		for _, root := range roots {
+			for _, exe := range exes {
+				candidates = append(candidates, newestFirst(filepath.Join(root, dir, exe))...)
+			}
+		}
+	}
+	// Puppeteer: <cache>/chrome/linux-131.0.6778.85/chrome-linux64/chrome
+	add(puppeteer, filepath.Join("chrome", "*"), chrome)
+	// Playwright: <cache>/chromium-1148/chrome-linux64/chrome (chrome-linux before 1.49)
+	add(playwright, "chromium-*", append(chrome, chromium...))
+	add(puppeteer, filepath.Join("chrome-headless-shell", "*"), shell)
+	add(playwright, "chromium_headless_shell-*", shell)
+	return candidates
+}
+
+// newestFirst returns the paths matching pattern, the highest version or
+// revision first.
+func newestFirst(pattern string) []string {
+	matches, _ := filepath.Glob(pattern)
+	slices.SortFunc(matches, func(a, b string) int {
+		return -compareVersions(a, b)
+	})
+	return matches
+}
+
+// compareVersions compares the numbers in two paths one by one.
+func compareVersions(a, b string) int {
+	na, nb := versionPattern.FindAllString(a, -1), versionPattern.FindAllString(b, -1)
+	for i := 0; i < len(na) && i < len(nb); i++ {
+		x, _ := strconv.Atoi(na[i])
+		y, _ := strconv.Atoi(nb[i])
+		if x != y {
+			return x - y
+		}
+	}
+	return len(na) - len(nb)
+}
+
+// IsHeadlessShell reports whether path is a chrome-headless-shell build,
+// which never opens a window, so logging in must happen beforehand in a
+// regular browser with the same profile.
+func IsHeadlessShell(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	return strings.Contains(name, "headless-shell") || strings.Contains(name, "headless_shell")
+}
+
 // DefaultProfilePath returns the default profile path for the current OS.
 func DefaultProfilePath() string {
 	homeDir, err := os.UserHomeDir()
@@ -0,0 +1,116 @@
+// Package browser provides Chrome/Chromedp initialization and configuration.
+package browser
+
+import (
+	"context"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Driver is the small set of browser actions used by the automation packages.
+// Production code uses the chromedp-backed implementation; tests can inject a
+// scripted Driver (see the browsertest package) with WithDriver so no live
+// Chrome is needed.
+type Driver interface {
+	// Run executes raw chromedp actions (used for CDP commands without a helper).
+	Run(ctx context.Context, actions ...chromedp.Action) error
+	// Evaluate runs a JavaScript expression and stores its result in res (may be nil).
+	Evaluate(ctx context.Context, expression string, res any) error
+	// Location returns the current page URL.
+	Location(ctx context.Context) (string, error)
+	// Navigate loads the given URL in the current tab.
+	Navigate(ctx context.Context, url string) error
+	// WaitVisible waits until the element matching sel is visible.
+	WaitVisible(ctx context.Context, sel string, opts ...chromedp.QueryOption) error
+	// Click clicks the element matching sel.
+	Click(ctx context.Context, sel string, opts ...chromedp.QueryOption) error
+	// MouseClickXY dispatches a mouse event at the given viewport coordinates.
+	MouseClickXY(ctx context.Context, x, y float64, opts ...chromedp.MouseOption) error
+	// KeyEvent sends the given keys to the page.
+	KeyEvent(ctx context.Context, keys string) error
+}
+
+// chromeDriver implements Driver on top of chromedp.Run.
+type chromeDriver struct{}
+
+func (chromeDriver) Run(ctx context.Context, actions ...chromedp.Action) error {
+	return chromedp.Run(ctx, actions...)
+}
+
+func (chromeDriver) Evaluate(ctx context.Context, expression string, res any) error {
+	return chromedp.Run(ctx, chromedp.Evaluate(expression, res))
+}
+
+func (chromeDriver) Location(ctx context.Context) (string, error) {
+	var url string
+	if err := chromedp.Run(ctx, chromedp.Location(&url)); err != nil {
+		return "", err
+	}
+	return url, nil
+}
+
+func (chromeDriver) Navigate(ctx context.Context, url string) error {
+	return chromedp.Run(ctx, chromedp.Navigate(url))
+}
+
+func (chromeDriver) WaitVisible(ctx context.Context, sel string, opts ...chromedp.QueryOption) error {
+	return chromedp.Run(ctx, chromedp.WaitVisible(sel, opts...))
+}
+
+func (chromeDriver) Click(ctx context.Context, sel string, opts ...chromedp.QueryOption) error {
+	return chromedp.Run(ctx, chromedp.Click(sel, opts...))
+}
+
+func (chromeDriver) MouseClickXY(ctx context.Context, x, y float64, opts ...chromedp.MouseOption) error {
+	return chromedp.Run(ctx, chromedp.MouseClickXY(x, y, opts...))
+}
+
+func (chromeDriver) KeyEvent(ctx context.Context, keys string) error {
+	return chromedp.Run(ctx, chromedp.KeyEvent(keys))
+}
+
+// driverKey is the context key under which an injected Driver is stored.
+type driverKey struct{}
+
+// WithDriver returns a copy of ctx that routes browser actions through d.
+func WithDriver(ctx context.Context, d Driver) context.Context {
+	return context.WithValue(ctx, driverKey{}, d)
+}
+
+// DriverFrom returns the Driver injected into ctx, or the chromedp driver if none.
+func DriverFrom(ctx context.Context) Driver {
+	if d, ok := ctx.Value(driverKey{}).(Driver); ok && d != nil {
+		return d
+	}
+	return chromeDriver{}
+}
+
+// Run executes raw chromedp actions through the context's Driver.
+func Run(ctx context.Context, actions ...chromedp.Action) error {
+	return DriverFrom(ctx).Run(ctx, actions...)
+}
+
+// Evaluate runs a JavaScript expression through the context's Driver.
+func Evaluate(ctx context.Context, expression string, res any) error {
+	return DriverFrom(ctx).Evaluate(ctx, expression, res)
+}
+
+// WaitVisible waits for an element through the context's Driver.
+func WaitVisible(ctx context.Context, sel string, opts ...chromedp.QueryOption) error {
+	return DriverFrom(ctx).WaitVisible(ctx, sel, opts...)
+}
+
+// Click clicks an element through the context's Driver.
+func Click(ctx context.Context, sel string, opts ...chromedp.QueryOption) error {
+	return DriverFrom(ctx).Click(ctx, sel, opts...)
+}
+
+// MouseClickXY dispatches a mouse event through the context's Driver.
+func MouseClickXY(ctx context.Context, x, y float64, opts ...chromedp.MouseOption) error {
+	return DriverFrom(ctx).MouseClickXY(ctx, x, y, opts...)
+}
+
+// KeyEvent sends keys through the context's Driver.
+func KeyEvent(ctx context.Context, keys string) error {
+	return DriverFrom(ctx).KeyEvent(ctx, keys)
+}
@@ -0,0 +1,139 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/crash"
+	"github.com/chromedp/cdproto/page"
+)
+
+const (
+	// HeartbeatTimeout is how long the page has to evaluate a heartbeat.
+	HeartbeatTimeout = 10 * time.Second
+	// heartbeatMisses is the number of heartbeats in a row the page must
+	// miss to be considered unresponsive.
+	heartbeatMisses = 2
+	// crashSettle is the wait after crashing a hung renderer before the tab
+	// is reloaded in a new one.
+	crashSettle = 2 * time.Second
+)
+
+// ErrUnresponsive is returned by ForceReload when the page still doesn't
+// respond after its renderer was replaced.
+var ErrUnresponsive = errors.New("page does not respond")
+
+// Heartbeat evaluates a trivial expression in the page at a fixed interval,
+// in the background, to notice a renderer that hangs while the browser and
+// the DevTools connection stay alive. Every step would otherwise spin until
+// its own timeout.
+//
+// All methods are safe to call on a nil *Heartbeat, which never reports the
+// page as unresponsive.
+type Heartbeat struct {
+	mu     sync.Mutex
+	misses int       // Heartbeats missed in a row
+	since  time.Time // Last answered heartbeat
+}
+
+// StartHeartbeat probes the page of ctx every interval until ctx ends.
+func StartHeartbeat(ctx context.Context, interval time.Duration) *Heartbeat {
+	h := &Heartbeat{since: time.Now()}
+	go func() {
+		defer crash.Recover()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			err := Probe(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			h.record(err)
+		}
+	}()
+	return h
+}
+
+// Probe evaluates a trivial expression in the page, failing if the renderer
+// doesn't answer within HeartbeatTimeout.
+func Probe(ctx context.Context) error {
+	probeCtx, cancel := context.WithTimeout(ctx, HeartbeatTimeout)
+	defer cancel()
+	var n int
+	return Evaluate(probeCtx, "1", &n)
+}
+
+// record counts a heartbeat answered (err == nil) or missed.
+func (h *Heartbeat) record(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		h.misses, h.since = 0, time.Now()
+		return
+	}
+	h.misses++
+	if h.misses == heartbeatMisses {
+		log.Printf("⚠️ The page has not responded for %v: %v", time.Since(h.since).Round(time.Second), err)
+	}
+}
+
+// Unresponsive reports whether the page missed its last heartbeats.
+func (h *Heartbeat) Unresponsive() bool {
+	if h == nil {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.misses >= heartbeatMisses
+}
+
+// Reset marks the page as responsive again, e.g. after ForceReload.
+func (h *Heartbeat) Reset() {
+	if h == nil {
+		return
+	}
+	h.record(nil)
+}
+
+// ForceReload recovers a hung page in the same tab, so the listeners
+// attached to it keep working. It first asks the browser to reload the
+// page; if the renderer still doesn't answer, it crashes the renderer and
+// reloads the tab in a new one. It returns ErrUnresponsive if the page
+// still doesn't respond; the browser then needs restarting.
+func ForceReload(ctx context.Context) error {
+	reloadCtx, cancel := context.WithTimeout(ctx, HeartbeatTimeout)
+	err := Run(reloadCtx, page.Reload().WithIgnoreCache(true))
+	cancel()
+	if err == nil && Probe(ctx) == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	log.Println("Reload didn't help; restarting the tab's renderer...")
+	crashCtx, cancel := context.WithTimeout(ctx, HeartbeatTimeout)
+	Run(crashCtx, page.Crash()) // Fails as the renderer goes away
+	cancel()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(crashSettle):
+	}
+	// The timeouts' errors would read as a closed browser; report the hang
+	reloadCtx, cancel = context.WithTimeout(ctx, HeartbeatTimeout)
+	defer cancel()
+	if Run(reloadCtx, page.Reload()) != nil || Probe(ctx) != nil {
+		return fmt.Errorf("%w after restarting its renderer", ErrUnresponsive)
+	}
+	return nil
+}
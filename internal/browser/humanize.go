@@ -0,0 +1,171 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// mousePixelsPerStep and the step bounds set how finely a mouse path is
+	// traced: longer moves take more, and more visible, steps.
+	mousePixelsPerStep = 25
+	minMouseSteps      = 8
+	maxMouseSteps      = 40
+	// wheelPixelsPerStep is the distance of one wheel notch in a
+	// humanized scroll.
+	wheelPixelsPerStep = 120
+	maxWheelSteps      = 30
+)
+
+// Humanizer sets how Humanize varies the timing and movement of input.
+type Humanizer struct {
+	MaxDelay time.Duration // Upper bound of the random pause before each click, key press and scroll
+	Jitter   float64       // Fraction by which Pause varies fixed waits, e.g. 0.3 for ±30%
+}
+
+// humanDriver wraps a Driver so clicks follow a curved mouse path and every
+// action comes after a short random pause.
+type humanDriver struct {
+	Driver
+	h Humanizer
+
+	mu   sync.Mutex
+	x, y float64 // Last pointer position
+}
+
+// Humanize returns a copy of ctx whose clicks, key presses and scrolls look
+// less mechanical: the pointer moves to each click along a curved path with
+// easing, scrolls advance in wheel-sized steps, and each action waits a
+// random moment first. This reduces the chance that anti-automation
+// heuristics flag very long sessions.
+func Humanize(ctx context.Context, h Humanizer) context.Context {
+	return WithDriver(ctx, &humanDriver{Driver: DriverFrom(ctx), h: h, x: 400, y: 300})
+}
+
+// humanizer returns the humanDriver of ctx, or nil if ctx isn't humanized.
+func humanizer(ctx context.Context) *humanDriver {
+	d, _ := DriverFrom(ctx).(*humanDriver)
+	return d
+}
+
+func (d *humanDriver) MouseClickXY(ctx context.Context, x, y float64, opts ...chromedp.MouseOption) error {
+	if err := d.moveTo(ctx, x, y); err != nil {
+		return err
+	}
+	d.pause(ctx)
+	return d.Driver.MouseClickXY(ctx, x, y, opts...)
+}
+
+func (d *humanDriver) Click(ctx context.Context, sel string, opts ...chromedp.QueryOption) error {
+	d.pause(ctx)
+	return d.Driver.Click(ctx, sel, opts...)
+}
+
+func (d *humanDriver) KeyEvent(ctx context.Context, keys string) error {
+	d.pause(ctx)
+	return d.Driver.KeyEvent(ctx, keys)
+}
+
+// moveTo moves the pointer from its last position to x, y along a cubic
+// Bézier curve bent to a random side, slow at both ends.
+func (d *humanDriver) moveTo(ctx context.Context, x, y float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dx, dy := x-d.x, y-d.y
+	dist := math.Hypot(dx, dy)
+	if dist < 1 {
+		return nil
+	}
+	steps := min(max(int(dist/mousePixelsPerStep), minMouseSteps), maxMouseSteps)
+
+	// Control points a third and two thirds of the way, pushed sideways
+	bend := (rand.Float64() - 0.5) * dist * 0.4
+	nx, ny := -dy/dist*bend, dx/dist*bend
+	c1x, c1y := d.x+dx/3+nx, d.y+dy/3+ny
+	c2x, c2y := d.x+dx*2/3+nx*rand.Float64(), d.y+dy*2/3+ny*rand.Float64()
+
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		t = t * t * (3 - 2*t) // Ease in and out
+		u := 1 - t
+		px := u*u*u*d.x + 3*u*u*t*c1x + 3*u*t*t*c2x + t*t*t*x
+		py := u*u*u*d.y + 3*u*u*t*c1y + 3*u*t*t*c2y + t*t*t*y
+		if err := d.Driver.Run(ctx, input.DispatchMouseEvent(input.MouseMoved, px, py)); err != nil {
+			return fmt.Errorf("mouse move failed: %w", err)
+		}
+		if !sleepCtx(ctx, time.Duration(8+rand.IntN(12))*time.Millisecond) {
+			return ctx.Err()
+		}
+	}
+	d.x, d.y = x, y
+	return nil
+}
+
+// scroll scrolls by dy in wheel-sized steps of varying length and returns
+// the new scroll position.
+func (d *humanDriver) scroll(ctx context.Context, dy float64) (float64, error) {
+	d.pause(ctx)
+	steps := min(max(int(math.Abs(dy)/wheelPixelsPerStep), 1), maxWheelSteps)
+	done := 0.0
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		next := dy * t * t * (3 - 2*t)
+		if err := d.Driver.Evaluate(ctx, fmt.Sprintf(`window.scrollBy(0, %f)`, next-done), nil); err != nil {
+			return 0, err
+		}
+		done = next
+		if i < steps && !sleepCtx(ctx, time.Duration(15+rand.IntN(30))*time.Millisecond) {
+			return 0, ctx.Err()
+		}
+	}
+	var y float64
+	err := d.Driver.Evaluate(ctx, `window.scrollY`, &y)
+	return y, err
+}
+
+// pause waits a random moment up to MaxDelay.
+func (d *humanDriver) pause(ctx context.Context) {
+	if d.h.MaxDelay > 0 {
+		sleepCtx(ctx, d.h.MaxDelay/4+rand.N(d.h.MaxDelay*3/4+1))
+	}
+}
+
+// ScrollBy scrolls the page by dy pixels (up when negative) and returns the
+// new scroll position. On a humanized ctx, the scroll advances in steps
+// like a mouse wheel.
+func ScrollBy(ctx context.Context, dy float64) (float64, error) {
+	if d := humanizer(ctx); d != nil {
+		return d.scroll(ctx, dy)
+	}
+	var y float64
+	err := Evaluate(ctx, fmt.Sprintf(`(window.scrollBy(0, %f), window.scrollY)`, dy), &y)
+	return y, err
+}
+
+// Pause waits for d, scaled to the page's speed when ctx has a Pacing (see
+// WithPacing) and varied by the Humanizer's Jitter on a humanized ctx. It
+// returns early when ctx ends.
+func Pause(ctx context.Context, d time.Duration) {
+	d = pacingFrom(ctx).Scale(d)
+	if h := humanizer(ctx); h != nil && h.h.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * h.h.Jitter * float64(d))
+	}
+	sleepCtx(ctx, d)
+}
+
+// sleepCtx waits for d and reports whether ctx is still alive.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
@@ -0,0 +1,55 @@
+package browser
+
+import (
+	"context"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/crash"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/logging"
+	"github.com/chromedp/cdproto/input"
+)
+
+// keepAliveScript tells the page it has the focus and scrolls it one pixel
+// down and back, which leaves the timeline where it was.
+const keepAliveScript = `(window.dispatchEvent(new Event('focus')), window.scrollBy(0, 1), window.scrollBy(0, -1), true)`
+
+// KeepAlive performs light activity on the page of ctx every interval, in
+// the background, until the returned function is called or ctx ends: a
+// pointer move in the page's top-left corner, a focus event and a one-pixel
+// scroll. While the exporter only waits for a large archive, the page
+// otherwise sees no input for many minutes and Yandex may expire the
+// session, which cancels the download. An interval of 0 does nothing.
+func KeepAlive(ctx context.Context, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		defer crash.Recover()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		x := 2.0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// Alternate the position so every ping is a real move
+			x = 5 - x
+			err := Run(ctx, input.DispatchMouseEvent(input.MouseMoved, x, 2))
+			if err == nil {
+				err = Evaluate(ctx, keepAliveScript, nil)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				logging.Debugf("Keep-alive failed: %v", err)
+			} else {
+				logging.Debugf("Keep-alive sent")
+			}
+		}
+	}()
+	return cancel
+}
@@ -0,0 +1,107 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/chromedp"
+)
+
+// keyboardKey is the context key under which keyboard mode is stored.
+type keyboardKey struct{}
+
+// activationFn focuses an element and tells how to activate it from the
+// keyboard: the shortcut the page declares for it, if any, otherwise
+// Space for checkboxes and Enter for everything else.
+const activationFn = `function() {
+	this.scrollIntoView({block: 'nearest'});
+	this.focus({preventScroll: true});
+	const checkbox = this.type === 'checkbox' || this.getAttribute('role') === 'checkbox';
+	return {
+		focused: document.activeElement === this || this.contains(document.activeElement),
+		shortcut: (this.getAttribute('aria-keyshortcuts') || '').trim().split(/\s+/)[0],
+		key: checkbox ? ' ' : '\r'
+	};
+}`
+
+// activation is the result of activationFn.
+type activation struct {
+	Focused  bool   `json:"focused"`
+	Shortcut string `json:"shortcut"`
+	Key      string `json:"key"`
+}
+
+// modifierNames maps the modifier names of aria-keyshortcuts to CDP
+// modifiers.
+var modifierNames = map[string]input.Modifier{
+	"alt": input.ModifierAlt, "control": input.ModifierCtrl, "ctrl": input.ModifierCtrl,
+	"meta": input.ModifierMeta, "shift": input.ModifierShift,
+}
+
+// keyNames maps the key names of aria-keyshortcuts to the keys KeyEvent
+// sends.
+var keyNames = map[string]string{
+	"enter": "\r", "space": " ", "escape": "\x1b", "tab": "\t", "delete": "\x7f",
+}
+
+// WithKeyboard returns a copy of ctx in which ClickElement activates
+// elements with key presses instead of a click: the element's declared
+// keyboard shortcut, or Space or Enter once it has focus. Key presses don't
+// depend on where the element is drawn, so layout shifts, zoom levels and
+// HiDPI scaling can't make them miss.
+func WithKeyboard(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keyboardKey{}, true)
+}
+
+// Keyboard reports whether ctx is in keyboard mode (see WithKeyboard).
+func Keyboard(ctx context.Context) bool {
+	on, _ := ctx.Value(keyboardKey{}).(bool)
+	return on
+}
+
+// activateByKeyboard focuses el and presses its shortcut, or Space or
+// Enter. It reports false, without an error, if el can't take the focus
+// and declares no shortcut, so the caller can click it instead.
+func activateByKeyboard(ctx context.Context, el Element) (bool, error) {
+	var a activation
+	if err := CallOn(ctx, el, activationFn, &a); err != nil {
+		return false, err
+	}
+	switch {
+	case a.Shortcut != "":
+		return true, PressShortcut(ctx, a.Shortcut)
+	case a.Focused:
+		return true, KeyEvent(ctx, a.Key)
+	default:
+		return false, nil
+	}
+}
+
+// PressShortcut presses a keyboard shortcut written as in
+// aria-keyshortcuts, e.g. "Control+A" or "Shift+D".
+func PressShortcut(ctx context.Context, shortcut string) error {
+	parts := strings.Split(shortcut, "+")
+	var mods []input.Modifier
+	for _, m := range parts[:len(parts)-1] {
+		mod, ok := modifierNames[strings.ToLower(m)]
+		if !ok {
+			return fmt.Errorf("unknown modifier %q in shortcut %q", m, shortcut)
+		}
+		mods = append(mods, mod)
+	}
+	key := parts[len(parts)-1]
+	if k, ok := keyNames[strings.ToLower(key)]; ok {
+		key = k
+	} else {
+		key = strings.ToLower(key) // chromedp would add Shift to an uppercase letter
+	}
+	if len(mods) == 0 {
+		return KeyEvent(ctx, key)
+	}
+	if d := humanizer(ctx); d != nil {
+		d.pause(ctx)
+	}
+	return Run(ctx, chromedp.KeyEvent(key, chromedp.KeyModifiers(mods...)))
+}
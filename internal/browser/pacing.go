@@ -0,0 +1,193 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of page reactions measured by WaitReact.
+const (
+	ReactSelection = "selection" // A date's checkbox shown, a selection registered or cleared
+	ReactMenu      = "menu"      // The filter menu opened, updated or closed
+	ReactToolbar   = "toolbar"   // The selection toolbar's buttons shown
+)
+
+const (
+	// referenceReaction is the reaction time the fixed pauses were tuned
+	// for: a page reacting in that time keeps them as they are.
+	referenceReaction = 300 * time.Millisecond
+	// minScale and maxScale bound how much the pauses are shortened on a
+	// fast page and lengthened on a slow one.
+	minScale = 0.5
+	maxScale = 3.0
+	// minReactions is the number of reactions of a kind measured before its
+	// timeouts adapt.
+	minReactions = 5
+	// keptReactions is the number of recent reactions of each kind the
+	// estimates are based on, so they follow the page as it speeds up or
+	// slows down during the session.
+	keptReactions = 50
+)
+
+// pacingKey is the context key under which the Pacing is stored.
+type pacingKey struct{}
+
+// reaction is a measured reaction time; a wait that timed out counts as
+// a reaction of at least the timeout.
+type reaction struct {
+	d        time.Duration
+	timedOut bool
+}
+
+// Pacing measures how long the page takes to react to selections, menu
+// clicks and toolbar updates during the session, and adapts waits to it:
+// the timeouts of WaitReact follow the reactions of each kind, and Pause
+// shortens fixed pauses on a fast page and lengthens them on a slow one.
+type Pacing struct {
+	mu        sync.Mutex
+	reactions map[string][]reaction // Recent reactions by kind, oldest first
+}
+
+// NewPacing returns a Pacing with no measurements, which keeps waits as
+// they are until the page has reacted a few times.
+func NewPacing() *Pacing {
+	return &Pacing{reactions: make(map[string][]reaction)}
+}
+
+// WithPacing returns a copy of ctx whose waits adapt to the page through p.
+func WithPacing(ctx context.Context, p *Pacing) context.Context {
+	return context.WithValue(ctx, pacingKey{}, p)
+}
+
+// pacingFrom returns the Pacing of ctx, or nil if waits don't adapt.
+func pacingFrom(ctx context.Context) *Pacing {
+	p, _ := ctx.Value(pacingKey{}).(*Pacing)
+	return p
+}
+
+// observe records a reaction of kind.
+func (p *Pacing) observe(kind string, r reaction) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rs := append(p.reactions[kind], r)
+	if len(rs) > keptReactions {
+		rs = rs[len(rs)-keptReactions:]
+	}
+	p.reactions[kind] = rs
+}
+
+// Timeout returns how long to wait for a reaction of kind whose fixed
+// timeout is limit: three times the 90th percentile of the recent ones,
+// between half and twice limit, or limit until enough were measured.
+func (p *Pacing) Timeout(kind string, limit time.Duration) time.Duration {
+	if p == nil {
+		return limit
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rs := p.reactions[kind]
+	if len(rs) < minReactions {
+		return limit
+	}
+	durations := make([]time.Duration, len(rs))
+	for i, r := range rs {
+		durations[i] = r.d
+	}
+	return min(max(3*quantile(durations, 0.9), limit/2), 2*limit)
+}
+
+// Scale returns a fixed pause d adapted to the page: multiplied by the
+// median reaction time over referenceReaction, within minScale and
+// maxScale. Reactions that timed out are left out, since a condition that
+// never came true tells nothing about the page's speed.
+func (p *Pacing) Scale(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * p.factor())
+}
+
+// factor returns the multiplier Scale applies, 1 until enough reactions
+// were measured.
+func (p *Pacing) factor() float64 {
+	if p == nil {
+		return 1
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var durations []time.Duration
+	for _, rs := range p.reactions {
+		for _, r := range rs {
+			if !r.timedOut {
+				durations = append(durations, r.d)
+			}
+		}
+	}
+	if len(durations) < minReactions {
+		return 1
+	}
+	f := float64(quantile(durations, 0.5)) / float64(referenceReaction)
+	return min(max(f, minScale), maxScale)
+}
+
+// Summary describes the measured reactions and the resulting scale of the
+// pauses, for the log.
+func (p *Pacing) Summary() string {
+	p.mu.Lock()
+	kinds := make([]string, 0, len(p.reactions))
+	for kind := range p.reactions {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		rs := p.reactions[kind]
+		durations := make([]time.Duration, len(rs))
+		timeouts := 0
+		for i, r := range rs {
+			durations[i] = r.d
+			if r.timedOut {
+				timeouts++
+			}
+		}
+		part := fmt.Sprintf("%s median %s", kind, quantile(durations, 0.5).Round(10*time.Millisecond))
+		if timeouts > 0 {
+			part += fmt.Sprintf(" (%d timed out)", timeouts)
+		}
+		parts = append(parts, part)
+	}
+	p.mu.Unlock()
+	if len(parts) == 0 {
+		return "no page reactions measured"
+	}
+	return fmt.Sprintf("%s; pauses ×%.1f", strings.Join(parts, ", "), p.factor())
+}
+
+// quantile returns the q-th quantile (0-1) of durations, which must not be
+// empty.
+func quantile(durations []time.Duration, q float64) time.Duration {
+	sorted := slices.Sorted(slices.Values(durations))
+	return sorted[int(q*float64(len(sorted)-1)+0.5)]
+}
+
+// WaitReact is WaitFor for a reaction of the page of kind, one of the
+// React constants, to an action just taken. With a Pacing in ctx (see
+// WithPacing), the time the page took is recorded and the timeout follows
+// the recent reactions of kind instead of limit.
+func WaitReact(ctx context.Context, kind, condition string, limit time.Duration) error {
+	p := pacingFrom(ctx)
+	timeout := p.Timeout(kind, limit)
+	start := time.Now()
+	err := WaitFor(ctx, condition, timeout)
+	if p != nil {
+		switch {
+		case err == nil:
+			p.observe(kind, reaction{d: time.Since(start)})
+		case errors.Is(err, ErrWaitTimeout):
+			p.observe(kind, reaction{d: timeout, timedOut: true})
+		}
+	}
+	return err
+}
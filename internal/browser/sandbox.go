@@ -0,0 +1,213 @@
+package browser
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// stagingName is the folder a sandboxed browser saves downloads to when it
+// can't write to the download directory.
+const stagingName = "yandex-exporter-downloads"
+
+// Sandbox describes the snap or flatpak confinement of a browser, which
+// limits the directories it can write to. Downloads Chrome can't write
+// fail without an error the exporter would see: they simply never appear.
+type Sandbox struct {
+	Kind string // "snap" or "flatpak"
+	App  string // Snap name or flatpak application ID
+
+	home string
+}
+
+// DetectSandbox returns the sandbox of the browser at execPath, or nil if
+// it isn't a snap or a flatpak.
+func DetectSandbox(execPath string) *Sandbox {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	path := execPath
+	if resolved, err := filepath.EvalSymlinks(execPath); err == nil {
+		path = resolved
+	}
+	switch {
+	case strings.HasPrefix(execPath, "/snap/bin/"):
+		// A link to /usr/bin/snap named after the snap, e.g. chromium or
+		// chromium.chromedriver
+		name, _, _ := strings.Cut(filepath.Base(execPath), ".")
+		return &Sandbox{Kind: "snap", App: name, home: home}
+	case strings.HasPrefix(path, "/snap/"):
+		// /snap/<name>/<revision>/...
+		return &Sandbox{Kind: "snap", App: strings.Split(path, "/")[2], home: home}
+	case strings.Contains(execPath, "/flatpak/exports/bin/"):
+		return &Sandbox{Kind: "flatpak", App: filepath.Base(execPath), home: home}
+	}
+	return nil
+}
+
+// CanWrite reports whether the browser can write to dir, with the reason
+// when it can't.
+func (s *Sandbox) CanWrite(dir string) (bool, string) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err.Error()
+	}
+	if s.Kind == "snap" {
+		return s.snapCanWrite(dir)
+	}
+	return s.flatpakCanWrite(dir)
+}
+
+// StagingDir returns a directory the browser can always write to and the
+// exporter can read: the sandbox's own data folder.
+func (s *Sandbox) StagingDir() string {
+	if s.Kind == "snap" {
+		return filepath.Join(s.home, "snap", s.App, "common", stagingName)
+	}
+	return filepath.Join(s.home, ".var", "app", s.App, "cache", stagingName)
+}
+
+// snapCanWrite applies the rules of the home and removable-media snap
+// interfaces: the home folder except its top-level hidden entries, and
+// /media, /mnt and /run/media only with removable-media connected. /tmp is
+// private to the snap.
+func (s *Sandbox) snapCanWrite(dir string) (bool, string) {
+	if within(dir, filepath.Join(s.home, "snap", s.App)) {
+		return true, ""
+	}
+	if rel, ok := relTo(dir, s.home); ok {
+		if rel != "." && strings.HasPrefix(rel, ".") {
+			return false, "snaps can't write to hidden folders of the home directory"
+		}
+		return true, ""
+	}
+	for _, root := range []string{"/media", "/mnt", "/run/media"} {
+		if within(dir, root) {
+			if s.snapConnected("removable-media") {
+				return true, ""
+			}
+			return false, "the removable-media interface is not connected (snap connect " + s.App + ":removable-media)"
+		}
+	}
+	return false, "snaps can only write to the home directory and, with removable-media, /media and /mnt"
+}
+
+// snapConnected reports whether the snap's plug for iface is connected.
+func (s *Sandbox) snapConnected(iface string) bool {
+	out, err := exec.Command("snap", "connections", s.App).Output()
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		// Interface  Plug  Slot  Notes; an unconnected plug has "-" as slot
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[0] == iface && fields[2] != "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// flatpakCanWrite checks dir against the filesystems permissions of the
+// flatpak, including the user's overrides.
+func (s *Sandbox) flatpakCanWrite(dir string) (bool, string) {
+	if within(dir, filepath.Join(s.home, ".var", "app", s.App)) {
+		return true, ""
+	}
+	out, err := exec.Command("flatpak", "info", "--show-permissions", s.App).Output()
+	if err != nil {
+		return false, "could not read the permissions of " + s.App + ": " + err.Error()
+	}
+	writable, denied := false, false
+	for _, line := range strings.Split(string(out), "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "filesystems=")
+		if !ok {
+			continue
+		}
+		for _, entry := range strings.Split(value, ";") {
+			negated := strings.HasPrefix(entry, "!")
+			entry, mode, _ := strings.Cut(strings.TrimPrefix(entry, "!"), ":")
+			root := s.flatpakPath(entry)
+			if root == "" || !within(dir, root) {
+				continue
+			}
+			switch {
+			case negated:
+				denied = true
+			case mode != "ro":
+				writable = true
+			}
+		}
+	}
+	if writable && !denied {
+		return true, ""
+	}
+	return false, "the flatpak has no write access to it (flatpak override --user --filesystem=" + dir + " " + s.App + ")"
+}
+
+// flatpakPath resolves a flatpak filesystem entry to a directory, or
+// returns "" for entries that don't name one, such as host-etc.
+func (s *Sandbox) flatpakPath(entry string) string {
+	name, sub, _ := strings.Cut(entry, "/")
+	var root string
+	switch {
+	case name == "host":
+		root = "/"
+	case name == "home" || name == "~":
+		root = s.home
+	case strings.HasPrefix(entry, "/"):
+		return entry
+	case strings.HasPrefix(name, "xdg-"):
+		root = s.xdgDir(strings.TrimPrefix(name, "xdg-"))
+	}
+	if root == "" {
+		return ""
+	}
+	return filepath.Join(root, sub)
+}
+
+// xdgDir returns the XDG directory a flatpak xdg-* entry names, e.g.
+// "download" or "config", or "" if unknown.
+func (s *Sandbox) xdgDir(name string) string {
+	switch name {
+	case "config":
+		return filepath.Join(s.home, ".config")
+	case "cache":
+		return filepath.Join(s.home, ".cache")
+	case "data":
+		return filepath.Join(s.home, ".local", "share")
+	}
+	key := "XDG_" + strings.ToUpper(strings.ReplaceAll(name, "-", "")) + "_DIR"
+	f, err := os.Open(filepath.Join(s.home, ".config", "user-dirs.dirs"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), "=")
+		if ok && strings.TrimSpace(k) == key {
+			v = strings.Trim(strings.TrimSpace(v), `"`)
+			return strings.Replace(v, "$HOME", s.home, 1)
+		}
+	}
+	return ""
+}
+
+// relTo returns path relative to root if it is inside root.
+func relTo(path, root string) (string, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// within reports whether path is root or inside it.
+func within(path, root string) bool {
+	_, ok := relTo(path, root)
+	return ok
+}
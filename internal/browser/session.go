@@ -0,0 +1,67 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// markCleanExit records in the profile that Chrome exited normally, so a
+// crash or a killed run does not make it show the "Restore pages?" bubble
+// over the page on the next start, where it shifts click coordinates.
+func markCleanExit(profileDir string) error {
+	path := filepath.Join(profileDir, "Default", "Preferences")
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil // New profile
+	}
+	if err != nil {
+		return err
+	}
+
+	var prefs map[string]any
+	if err := json.Unmarshal(b, &prefs); err != nil {
+		return err
+	}
+	profile, _ := prefs["profile"].(map[string]any)
+	if profile == nil {
+		profile = make(map[string]any)
+		prefs["profile"] = profile
+	}
+	if profile["exit_type"] == "Normal" && profile["exited_cleanly"] == true {
+		return nil
+	}
+	profile["exit_type"] = "Normal"
+	profile["exited_cleanly"] = true
+
+	b, err = json.Marshal(prefs)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
+
+// DismissDialogs accepts the JavaScript dialogs (alert, confirm, "Leave
+// site?") the page opens, which would otherwise block every further action
+// on the tab until someone clicks them.
+func DismissDialogs(ctx context.Context) {
+	chromedp.ListenTarget(ctx, func(ev any) {
+		e, ok := ev.(*page.EventJavascriptDialogOpening)
+		if !ok {
+			return
+		}
+		log.Printf("Dismissing %s dialog: %q", e.Type, e.Message)
+		// Commands cannot be sent from the event handler itself
+		go func() {
+			if err := Run(ctx, page.HandleJavaScriptDialog(true)); err != nil {
+				log.Printf("Warning: could not dismiss dialog: %v", err)
+			}
+		}()
+	})
+}
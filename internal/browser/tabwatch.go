@@ -0,0 +1,79 @@
+package browser
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/chromedp"
+)
+
+// TabWatch follows the DevTools events of the photos tab that tell its
+// renderer crashed ("Aw, Snap!") or the tab went away, while the browser
+// itself still runs. Without it, a crash shows up as steps timing out one
+// after the other, and then as a closed browser.
+//
+// All methods are safe to call on a nil *TabWatch, which never reports a
+// crash.
+type TabWatch struct {
+	mu       sync.Mutex
+	crashed  bool   // The renderer crashed and the tab wasn't reloaded since
+	detached string // Why DevTools was detached from the tab ("" while attached)
+}
+
+// WatchTab starts following the tab of ctx.
+func WatchTab(ctx context.Context) *TabWatch {
+	w := &TabWatch{}
+	chromedp.ListenTarget(ctx, func(ev any) {
+		switch ev := ev.(type) {
+		case *inspector.EventTargetCrashed:
+			w.mu.Lock()
+			w.crashed = true
+			w.mu.Unlock()
+			log.Println("💥 The renderer of the photos tab crashed")
+		case *inspector.EventTargetReloadedAfterCrash:
+			w.mu.Lock()
+			w.crashed = false
+			w.mu.Unlock()
+		case *inspector.EventDetached:
+			w.mu.Lock()
+			w.detached = string(ev.Reason)
+			w.mu.Unlock()
+			log.Printf("⚠️ The photos tab was detached: %s", ev.Reason)
+		}
+	})
+	return w
+}
+
+// Crashed reports whether the tab's renderer crashed and the tab needs
+// reloading.
+func (w *TabWatch) Crashed() bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.crashed
+}
+
+// Detached returns why DevTools lost the tab, e.g. because it was closed,
+// or "" while the tab is still attached.
+func (w *TabWatch) Detached() string {
+	if w == nil {
+		return ""
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.detached
+}
+
+// Reset marks the tab as working again, e.g. after it was reloaded.
+func (w *TabWatch) Reset() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.crashed = false
+}
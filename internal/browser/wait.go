@@ -0,0 +1,41 @@
+// Package browser provides Chrome/Chromedp initialization and configuration.
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PollInterval is how often WaitFor re-evaluates its condition.
+const PollInterval = 200 * time.Millisecond
+
+// ErrWaitTimeout is returned when a WaitFor condition is not met in time.
+var ErrWaitTimeout = errors.New("timed out waiting for condition")
+
+// WaitFor polls a JavaScript boolean expression until it evaluates to true or
+// the timeout expires. Evaluation errors are treated as "not yet" unless they
+// indicate the browser is gone.
+func WaitFor(ctx context.Context, condition string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var ok bool
+		err := Evaluate(ctx, condition, &ok)
+		if err == nil && ok {
+			return nil
+		}
+		if err != nil && IsBrowserClosed(err) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w after %v", ErrWaitTimeout, timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(PollInterval):
+		}
+	}
+}
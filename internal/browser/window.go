@@ -0,0 +1,59 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/chromedp"
+)
+
+// Window states for -window-state.
+const (
+	WindowNormal    = "normal"
+	WindowMinimized = "minimized"
+	WindowMaximized = "maximized"
+)
+
+// WindowStates are the window states SetWindowState accepts.
+var WindowStates = []string{WindowNormal, WindowMinimized, WindowMaximized}
+
+// SetWindowState minimizes, maximizes or restores the browser window. The
+// page keeps running while minimized or moved off-screen: the default
+// options of New already stop Chrome from throttling hidden windows.
+func SetWindowState(ctx context.Context, state string) error {
+	if !slices.Contains(WindowStates, state) {
+		return fmt.Errorf("unknown window state %q", state)
+	}
+	return Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		id, _, err := browser.GetWindowForTarget().Do(ctx)
+		if err != nil {
+			return fmt.Errorf("could not find the browser window: %w", err)
+		}
+		return browser.SetWindowBounds(id, &browser.Bounds{WindowState: browser.WindowState(state)}).Do(ctx)
+	}))
+}
+
+// MoveWindow moves the window's top-left corner to (x, y), e.g. off-screen
+// or back into view. It restores a minimized or maximized window first,
+// since Chrome only moves normal ones. CDP leaves out zero coordinates, so
+// 0 is sent as 1.
+func MoveWindow(ctx context.Context, x, y int) error {
+	if err := SetWindowState(ctx, WindowNormal); err != nil {
+		return err
+	}
+	nonZero := func(v int) int64 {
+		if v == 0 {
+			return 1
+		}
+		return int64(v)
+	}
+	return Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		id, _, err := browser.GetWindowForTarget().Do(ctx)
+		if err != nil {
+			return fmt.Errorf("could not find the browser window: %w", err)
+		}
+		return browser.SetWindowBounds(id, &browser.Bounds{Left: nonZero(x), Top: nonZero(y)}).Do(ctx)
+	}))
+}
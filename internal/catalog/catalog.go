@@ -0,0 +1,475 @@
+// Package catalog keeps a persistent record of the dates seen on Yandex Disk
+// and the archives exported for them, across runs.
+//
+// All methods are safe to call on a nil *Catalog, which records nothing.
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// saveDelay is how long changes are gathered before the catalog file is
+// rewritten, so a run does not rewrite the whole file, inventories and
+// hashes included, for every change.
+const saveDelay = 2 * time.Second
+
+// FileName is the base name of the catalog files, which are kept in the
+// state directory, one per download directory (see appdirs.Dirs.Catalog).
+// Earlier versions kept the catalog in the download directory under this
+// name.
+const FileName = "yandex-catalog.json"
+
+// Status is the export status of a date.
+type Status string
+
+// Date statuses.
+const (
+	StatusSeen     Status = "seen"     // Listed on Yandex, not downloaded yet
+	StatusExported Status = "exported" // At least one archive saved
+	StatusFailed   Status = "failed"   // Last attempt failed
+)
+
+// Entry is the record of one date group of the Yandex timeline.
+type Entry struct {
+	Date      string    `json:"date"` // Label as shown by Yandex
+	Status    Status    `json:"status"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Archives  []string  `json:"archives,omitempty"` // Paths relative to the download directory, not to the catalog file
+	Begun     []string  `json:"begun,omitempty"`    // Names of the archives Chrome began downloading, saved or not
+	Files     []string  `json:"files,omitempty"`    // Extracted files, relative to the download directory
+	Bytes     int64     `json:"bytes,omitempty"`
+	Items     int       `json:"items,omitempty"` // Photos and videos in the date when last selected
+	Error     string    `json:"error,omitempty"`
+	// Failures counts the runs in which exporting the date failed since it
+	// was last exported. Blacklisted is set once they reach the threshold
+	// given to SetBlacklistAfter; later runs skip the date.
+	Failures    int       `json:"failures,omitempty"`
+	Blacklisted time.Time `json:"blacklisted,omitzero"`
+	// Photos lists the files of the date as of Inventoried, recorded by an
+	// inventory run without downloading anything.
+	Photos      []Photo   `json:"photos,omitempty"`
+	Inventoried time.Time `json:"inventoried,omitzero"`
+}
+
+// Photo is one file of a date as listed on Yandex.
+type Photo struct {
+	Name string `json:"name"`
+	Type string `json:"type"`           // photo, video or file
+	Size int64  `json:"size,omitempty"` // Bytes; 0 when Yandex didn't show it
+}
+
+// data is the on-disk format.
+type data struct {
+	// LastFullScan is the start of the last run that walked the whole
+	// timeline; dates not seen since then are no longer on Yandex.
+	LastFullScan time.Time         `json:"lastFullScan,omitzero"`
+	Dates        map[string]*Entry `json:"dates"`
+	// Hashes maps the SHA-256 of extracted files to the first file with
+	// that content, relative to the download directory (see Claim).
+	Hashes map[string]string `json:"hashes,omitempty"`
+}
+
+// Catalog is a catalog file loaded in memory. Changes are saved within
+// saveDelay, and at once by Flush.
+type Catalog struct {
+	path string
+	dir  string // Directory archive paths are relative to
+
+	mu             sync.Mutex
+	data           data
+	blacklistAfter int             // Failed runs that blacklist a date (0 disables)
+	failedThisRun  map[string]bool // Dates whose failure this run was counted
+	saving         *time.Timer     // Pending save, nil if there are no unsaved changes
+
+	writeMu sync.Mutex // Serializes writes of the file; taken before mu
+}
+
+// Open loads the catalog at path, or starts an empty one if it does not
+// exist yet. Archive paths are stored relative to dir, the download
+// directory.
+func Open(path, dir string) (*Catalog, error) {
+	c := &Catalog{path: path, dir: dir, data: data{Dates: make(map[string]*Entry)}, failedThisRun: make(map[string]bool)}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read catalog: %w", err)
+	}
+	if err := json.Unmarshal(b, &c.data); err != nil {
+		return nil, fmt.Errorf("could not parse catalog %s: %w", path, err)
+	}
+	if c.data.Dates == nil {
+		c.data.Dates = make(map[string]*Entry)
+	}
+	return c, nil
+}
+
+// Path returns the location of the catalog file.
+func (c *Catalog) Path() string {
+	return c.path
+}
+
+// Seen records that date is listed on Yandex.
+func (c *Catalog) Seen(date string) {
+	c.update(date, func(e *Entry) {
+		e.LastSeen = time.Now()
+	})
+}
+
+// Selected records the number of items Yandex reported for date.
+func (c *Catalog) Selected(date string, items int) {
+	c.update(date, func(e *Entry) {
+		e.Items = items
+	})
+}
+
+// Items returns the number of items Yandex reported for date when it was
+// last selected, or 0 if unknown.
+func (c *Catalog) Items(date string) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.data.Dates[date]; ok {
+		return e.Items
+	}
+	return 0
+}
+
+// Exported records an archive saved for date.
+func (c *Catalog) Exported(date, path string, bytes int64) {
+	if c == nil {
+		return
+	}
+	if rel, err := filepath.Rel(c.dir, path); err == nil {
+		path = rel
+	}
+	c.update(date, func(e *Entry) {
+		e.Status = StatusExported
+		e.Error = ""
+		e.Failures, e.Blacklisted = 0, time.Time{}
+		for _, a := range e.Archives {
+			if a == path {
+				return
+			}
+		}
+		e.Archives = append(e.Archives, path)
+		e.Bytes += bytes
+	})
+}
+
+// SavedArchives returns the paths of the archives recorded for date if it
+// is exported and all of them are still on disk with the total size
+// recorded for them, or nil otherwise.
+func (c *Catalog) SavedArchives(date string) []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.data.Dates[date]
+	if !ok || e.Status != StatusExported || len(e.Archives) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(e.Archives))
+	var total int64
+	for _, a := range e.Archives {
+		path := a
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.dir, path)
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		total += info.Size()
+		paths = append(paths, path)
+	}
+	if e.Bytes > 0 && total != e.Bytes {
+		return nil
+	}
+	return paths
+}
+
+// Inventoried records the files listed on Yandex for date, replacing the
+// previous inventory.
+func (c *Catalog) Inventoried(date string, photos []Photo) {
+	c.update(date, func(e *Entry) {
+		e.Photos = append([]Photo(nil), photos...)
+		e.Inventoried = time.Now()
+	})
+}
+
+// Photos returns the files of date recorded by Inventoried, or nil.
+func (c *Catalog) Photos(date string) []Photo {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.data.Dates[date]; ok {
+		return append([]Photo(nil), e.Photos...)
+	}
+	return nil
+}
+
+// Began records the name of an archive Chrome began downloading for date,
+// so an archive that never finished can still be found on disk by name.
+func (c *Catalog) Began(date, name string) {
+	c.update(date, func(e *Entry) {
+		for _, n := range e.Begun {
+			if n == name {
+				return
+			}
+		}
+		e.Begun = append(e.Begun, name)
+	})
+}
+
+// Extracted records the files extracted from one of date's archives.
+func (c *Catalog) Extracted(date string, files []string) {
+	if c == nil {
+		return
+	}
+	rel := make([]string, 0, len(files))
+	for _, f := range files {
+		if r, err := filepath.Rel(c.dir, f); err == nil {
+			f = r
+		}
+		rel = append(rel, f)
+	}
+	c.update(date, func(e *Entry) {
+		seen := make(map[string]bool, len(e.Files))
+		for _, f := range e.Files {
+			seen[f] = true
+		}
+		for _, f := range rel {
+			if !seen[f] {
+				e.Files = append(e.Files, f)
+			}
+		}
+	})
+}
+
+// Failed records that exporting date failed. An exported date keeps its
+// status, since its earlier archive is still on disk. Otherwise the first
+// failure of each run counts toward blacklisting the date.
+func (c *Catalog) Failed(date, message string) {
+	c.update(date, func(e *Entry) {
+		e.Error = message
+		if e.Status == StatusExported {
+			return
+		}
+		e.Status = StatusFailed
+		if c.failedThisRun[date] {
+			return
+		}
+		c.failedThisRun[date] = true
+		e.Failures++
+		if c.blacklistAfter > 0 && e.Failures >= c.blacklistAfter && e.Blacklisted.IsZero() {
+			e.Blacklisted = time.Now()
+			log.Printf("⛔ '%s' has failed in %d runs and is now blacklisted: later runs skip it unless -retry-blacklisted is set", date, e.Failures)
+		}
+	})
+}
+
+// SetBlacklistAfter sets the number of runs in which a date must fail to be
+// blacklisted (0 disables blacklisting; dates already blacklisted stay so).
+func (c *Catalog) SetBlacklistAfter(runs int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.blacklistAfter = runs
+}
+
+// Blacklisted returns the entry of date and whether the date is blacklisted.
+func (c *Catalog) Blacklisted(date string) (Entry, bool) {
+	if c == nil {
+		return Entry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.data.Dates[date]
+	if !ok || e.Blacklisted.IsZero() {
+		return Entry{}, false
+	}
+	return *e, true
+}
+
+// Blacklist returns the entries of the blacklisted dates, most recently seen first.
+func (c *Catalog) Blacklist() []Entry {
+	if c == nil {
+		return nil
+	}
+	var list []Entry
+	for _, e := range c.Entries() {
+		if !e.Blacklisted.IsZero() {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// Claim returns the absolute path of a file already on disk with the given
+// content hash, or records path as the file for hash and returns "".
+func (c *Catalog) Claim(hash, path string) string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rel, ok := c.data.Hashes[hash]; ok {
+		original := filepath.Join(c.dir, rel)
+		if _, err := os.Stat(original); err == nil {
+			return original
+		}
+	}
+	if c.data.Hashes == nil {
+		c.data.Hashes = make(map[string]string)
+	}
+	if rel, err := filepath.Rel(c.dir, path); err == nil {
+		path = rel
+	}
+	c.data.Hashes[hash] = path
+	c.changed()
+	return ""
+}
+
+// FullScan records that a run started at start walked the whole timeline.
+func (c *Catalog) FullScan(start time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data.LastFullScan = start
+	c.changed()
+}
+
+// LastFullScan returns the start of the last run that walked the whole timeline.
+func (c *Catalog) LastFullScan() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data.LastFullScan
+}
+
+// Lookup returns the entries of the dates an archive (saved, or only begun)
+// or extracted file belongs to. file may be a path relative to the download
+// directory, an absolute path or just a file name, which also matches the
+// inventoried files of each date.
+func (c *Catalog) Lookup(file string) []Entry {
+	if rel, err := filepath.Rel(c.dir, file); err == nil && filepath.IsAbs(file) {
+		file = rel
+	}
+	file = filepath.Clean(file)
+	byName := !strings.ContainsRune(file, filepath.Separator)
+
+	var found []Entry
+	for _, e := range c.Entries() {
+		match := byName && slices.ContainsFunc(e.Photos, func(p Photo) bool { return p.Name == file })
+		for _, f := range slices.Concat(e.Archives, e.Files, e.Begun) {
+			match = match || f == file || (byName && filepath.Base(f) == file)
+		}
+		if match {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// Entries returns a copy of all entries, most recently seen first.
+func (c *Catalog) Entries() []Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make([]Entry, 0, len(c.data.Dates))
+	for _, e := range c.data.Dates {
+		cp := *e
+		cp.Archives = append([]string(nil), e.Archives...)
+		cp.Files = append([]string(nil), e.Files...)
+		cp.Begun = append([]string(nil), e.Begun...)
+		cp.Photos = append([]Photo(nil), e.Photos...)
+		entries = append(entries, cp)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastSeen.After(entries[j].LastSeen)
+	})
+	return entries
+}
+
+// update applies fn to the entry of date, creating it if needed, and
+// schedules a save.
+func (c *Catalog) update(date string, fn func(*Entry)) {
+	if c == nil || date == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.data.Dates[date]
+	if !ok {
+		now := time.Now()
+		e = &Entry{Date: date, Status: StatusSeen, FirstSeen: now, LastSeen: now}
+		c.data.Dates[date] = e
+	}
+	fn(e)
+	c.changed()
+}
+
+// changed schedules a save of the catalog, unless one is pending. The
+// caller must hold c.mu.
+func (c *Catalog) changed() {
+	if c.saving == nil {
+		c.saving = time.AfterFunc(saveDelay, c.Flush)
+	}
+}
+
+// Flush saves the pending changes now. Call it when the run ends, so none
+// are lost when the process exits.
+func (c *Catalog) Flush() {
+	if c == nil {
+		return
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.mu.Lock()
+	if c.saving == nil {
+		c.mu.Unlock()
+		return
+	}
+	c.saving.Stop()
+	c.saving = nil
+	b, err := json.MarshalIndent(c.data, "", "  ")
+	c.mu.Unlock()
+	// The file is written without holding c.mu, so the export is not held
+	// up by the disk
+	c.save(b, err)
+}
+
+// save writes the marshaled catalog atomically. The caller must hold
+// c.writeMu.
+func (c *Catalog) save(b []byte, err error) {
+	if err == nil {
+		tmp := c.path + ".tmp"
+		if err = os.WriteFile(tmp, b, 0644); err == nil {
+			err = os.Rename(tmp, c.path)
+		}
+	}
+	if err != nil {
+		// Losing the catalog must not stop the export
+		log.Printf("Warning: could not save catalog: %v", err)
+	}
+}
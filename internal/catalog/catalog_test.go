@@ -0,0 +1,39 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlush(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	c, err := Open(path, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, date := range []string{"1 January", "2 January", "3 January"} {
+		c.Seen(date)
+		c.Exported(date, filepath.Join(dir, date+".zip"), 10)
+	}
+	// Changes are gathered rather than written one by one
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("catalog written before the save delay: %v", err)
+	}
+
+	c.Flush()
+	reopened, err := Open(path, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := reopened.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("%d dates saved, want 3", len(entries))
+	}
+	for _, e := range entries {
+		if e.Status != StatusExported || len(e.Archives) != 1 {
+			t.Errorf("%s saved as %s with archives %v", e.Date, e.Status, e.Archives)
+		}
+	}
+}
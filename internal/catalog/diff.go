@@ -0,0 +1,148 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/annotations"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/datelog"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/logging"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/progress"
+)
+
+// Diff compares the catalog with the files actually present on disk.
+type Diff struct {
+	// MissingLocally lists dates on Yandex that have no archive on disk,
+	// because they were never exported or their archives were removed.
+	MissingLocally []Entry
+	// NotRemote lists local archives and extracted files of dates that were
+	// not seen in the last full scan, i.e. that no longer exist on Yandex.
+	NotRemote []string
+	// Unknown lists local files that no catalog date accounts for.
+	Unknown []string
+	// FullScan reports whether a full timeline scan is on record. Without
+	// one, NotRemote cannot be computed.
+	FullScan bool
+}
+
+// Complete reports whether every date on Yandex has an archive on disk.
+func (d *Diff) Complete() bool {
+	return len(d.MissingLocally) == 0
+}
+
+// Compare scans the download directory and compares it with the catalog.
+func (c *Catalog) Compare() (*Diff, error) {
+	local, err := scan(c.dir, c.path)
+	if err != nil {
+		return nil, err
+	}
+
+	lastScan := c.LastFullScan()
+	d := &Diff{FullScan: !lastScan.IsZero()}
+	known := make(map[string]bool)
+
+	for _, e := range c.Entries() {
+		content := append(e.Archives, e.Files...)
+		onDisk := 0
+		for _, f := range content {
+			known[f] = true
+			if local[f] {
+				onDisk++
+			}
+		}
+		remote := !d.FullScan || !e.LastSeen.Before(lastScan)
+		switch {
+		case remote && onDisk == 0:
+			d.MissingLocally = append(d.MissingLocally, e)
+		case !remote:
+			for _, f := range content {
+				if local[f] {
+					d.NotRemote = append(d.NotRemote, f)
+				}
+			}
+		}
+	}
+
+	for f := range local {
+		if !known[f] {
+			d.Unknown = append(d.Unknown, f)
+		}
+	}
+	sort.Strings(d.NotRemote)
+	sort.Strings(d.Unknown)
+	return d, nil
+}
+
+// scan returns the regular files under dir, relative to it, skipping the
+// catalog itself, the progress file, the tool's logs and unfinished downloads.
+func scan(dir, catalogPath string) (map[string]bool, error) {
+	files := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path == filepath.Join(dir, datelog.DirName) {
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() || path == catalogPath || strings.HasSuffix(path, ".crdownload") ||
+			strings.HasPrefix(d.Name(), FileName) || strings.HasPrefix(d.Name(), progress.FileName) ||
+			d.Name() == logging.ErrorLogName || d.Name() == annotations.FileName {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[rel] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not scan %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// Print writes the diff to w in a human-readable form.
+func (d *Diff) Print(w io.Writer) {
+	fmt.Fprintln(w)
+	if !d.FullScan {
+		fmt.Fprintln(w, "⚠️  No full export run on record (one without -from/-to that reached the end")
+		fmt.Fprintln(w, "   of the timeline), so files deleted on Yandex cannot be detected yet.")
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "📥 On Yandex but missing locally: %d date(s)\n", len(d.MissingLocally))
+	for _, e := range d.MissingLocally {
+		reason := string(e.Status)
+		if e.Error != "" {
+			reason += ": " + e.Error
+		} else if len(e.Archives) > 0 {
+			reason = "files removed from disk"
+		}
+		if e.Items > 0 {
+			reason = fmt.Sprintf("%d items, %s", e.Items, reason)
+		}
+		fmt.Fprintf(w, "   - %s (%s)\n", e.Date, reason)
+	}
+
+	fmt.Fprintf(w, "🗑️  On disk but no longer on Yandex: %d file(s)\n", len(d.NotRemote))
+	for _, f := range d.NotRemote {
+		fmt.Fprintf(w, "   - %s\n", f)
+	}
+
+	fmt.Fprintf(w, "❓ On disk but not in the catalog: %d file(s)\n", len(d.Unknown))
+	for _, f := range d.Unknown {
+		fmt.Fprintf(w, "   - %s\n", f)
+	}
+
+	fmt.Fprintln(w)
+	if d.Complete() {
+		fmt.Fprintln(w, "✅ Every date seen on Yandex has an archive on disk.")
+	} else {
+		fmt.Fprintln(w, "❌ The export is incomplete. Run the exporter again to fetch the missing dates.")
+	}
+}
@@ -0,0 +1,138 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/download"
+)
+
+// ArchiveCheck is the result of checking one zip archive on disk.
+type ArchiveCheck struct {
+	Path  string   // Relative to the checked directory
+	Files int      // Files in the archive
+	Err   error    // Why the archive is unusable (nil if it is valid)
+	Dates []string // Catalog dates the archive was downloaded for
+}
+
+// Redownload is a catalog date without a valid, complete archive.
+type Redownload struct {
+	Entry
+	Reason string
+}
+
+// Verification is the result of VerifyArchives.
+type Verification struct {
+	Dir        string
+	Archives   []ArchiveCheck // Sorted by path
+	Redownload []Redownload   // Dates still on Yandex that need downloading again, most recently seen first
+	FullScan   bool           // See Diff.FullScan
+}
+
+// Complete reports whether every date on Yandex has a valid, complete archive.
+func (v *Verification) Complete() bool {
+	return len(v.Redownload) == 0
+}
+
+// VerifyArchives test-extracts every zip archive under dir, such as the
+// archives of an earlier run or of a manual export, and matches them by
+// name with the catalog's dates. Dates still on Yandex that have no valid
+// archive with as many files as items were selected (or, for dates never
+// selected, as files were inventoried) need downloading again.
+func (c *Catalog) VerifyArchives(dir string) (*Verification, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() && strings.EqualFold(filepath.Ext(path), ".zip") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not scan %s: %w", dir, err)
+	}
+	sort.Strings(paths)
+
+	lastScan := c.LastFullScan()
+	v := &Verification{Dir: dir, FullScan: !lastScan.IsZero()}
+	best := make(map[string]ArchiveCheck)   // Most complete valid archive by date
+	broken := make(map[string]ArchiveCheck) // An unusable archive by date
+	for _, path := range paths {
+		check := ArchiveCheck{Path: path}
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			check.Path = rel
+		}
+		check.Files, check.Err = download.CountArchive(path)
+		for _, e := range c.Lookup(filepath.Base(path)) {
+			check.Dates = append(check.Dates, e.Date)
+			switch b, ok := best[e.Date]; {
+			case check.Err != nil:
+				broken[e.Date] = check
+			case !ok || check.Files > b.Files:
+				best[e.Date] = check
+			}
+		}
+		v.Archives = append(v.Archives, check)
+	}
+	for _, e := range c.Entries() {
+		if v.FullScan && e.LastSeen.Before(lastScan) {
+			continue // No longer on Yandex
+		}
+		want := e.Items
+		if want == 0 {
+			want = len(e.Photos)
+		}
+		b, ok := best[e.Date]
+		switch {
+		case ok && (want == 0 || b.Files >= want):
+			continue
+		case ok:
+			v.Redownload = append(v.Redownload, Redownload{e, fmt.Sprintf("%s has %d of %d items", b.Path, b.Files, want)})
+		case broken[e.Date].Err != nil:
+			v.Redownload = append(v.Redownload, Redownload{e, broken[e.Date].Err.Error()})
+		default:
+			v.Redownload = append(v.Redownload, Redownload{e, "no archive found"})
+		}
+	}
+	return v, nil
+}
+
+// Print writes the verification to w in a human-readable form.
+func (v *Verification) Print(w io.Writer) {
+	fmt.Fprintln(w)
+	valid := 0
+	for _, a := range v.Archives {
+		if a.Err == nil {
+			valid++
+		}
+	}
+	fmt.Fprintf(w, "🗂️  Archives in %s: %d (%d valid)\n", v.Dir, len(v.Archives), valid)
+	for _, a := range v.Archives {
+		switch {
+		case a.Err != nil:
+			fmt.Fprintf(w, "   ❌ %s: %v\n", a.Path, a.Err)
+		case len(a.Dates) == 0:
+			fmt.Fprintf(w, "   ❓ %s: %d files, not in the catalog\n", a.Path, a.Files)
+		default:
+			fmt.Fprintf(w, "   ✓ %s: %d files (%s)\n", a.Path, a.Files, strings.Join(a.Dates, ", "))
+		}
+	}
+
+	fmt.Fprintf(w, "📥 Dates to download again: %d\n", len(v.Redownload))
+	for _, r := range v.Redownload {
+		fmt.Fprintf(w, "   - %s (%s)\n", r.Date, r.Reason)
+	}
+
+	fmt.Fprintln(w)
+	if v.Complete() {
+		fmt.Fprintln(w, "✅ Every date seen on Yandex has a valid, complete archive.")
+	} else {
+		fmt.Fprintln(w, "❌ Some dates need downloading again. Run the exporter with -from/-to around them.")
+	}
+}
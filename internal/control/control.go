@@ -0,0 +1,103 @@
+// Package control lets the user pause, resume or stop a running export
+// between dates.
+//
+// All methods are safe to call on a nil *Controller, which never pauses.
+package control
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrStopped is returned by Wait once Stop has been requested.
+var ErrStopped = errors.New("export stopped by user")
+
+// State is the requested run state.
+type State string
+
+// Run states.
+const (
+	Running  State = "running"
+	Paused   State = "paused"
+	Stopping State = "stopping"
+)
+
+// Controller holds the requested run state. Requests take effect after the
+// date being processed, so a download is never interrupted half-way.
+type Controller struct {
+	mu      sync.Mutex
+	state   State
+	changed chan struct{} // Closed and replaced on every state change
+}
+
+// New creates a Controller in the running state.
+func New() *Controller {
+	return &Controller{state: Running, changed: make(chan struct{})}
+}
+
+// State returns the requested run state.
+func (c *Controller) State() State {
+	if c == nil {
+		return Running
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Pause requests a pause after the current date.
+func (c *Controller) Pause() {
+	c.set(Paused)
+}
+
+// Resume continues a paused run.
+func (c *Controller) Resume() {
+	c.set(Running)
+}
+
+// Stop requests the run to end after the current date.
+func (c *Controller) Stop() {
+	c.set(Stopping)
+}
+
+// set changes the state and wakes up waiters. A stop cannot be undone.
+func (c *Controller) set(s State) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == s || c.state == Stopping {
+		return
+	}
+	c.state = s
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// Wait blocks while the run is paused. It returns ErrStopped if a stop was
+// requested, or the context error if ctx ends first.
+func (c *Controller) Wait(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	for {
+		c.mu.Lock()
+		state, changed := c.state, c.changed
+		c.mu.Unlock()
+
+		switch state {
+		case Running:
+			return nil
+		case Stopping:
+			return ErrStopped
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
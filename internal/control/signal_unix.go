@@ -0,0 +1,31 @@
+//go:build !windows
+
+package control
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandleSignals pauses the run on SIGUSR1 and resumes it on SIGUSR2, e.g.
+// `kill -USR1 <pid>` to free the bandwidth for a while.
+func (c *Controller) HandleSignals() {
+	if c == nil {
+		return
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for sig := range ch {
+			if sig == syscall.SIGUSR1 {
+				log.Println("⏸️ SIGUSR1 received. Pausing after the current date...")
+				c.Pause()
+			} else {
+				log.Println("▶️ SIGUSR2 received. Resuming...")
+				c.Resume()
+			}
+		}
+	}()
+}
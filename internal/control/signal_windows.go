@@ -0,0 +1,7 @@
+//go:build windows
+
+package control
+
+// HandleSignals does nothing on Windows, which has no SIGUSR1/SIGUSR2; use
+// the web dashboard to pause and resume instead.
+func (c *Controller) HandleSignals() {}
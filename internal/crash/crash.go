@@ -0,0 +1,96 @@
+// Package crash turns an unexpected panic into a usable record of the run:
+// the panic and its stack are logged, the registered handlers save what
+// they can (report so far, debug bundle, progress file) and the process
+// exits with ExitCode, so scripts can tell a crash from an ordinary error.
+package crash
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ExitCode is the exit status after a panic: log.Fatal exits with 1 and an
+// unrecovered panic with 2.
+const ExitCode = 3
+
+// handlerTimeout bounds the handlers, which may wait on a lock the
+// panicking goroutine held or on a wedged browser.
+const handlerTimeout = 90 * time.Second
+
+// handler is a registered crash handler.
+type handler struct {
+	id int
+	fn func(reason error)
+}
+
+var (
+	mu       sync.Mutex
+	handlers []handler
+	nextID   int
+	once     sync.Once
+)
+
+// OnCrash registers fn to be called, in registration order, when a panic
+// reaches Recover. reason holds the panic value. The returned function
+// unregisters fn, for handlers tied to a part of the run that ends, such as
+// one browser.
+func OnCrash(fn func(reason error)) (unregister func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	nextID++
+	id := nextID
+	handlers = append(handlers, handler{id: id, fn: fn})
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		handlers = slices.DeleteFunc(handlers, func(h handler) bool { return h.id == id })
+	}
+}
+
+// Recover handles a panic of the calling goroutine. Defer it at the top of
+// main and of every goroutine doing the export's work: a panic in a
+// goroutine without it still ends the process without a record.
+func Recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+	// A second panicking goroutine waits here until the process exits
+	once.Do(func() {
+		log.Printf("💥 Unexpected panic: %v\n%s", r, stack)
+		reason := fmt.Errorf("panic: %v", r)
+
+		mu.Lock()
+		fns := slices.Clone(handlers)
+		mu.Unlock()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for _, h := range fns {
+				run(h.fn, reason)
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(handlerTimeout):
+			log.Printf("⚠️ Gave up saving the crash record after %s", handlerTimeout)
+		}
+		os.Exit(ExitCode)
+	})
+}
+
+// run calls a handler, so one that panics too doesn't stop the others.
+func run(fn func(reason error), reason error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ Crash handler failed: %v", r)
+		}
+	}()
+	fn(reason)
+}
@@ -3,6 +3,7 @@ package datefilter
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -63,7 +64,8 @@ func NewDateRange(from, to string) (*DateRange, error) {
 	return dr, nil
 }
 
-// monthMap maps English month names to month numbers.
+// monthMap maps English and Russian month names to month numbers. Russian
+// dates use the genitive ("12 января").
 var monthMap = map[string]time.Month{
 	"january":   time.January,
 	"february":  time.February,
@@ -77,10 +79,35 @@ var monthMap = map[string]time.Month{
 	"october":   time.October,
 	"november":  time.November,
 	"december":  time.December,
+	"января":    time.January,
+	"февраля":   time.February,
+	"марта":     time.March,
+	"апреля":    time.April,
+	"мая":       time.May,
+	"июня":      time.June,
+	"июля":      time.July,
+	"августа":   time.August,
+	"сентября":  time.September,
+	"октября":   time.October,
+	"ноября":    time.November,
+	"декабря":   time.December,
 }
 
-// datePattern matches "12 January" or "12 January 2023" format.
-var datePattern = regexp.MustCompile(`^(\d{1,2})\s+([A-Za-z]+)(?:\s+(\d{4}))?$`)
+// AddMonthNames makes ParseYandexDate accept the month names of another
+// interface language, given from January to December.
+func AddMonthNames(names []string) error {
+	if len(names) != 12 {
+		return fmt.Errorf("%d month names given, need 12 (January to December)", len(names))
+	}
+	for i, name := range names {
+		monthMap[strings.ToLower(name)] = time.Month(i + 1)
+	}
+	return nil
+}
+
+// datePattern matches "12 January" or "12 January 2023" format, in any
+// script ("12 января 2023").
+var datePattern = regexp.MustCompile(`^(\d{1,2})\s+(\p{L}+)(?:\s+(\d{4}))?$`)
 
 // ParseYandexDate parses a date string from Yandex Disk format.
 // Formats: "12 January" (assumes current year) or "12 January 2023"
@@ -168,6 +195,57 @@ func (dr *DateRange) IsAfterRange(dateText string) bool {
 	return parsedDate.After(dr.To)
 }
 
+// DaysAfterRange returns how many days a date is after the range end, or 0
+// if it is not after the range or cannot be parsed. The exporter uses it to
+// decide whether to fast-forward through the timeline.
+func (dr *DateRange) DaysAfterRange(dateText string) int {
+	if !dr.Enabled {
+		return 0
+	}
+
+	parsedDate, err := ParseYandexDate(dateText)
+	if err != nil || !parsedDate.After(dr.To) {
+		return 0
+	}
+
+	return int(parsedDate.Sub(dr.To).Hours() / 24)
+}
+
+// Split divides the range into up to n contiguous, non-overlapping sub-ranges
+// ordered from newest to oldest, matching the order of the Yandex timeline.
+// A disabled range or n < 2 returns the range itself.
+func (dr *DateRange) Split(n int) []*DateRange {
+	if !dr.Enabled || n < 2 {
+		return []*DateRange{dr}
+	}
+
+	from := truncateDay(dr.From)
+	to := truncateDay(dr.To)
+	days := int(to.Sub(from).Hours()/24) + 1
+	if n > days {
+		n = days
+	}
+
+	shards := make([]*DateRange, 0, n)
+	end := to
+	for i := 0; i < n; i++ {
+		// Spread the remainder over the first shards
+		size := days / n
+		if i < days%n {
+			size++
+		}
+		start := end.AddDate(0, 0, -(size - 1))
+		shards = append(shards, &DateRange{From: start, To: end, Enabled: true})
+		end = start.AddDate(0, 0, -1)
+	}
+	return shards
+}
+
+// truncateDay returns t at midnight UTC.
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
 // String returns a human-readable representation of the date range.
 func (dr *DateRange) String() string {
 	if !dr.Enabled {
@@ -175,3 +253,63 @@ func (dr *DateRange) String() string {
 	}
 	return fmt.Sprintf("%s to %s", dr.From.Format("2006-01-02"), dr.To.Format("2006-01-02"))
 }
+
+// DirName returns a file-system friendly name for a Yandex date label: the
+// date as YYYY-MM-DD, so folders sort chronologically, or the label itself
+// with spaces replaced if it cannot be parsed.
+func DirName(dateText string) string {
+	if t, err := ParseYandexDate(dateText); err == nil {
+		return t.Format("2006-01-02")
+	}
+	return strings.NewReplacer(" ", "-", "/", "-", "\\", "-").Replace(strings.TrimSpace(dateText))
+}
+
+// dirTokens matches the {tokens} of a download directory template.
+var dirTokens = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// ValidateDirTemplate checks that a download directory template only uses
+// {year}, {month}, {day} and {date}.
+func ValidateDirTemplate(tmpl string) error {
+	for _, m := range dirTokens.FindAllStringSubmatch(tmpl, -1) {
+		switch m[1] {
+		case "year", "month", "day", "date":
+		default:
+			return fmt.Errorf("unknown token {%s} (use {year}, {month}, {day} or {date})", m[1])
+		}
+	}
+	return nil
+}
+
+// ExpandDirTemplate resolves a download directory template such as
+// "~/Photos/{year}/{month}" for a Yandex date label. It fails if the label
+// cannot be parsed.
+func ExpandDirTemplate(tmpl, dateText string) (string, error) {
+	t, err := ParseYandexDate(dateText)
+	if err != nil {
+		return "", err
+	}
+	return dirTokens.ReplaceAllStringFunc(tmpl, func(tok string) string {
+		switch tok {
+		case "{year}":
+			return t.Format("2006")
+		case "{month}":
+			return t.Format("01")
+		case "{day}":
+			return t.Format("02")
+		case "{date}":
+			return t.Format("2006-01-02")
+		}
+		return tok
+	}), nil
+}
+
+// TemplateBase returns the fixed part of a download directory template: the
+// deepest directory that contains no token.
+func TemplateBase(tmpl string) string {
+	i := strings.Index(tmpl, "{")
+	if i < 0 {
+		return tmpl
+	}
+	// The placeholder stands for the templated element, which Dir drops
+	return filepath.Dir(tmpl[:i] + "_")
+}
@@ -0,0 +1,158 @@
+// Package datelog writes a small JSON-lines log for each processed date
+// into a logs/ directory: the log lines written while the date was being
+// processed (actions taken, selectors used, errors), the duration of each
+// step and the outcome. Debugging one problematic day out of thousands then
+// doesn't require searching the combined log of the whole run.
+//
+// All methods are safe to call on a nil *Logger, which writes nothing.
+package datelog
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/datefilter"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/logging"
+)
+
+// DirName is the name of the directory of per-date logs in the download
+// directory of earlier versions, which now write them to the log directory.
+const DirName = "logs"
+
+// maxPending bounds the log lines kept while no date is being processed,
+// e.g. while scrolling through a long stretch without dates.
+const maxPending = 200
+
+// Record kinds.
+const (
+	KindLog  = "log"  // A line of the tool's log
+	KindStep = "step" // A step of the per-date cycle
+	KindEnd  = "end"  // The outcome of the date
+)
+
+// Record is one line of a per-date log.
+type Record struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Message  string    `json:"message,omitempty"`          // Log line, or outcome of the date
+	Step     string    `json:"step,omitempty"`             // Name of the step
+	Duration float64   `json:"duration_seconds,omitempty"` // Duration of the step
+	Error    string    `json:"error,omitempty"`
+}
+
+// Logger collects the records of the date being processed and writes them
+// to the date's file when it is done.
+type Logger struct {
+	dir string
+
+	mu      sync.Mutex
+	date    string // Date being processed ("" between dates)
+	records []Record
+	warned  bool // A write error was already logged
+}
+
+// New creates a Logger writing into dir.
+func New(dir string) *Logger {
+	return &Logger{dir: dir}
+}
+
+// Write implements io.Writer so the Logger can be attached to the standard
+// logger with io.MultiWriter. Lines written between dates are kept for the
+// next one, since they come from the step that finds it.
+func (l *Logger) Write(p []byte) (int, error) {
+	if l == nil {
+		return len(p), nil
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		line = strings.TrimSpace(logging.StripPrefix(line))
+		if line == "" {
+			continue
+		}
+		l.records = append(l.records, Record{Time: now, Kind: KindLog, Message: line})
+	}
+	if l.date == "" && len(l.records) > maxPending {
+		l.records = l.records[len(l.records)-maxPending:]
+	}
+	return len(p), nil
+}
+
+// Start begins the log of date.
+func (l *Logger) Start(date string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.date = date
+}
+
+// Step records a step of the date's cycle.
+func (l *Logger) Step(step string, d time.Duration, err error) {
+	if l == nil {
+		return
+	}
+	r := Record{Time: time.Now(), Kind: KindStep, Step: step, Duration: d.Seconds()}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.date != "" {
+		l.records = append(l.records, r)
+	}
+}
+
+// Finish records the outcome of the date and appends its records to
+// logs/YYYY-MM-DD.jsonl, so a date processed again keeps both attempts.
+// With write false, the records are dropped instead, e.g. for dates outside
+// the requested range.
+func (l *Logger) Finish(outcome string, err error, write bool) {
+	if l == nil {
+		return
+	}
+	r := Record{Time: time.Now(), Kind: KindEnd, Message: outcome}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	l.mu.Lock()
+	date, records := l.date, append(l.records, r)
+	l.date, l.records = "", nil
+	l.mu.Unlock()
+	if date == "" || !write {
+		return
+	}
+
+	// Written without holding l.mu: a warning goes through Write
+	err = l.write(filepath.Join(l.dir, datefilter.DirName(date)+".jsonl"), records)
+	if err != nil && !l.warned {
+		l.warned = true
+		log.Printf("Warning: could not write the log of '%s': %v", date, err)
+	}
+}
+
+// write appends records to the file at path.
+func (l *Logger) write(path string, records []Record) error {
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
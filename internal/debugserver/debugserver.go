@@ -0,0 +1,35 @@
+// Package debugserver exposes net/http/pprof so memory growth and goroutine
+// leaks during multi-hour runs can be profiled while the export is running.
+package debugserver
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+// Start listens on addr (e.g. ":6060" or "localhost:6060") and serves the
+// pprof endpoints under /debug/pprof/ in the background. It returns the
+// address actually bound, which differs from addr when the port is 0.
+func Start(addr string) (string, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("could not start debug server on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			log.Printf("⚠️ Debug server stopped: %v", err)
+		}
+	}()
+	return ln.Addr().String(), nil
+}
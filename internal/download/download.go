@@ -3,32 +3,90 @@ package download
 
 import (
 	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
 
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/locale"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/logging"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
 	"github.com/chromedp/chromedp"
 )
 
-// ClickDownloadButton finds and clicks the Download button.
+const (
+	// ButtonTimeout is how long to wait for the Download button to appear after selecting.
+	ButtonTimeout = 5 * time.Second
+	// MenuTimeout is how long to wait for the context menu to list Download.
+	MenuTimeout = 2 * time.Second
+)
+
+// ErrButtonNotFound is returned when no Download button is present on the page.
+var ErrButtonNotFound = errors.New("download button not found")
+
+// WaitForButton waits until the selection toolbar shows the Download button.
+func WaitForButton(ctx context.Context) error {
+	return browser.WaitReact(ctx, browser.ReactToolbar, scripts.Call("download_button", "find", locale.Current().Download), ButtonTimeout)
+}
+
+// isDownloadName matches the accessible name of the Download button.
+func isDownloadName(name string) bool {
+	return slices.Contains(locale.Current().Download, name)
+}
+
+// ClickDownloadButton finds and clicks the Download button, locating it by
+// role and accessible name first and by text and attributes as a fallback.
+// If the toolbar has no such button, e.g. with an unknown label or a
+// redesigned toolbar, Download is chosen from the context menu of a
+// selected photo instead.
 func ClickDownloadButton(ctx context.Context) error {
-	return chromedp.Run(ctx,
-		chromedp.Evaluate(`
-			(function() {
-				const buttons = document.querySelectorAll('button, [role="button"]');
-				for (const btn of buttons) {
-					const text = btn.textContent?.trim() || '';
-					const ariaLabel = btn.getAttribute('aria-label') || '';
-					const title = btn.getAttribute('title') || '';
-					
-					if (text === 'Download' || 
-						text === 'Скачать' ||
-						ariaLabel.includes('Download') ||
-						ariaLabel.includes('Скачать') ||
-						title.includes('Download')) {
-						btn.click();
-						return 'clicked';
-					}
-				}
-				return 'not found';
-			})()
-		`, nil),
-	)
+	if _, err := browser.ClickByRole(ctx, "button", isDownloadName); err == nil {
+		return nil
+	} else if browser.IsBrowserClosed(err) {
+		return err
+	}
+
+	var clicked bool
+	if err := browser.Evaluate(ctx, scripts.Call("download_button", "click", locale.Current().Download), &clicked); err != nil {
+		return err
+	}
+	if clicked {
+		return nil
+	}
+	log.Println("Download button not found; trying the context menu...")
+	return downloadFromContextMenu(ctx)
+}
+
+// downloadFromContextMenu right-clicks a selected photo and chooses
+// Download from the menu that opens. The menu applies to the whole
+// selection. It returns ErrButtonNotFound if no selected photo is on screen
+// or the menu has no Download entry.
+func downloadFromContextMenu(ctx context.Context) error {
+	var item *struct{ X, Y float64 }
+	if err := browser.Evaluate(ctx, scripts.Call("selected_item"), &item); err != nil {
+		return err
+	}
+	if item == nil {
+		return ErrButtonNotFound
+	}
+	if err := browser.MouseClickXY(ctx, item.X, item.Y, chromedp.ButtonRight); err != nil {
+		return err
+	}
+
+	labels := locale.Current().Download
+	if _, err := browser.ClickByRole(ctx, "menuitem", isDownloadName); err == nil {
+		return nil
+	} else if browser.IsBrowserClosed(err) {
+		return err
+	}
+	if err := browser.WaitFor(ctx, scripts.Call("context_menu_item", labels), MenuTimeout); err != nil {
+		if browser.IsBrowserClosed(err) {
+			return err
+		}
+		browser.KeyEvent(ctx, "\x1b") // Close the menu
+		return ErrButtonNotFound
+	}
+	logging.Debugf("Download chosen from the context menu")
+	return nil
 }
@@ -0,0 +1,94 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/locale"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/logging"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/retry"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
+)
+
+// StartTimeout is how long to wait for a click on Download to take effect.
+const StartTimeout = 4 * time.Second
+
+// Kinds of download failures reported by Yandex notifications.
+const (
+	KindQuota     = "quota"      // Download or traffic limit reached
+	KindRateLimit = "rate-limit" // Too many requests
+	KindFailed    = "failed"     // Generic "download failed" / "something went wrong"
+)
+
+// NotificationError is a download failure reported by a Yandex notification.
+type NotificationError struct {
+	Kind    string
+	Message string
+}
+
+func (e *NotificationError) Error() string {
+	return fmt.Sprintf("Yandex reported %s: %s", e.Kind, e.Message)
+}
+
+// ErrNotStarted is returned by WaitForStart when a click on Download had no
+// visible effect, which happens when the page is busy and drops the click.
+var ErrNotStarted = errors.New("download did not start")
+
+// State returns a summary of the page's download feedback (notifications,
+// progress bars and the Download button's state). Take it before clicking
+// Download and pass it to WaitForStart.
+func State(ctx context.Context) string {
+	var state string
+	if err := browser.Evaluate(ctx, scripts.Call("download_state", locale.Current().Download), &state); err != nil {
+		return ""
+	}
+	return state
+}
+
+// WaitForStart waits up to StartTimeout for a click on Download to take
+// effect: a download begun after mark in t, or a change of the page's
+// download feedback from before (see State). It returns a
+// *NotificationError if Yandex reports an error instead, and ErrNotStarted
+// if nothing happened, so the click can be retried. Quota errors are marked
+// as permanent, since retrying cannot succeed until the limit resets.
+func WaitForStart(ctx context.Context, t *Tracker, mark int, before string) error {
+	deadline := time.Now().Add(StartTimeout)
+	for {
+		var toast *struct {
+			Kind string `json:"kind"`
+			Text string `json:"text"`
+		}
+		err := browser.Evaluate(ctx, scripts.Call("error_toast"), &toast)
+		if err != nil && (browser.IsBrowserClosed(err) || browser.IsContextCanceled(ctx)) {
+			return err
+		}
+		if toast != nil {
+			notifErr := &NotificationError{Kind: toast.Kind, Message: toast.Text}
+			if notifErr.Kind == KindQuota {
+				return retry.Permanent(notifErr)
+			}
+			return notifErr
+		}
+
+		if t.Began(mark) {
+			logging.Debugf("Download start confirmed: Chrome began a download")
+			return nil
+		}
+		if state := State(ctx); state != "" && state != before {
+			logging.Debugf("Download start confirmed: page feedback changed")
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: no notification, progress or download within %v", ErrNotStarted, StartTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(browser.PollInterval):
+		}
+	}
+}
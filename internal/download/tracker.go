@@ -0,0 +1,311 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/fsutil"
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/chromedp"
+)
+
+// ErrCanceled is returned by Tracker.Wait when Chrome canceled the download.
+var ErrCanceled = errors.New("download canceled")
+
+// File is a download saved by Chrome.
+type File struct {
+	Name  string // Name suggested by Yandex
+	Path  string // Location on disk
+	Bytes int64
+	Date  string // Date whose Download click the file answers (see Expect)
+}
+
+// Tracker follows the downloads Chrome saves from a tab, in the order they
+// began, so a click on Download can be matched with the file it produced.
+type Tracker struct {
+	dir     string
+	dateDir func(date string) string // Folder for a date's downloads (nil keeps them in dir)
+
+	mu       sync.Mutex
+	expected []string            // Date each download answers, by begin order ("" if unknown)
+	begun    []func(File)        // Called when a download begins
+	finished []func(File, error) // Called when a download completes or is canceled
+	order    []string            // GUIDs in the order downloads began
+	files    map[string]*File    // By GUID
+	done     map[string]error    // Finished downloads by GUID (nil error on success)
+	moving   map[string]bool     // Completed downloads still being moved to their date's folder
+	changed  chan struct{}       // Closed and replaced on every event
+}
+
+// NewTracker creates a Tracker for downloads saved into dir.
+func NewTracker(dir string) *Tracker {
+	return &Tracker{
+		dir:     dir,
+		files:   make(map[string]*File),
+		done:    make(map[string]error),
+		moving:  make(map[string]bool),
+		changed: make(chan struct{}),
+	}
+}
+
+// Watch starts following the downloads of the given tab. Downloads must be
+// configured with events enabled (see browser.ConfigureDownloads).
+func (t *Tracker) Watch(ctx context.Context) {
+	chromedp.ListenTarget(ctx, func(ev any) {
+		switch ev := ev.(type) {
+		case *cdpbrowser.EventDownloadWillBegin:
+			t.mu.Lock()
+			var date string
+			if n := len(t.order); n < len(t.expected) {
+				date = t.expected[n]
+			}
+			t.order = append(t.order, ev.GUID)
+			f := &File{Name: ev.SuggestedFilename, Path: filepath.Join(t.dir, ev.SuggestedFilename), Date: date}
+			t.files[ev.GUID] = f
+			t.notify()
+			file, callbacks := *f, t.begun
+			t.mu.Unlock()
+
+			for _, fn := range callbacks {
+				fn(file)
+			}
+		case *cdpbrowser.EventDownloadProgress:
+			if ev.State == cdpbrowser.DownloadProgressStateInProgress {
+				return
+			}
+			t.mu.Lock()
+			f, ok := t.files[ev.GUID]
+			_, finished := t.done[ev.GUID]
+			if !ok || finished || t.moving[ev.GUID] {
+				t.mu.Unlock()
+				return
+			}
+			if ev.State != cdpbrowser.DownloadProgressStateCompleted {
+				t.finish(ev.GUID, ErrCanceled)
+				return
+			}
+			f.Bytes = int64(ev.ReceivedBytes)
+			if ev.FilePath != "" {
+				f.Path = ev.FilePath
+			}
+			var dir string
+			if t.dateDir != nil && f.Date != "" {
+				dir = t.dateDir(f.Date)
+			}
+			if dir == "" || dir == filepath.Dir(f.Path) {
+				t.finish(ev.GUID, nil)
+				return
+			}
+			// Moving can mean copying an archive of several GB to another
+			// disk: do it off the event listener, and only report the
+			// download finished once the file is in place.
+			t.moving[ev.GUID] = true
+			file := *f
+			t.mu.Unlock()
+
+			go func(guid string) {
+				path := moveToDateDir(file, dir)
+				t.mu.Lock()
+				delete(t.moving, guid)
+				f.Path = path
+				t.finish(guid, nil)
+			}(ev.GUID)
+		}
+	})
+}
+
+// finish records a download as completed (nil err) or canceled, wakes up
+// waiters and runs the OnFinish callbacks. The caller must hold t.mu, which
+// finish releases.
+func (t *Tracker) finish(guid string, err error) {
+	t.done[guid] = err
+	t.notify()
+	file, callbacks := *t.files[guid], t.finished
+	t.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(file, err)
+	}
+}
+
+// SetDateDir makes the tracker move each finished download into the folder
+// dir returns for its date, so Wait and OnFinish callbacks see the final
+// location: a download only counts as finished once it was moved. Downloads stay where Chrome saved them if dir returns "".
+func (t *Tracker) SetDateDir(dir func(date string) string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dateDir = dir
+}
+
+// moveToDateDir moves a finished download into dir, adding a " (n)" suffix
+// like Chrome does if the name is taken, and returns its new path. The file
+// stays where Chrome saved it, and its path is returned unchanged, if the
+// move fails.
+func moveToDateDir(f File, dir string) string {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("⚠️ Could not create %s: %v", dir, err)
+		return f.Path
+	}
+	ext := filepath.Ext(f.Name)
+	stem := strings.TrimSuffix(filepath.Base(f.Path), ext)
+	target := filepath.Join(dir, stem+ext)
+	for n := 1; ; n++ {
+		if _, err := os.Lstat(target); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		target = filepath.Join(dir, stem+" ("+strconv.Itoa(n)+")"+ext)
+	}
+	if err := fsutil.Move(f.Path, target); err != nil {
+		log.Printf("⚠️ Could not move %s to %s: %v", f.Name, dir, err)
+		return f.Path
+	}
+	return target
+}
+
+// Expect records that the next download to begin answers a Download click
+// for date, and returns the mark to pass to Wait and Began for it. Labels
+// are kept by begin order, so a download that begins after the next date
+// was requested still gets its own date. Requests whose download never
+// began are dropped, so call WaitBegun for the previous request first.
+func (t *Tracker) Expect(date string) int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := len(t.order)
+	if len(t.expected) > n {
+		t.expected = t.expected[:n]
+	}
+	for len(t.expected) < n {
+		t.expected = append(t.expected, "")
+	}
+	t.expected = append(t.expected, date)
+	return n
+}
+
+// OnBegin registers fn to be called when Chrome begins a download, with the
+// name Yandex suggested for it. Calls come from the event listener, so fn
+// must not block.
+func (t *Tracker) OnBegin(fn func(File)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.begun = append(t.begun, fn)
+}
+
+// OnFinish registers fn to be called when a download completes (nil error)
+// or is canceled. Calls come from the event listener, so fn must not block.
+func (t *Tracker) OnFinish(fn func(File, error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.finished = append(t.finished, fn)
+}
+
+// notify wakes up waiters. The caller must hold t.mu.
+func (t *Tracker) notify() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// Mark returns a marker for the downloads begun so far.
+func (t *Tracker) Mark() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.order)
+}
+
+// Began reports whether a download has begun since mark was taken.
+func (t *Tracker) Began(mark int) bool {
+	if t == nil {
+		return false
+	}
+	return t.Mark() > mark
+}
+
+// WaitBegun blocks until a download has begun since mark was taken, or
+// until timeout. It reports whether one began.
+func (t *Tracker) WaitBegun(ctx context.Context, mark int, timeout time.Duration) bool {
+	if t == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		t.mu.Lock()
+		changed, begun := t.changed, len(t.order) > mark
+		t.mu.Unlock()
+		if begun {
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// WaitAll blocks until every download that has begun has finished, or until
+// timeout. It reports whether downloads were still running.
+func (t *Tracker) WaitAll(ctx context.Context, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		t.mu.Lock()
+		changed, running := t.changed, len(t.order) > len(t.done)
+		t.mu.Unlock()
+		if !running {
+			return false
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return true
+		}
+	}
+}
+
+// Wait blocks until the first download begun after mark has finished and
+// returns the saved file.
+func (t *Tracker) Wait(parent context.Context, mark int, timeout time.Duration) (File, error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	for {
+		t.mu.Lock()
+		changed := t.changed
+		if mark < len(t.order) {
+			guid := t.order[mark]
+			if err, finished := t.done[guid]; finished {
+				f := *t.files[guid]
+				t.mu.Unlock()
+				return f, err
+			}
+		}
+		t.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			if parent.Err() != nil {
+				return File{}, parent.Err()
+			}
+			if mark < t.Mark() {
+				return File{}, fmt.Errorf("download did not finish within %v", timeout)
+			}
+			return File{}, fmt.Errorf("download did not begin within %v", timeout)
+		}
+	}
+}
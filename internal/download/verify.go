@@ -0,0 +1,102 @@
+package download
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// VerifyArchive checks a downloaded file against the number of photos that
+// were selected. Zip archives are test-extracted: every entry is decompressed
+// and its CRC-32 checked, and the number of files must equal want. Any other
+// file is a single photo, so want must be 1 and the size must match.
+func VerifyArchive(path string, size int64, want int) error {
+	if want <= 0 {
+		return errors.New("number of selected photos is unknown")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("downloaded file not found: %w", err)
+	}
+	if size > 0 && info.Size() != size {
+		return fmt.Errorf("%s is %d bytes, expected %d", filepath.Base(path), info.Size(), size)
+	}
+
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		if want != 1 {
+			return fmt.Errorf("%s is a single file but %d photos were selected", filepath.Base(path), want)
+		}
+		if info.Size() == 0 {
+			return fmt.Errorf("%s is empty", filepath.Base(path))
+		}
+		return nil
+	}
+
+	count, err := CountArchive(path)
+	if err != nil {
+		return err
+	}
+	if count != want {
+		return fmt.Errorf("%s contains %d files but %d photos were selected", filepath.Base(path), count, want)
+	}
+	return nil
+}
+
+// ArchiveEntries returns the number of files a zip archive lists, reading
+// only its directory, so it is fast but doesn't notice damaged entries.
+// Any other file counts as a single photo.
+func ArchiveEntries(path string) (int, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		return 1, nil
+	}
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return 0, fmt.Errorf("could not open %s: %w", filepath.Base(path), err)
+	}
+	defer r.Close()
+	count := 0
+	for _, f := range r.File {
+		if !f.FileInfo().IsDir() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CountArchive test-extracts a zip archive, decompressing every entry and
+// checking its CRC-32, and returns the number of files it holds.
+func CountArchive(path string) (int, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return 0, fmt.Errorf("could not open %s: %w", filepath.Base(path), err)
+	}
+	defer r.Close()
+
+	count := 0
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := checkEntry(f); err != nil {
+			return 0, fmt.Errorf("%s: %s: %w", filepath.Base(path), f.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// checkEntry decompresses a zip entry; the reader verifies the CRC-32 at EOF.
+func checkEntry(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
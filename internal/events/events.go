@@ -0,0 +1,89 @@
+// Package events emits significant export events as JSON lines, so wrapper
+// scripts and dashboards can follow a run without scraping the human log.
+//
+// All methods are safe to call on a nil *Emitter, which discards events.
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// Type identifies the kind of an event.
+type Type string
+
+// Event types.
+const (
+	RunStarted        Type = "run_started"
+	DateFound         Type = "date_found"
+	DateSelected      Type = "date_selected"
+	DateSkipped       Type = "date_skipped"
+	DownloadStarted   Type = "download_started"
+	DownloadCompleted Type = "download_completed"
+	DownloadFailed    Type = "download_failed"
+	DateTrashed       Type = "date_trashed"
+	DateMarked        Type = "date_marked"
+	Error             Type = "error"
+	Paused            Type = "paused"
+	Resumed           Type = "resumed"
+	RunFinished       Type = "run_finished"
+)
+
+// Event is one line of the stream.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    Type      `json:"type"`
+	Date    string    `json:"date,omitempty"`    // Date group label as shown by Yandex
+	File    string    `json:"file,omitempty"`    // Downloaded file name
+	Bytes   int64     `json:"bytes,omitempty"`   // Downloaded file size
+	Items   int       `json:"items,omitempty"`   // Photos and videos in the date
+	Message string    `json:"message,omitempty"` // Reason or summary
+}
+
+// Emitter writes events to a stream, one JSON object per line, and passes
+// them to subscribers.
+type Emitter struct {
+	mu          sync.Mutex
+	enc         *json.Encoder // nil when events only go to subscribers
+	subscribers []func(Event)
+}
+
+// New creates an Emitter writing to w (nil writes nothing).
+func New(w io.Writer) *Emitter {
+	e := &Emitter{}
+	if w != nil {
+		e.enc = json.NewEncoder(w)
+	}
+	return e
+}
+
+// Subscribe registers fn to receive every event. Calls are serialized.
+func (e *Emitter) Subscribe(fn func(Event)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.subscribers = append(e.subscribers, fn)
+}
+
+// Emit writes an event, stamping it with the current time if unset.
+func (e *Emitter) Emit(ev Event) {
+	if e == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, fn := range e.subscribers {
+		fn(ev)
+	}
+	if e.enc == nil {
+		return
+	}
+	if err := e.enc.Encode(ev); err != nil {
+		log.Printf("Warning: could not write event: %v", err)
+	}
+}
@@ -0,0 +1,304 @@
+// Package exif reads the few EXIF fields the exporter needs from photos,
+// without decoding the images: JPEG, TIFF-based raw formats and, by
+// scanning for the Exif block, HEIC.
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNoExif is returned for files without EXIF data.
+var ErrNoExif = errors.New("no EXIF data")
+
+const (
+	// scanLimit is how much of a file is searched for an Exif block when
+	// its format is not parsed directly.
+	scanLimit = 1 << 20
+
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagPixelXDimension  = 0xA002
+	tagPixelYDimension  = 0xA003
+
+	tagGPSLatitudeRef  = 1
+	tagGPSLatitude     = 2
+	tagGPSLongitudeRef = 3
+	tagGPSLongitude    = 4
+)
+
+// Info holds the EXIF fields read from a file.
+type Info struct {
+	Taken time.Time // Capture time in the camera's local time; zero if unknown
+
+	Make, Model   string // Camera maker and model; empty for screenshots and edited images
+	Width, Height int    // Image size in pixels; zero if unknown
+
+	HasGPS   bool    // Whether the photo is geotagged
+	Lat, Lon float64 // Decimal degrees, negative south and west
+}
+
+// Read reads the EXIF data of the file at path.
+func Read(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadFrom(f)
+}
+
+// ReadFrom reads the EXIF data from the start of a file. The image size is
+// also read from PNG and JPEG headers, so an Info without EXIF fields may be
+// returned for such files.
+func ReadFrom(r io.Reader) (*Info, error) {
+	head := make([]byte, scanLimit)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	info := &Info{}
+	info.Width, info.Height = imageSize(head)
+	tiff := findTIFF(head)
+	if tiff == nil {
+		if info.Width == 0 {
+			return nil, ErrNoExif
+		}
+		return info, nil
+	}
+	if err := parseTIFF(tiff, info); err != nil && info.Width == 0 {
+		return nil, err
+	}
+	return info, nil
+}
+
+// imageSize reads the size of a PNG or JPEG image from its header.
+func imageSize(b []byte) (int, int) {
+	switch {
+	case len(b) >= 24 && bytes.HasPrefix(b, []byte("\x89PNG\r\n\x1a\n")):
+		return int(binary.BigEndian.Uint32(b[16:])), int(binary.BigEndian.Uint32(b[20:]))
+	case len(b) > 2 && b[0] == 0xFF && b[1] == 0xD8:
+		for i := 2; i+9 <= len(b) && b[i] == 0xFF; {
+			marker := b[i+1]
+			if marker == 0xDA || marker == 0xD9 {
+				break
+			}
+			// Start of frame markers, except DHT, JPG and DAC
+			if marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC {
+				return int(binary.BigEndian.Uint16(b[i+7:])), int(binary.BigEndian.Uint16(b[i+5:]))
+			}
+			i += 2 + int(binary.BigEndian.Uint16(b[i+2:]))
+		}
+	}
+	return 0, 0
+}
+
+// findTIFF locates the TIFF structure holding the EXIF data.
+func findTIFF(b []byte) []byte {
+	switch {
+	case isTIFFHeader(b):
+		return b
+	case len(b) > 2 && b[0] == 0xFF && b[1] == 0xD8:
+		return findJPEGExif(b)
+	}
+	// HEIC and others: look for the Exif block header
+	for off := 0; ; {
+		i := bytes.Index(b[off:], []byte("Exif\x00\x00"))
+		if i < 0 {
+			return nil
+		}
+		start := off + i + 6
+		if isTIFFHeader(b[start:]) {
+			return b[start:]
+		}
+		off = start
+	}
+}
+
+// findJPEGExif walks the JPEG segments up to the APP1 Exif segment.
+func findJPEGExif(b []byte) []byte {
+	for i := 2; i+4 <= len(b); {
+		if b[i] != 0xFF {
+			return nil
+		}
+		marker := b[i+1]
+		if marker == 0xDA || marker == 0xD9 { // Image data starts: no EXIF
+			return nil
+		}
+		size := int(binary.BigEndian.Uint16(b[i+2:]))
+		end := i + 2 + size
+		if size < 2 || end > len(b) {
+			return nil
+		}
+		if marker == 0xE1 && bytes.HasPrefix(b[i+4:end], []byte("Exif\x00\x00")) {
+			return b[i+10 : end]
+		}
+		i = end
+	}
+	return nil
+}
+
+func isTIFFHeader(b []byte) bool {
+	return len(b) >= 8 && (bytes.HasPrefix(b, []byte("II*\x00")) || bytes.HasPrefix(b, []byte("MM\x00*")))
+}
+
+// ifdEntry is a raw entry of a TIFF image file directory.
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value []byte // Count elements of typ, in the TIFF's byte order
+}
+
+// reader decodes a TIFF structure.
+type reader struct {
+	b     []byte
+	order binary.ByteOrder
+}
+
+// ifd reads the directory at off into a map by tag.
+func (r *reader) ifd(off uint32) map[uint16]ifdEntry {
+	entries := make(map[uint16]ifdEntry)
+	if int(off)+2 > len(r.b) {
+		return entries
+	}
+	n := int(r.order.Uint16(r.b[off:]))
+	for i := 0; i < n; i++ {
+		p := int(off) + 2 + i*12
+		if p+12 > len(r.b) {
+			break
+		}
+		tag := r.order.Uint16(r.b[p:])
+		typ := r.order.Uint16(r.b[p+2:])
+		count := r.order.Uint32(r.b[p+4:])
+		size := typeSize(typ) * int(count)
+		if size <= 0 || size > len(r.b) {
+			continue
+		}
+		value := r.b[p+8 : p+12]
+		if size > 4 {
+			at := int(r.order.Uint32(r.b[p+8:]))
+			if at+size > len(r.b) {
+				continue
+			}
+			value = r.b[at : at+size]
+		}
+		entries[tag] = ifdEntry{typ: typ, count: count, value: value[:min(size, len(value))]}
+	}
+	return entries
+}
+
+// typeSize returns the size of one element of a TIFF field type.
+func typeSize(typ uint16) int {
+	switch typ {
+	case 1, 2, 6, 7: // BYTE, ASCII, SBYTE, UNDEFINED
+		return 1
+	case 3, 8: // SHORT, SSHORT
+		return 2
+	case 4, 9, 11: // LONG, SLONG, FLOAT
+		return 4
+	case 5, 10, 12: // RATIONAL, SRATIONAL, DOUBLE
+		return 8
+	}
+	return 0
+}
+
+// long returns the first LONG or SHORT of an entry.
+func (r *reader) long(e ifdEntry) (uint32, bool) {
+	switch {
+	case e.typ == 4 && len(e.value) >= 4:
+		return r.order.Uint32(e.value), true
+	case e.typ == 3 && len(e.value) >= 2:
+		return uint32(r.order.Uint16(e.value)), true
+	}
+	return 0, false
+}
+
+// degrees converts a GPS coordinate of three RATIONALs (degrees, minutes,
+// seconds) to decimal degrees.
+func (r *reader) degrees(e ifdEntry) (float64, bool) {
+	if e.typ != 5 || len(e.value) < 24 {
+		return 0, false
+	}
+	var parts [3]float64
+	for i := range parts {
+		num := r.order.Uint32(e.value[i*8:])
+		den := r.order.Uint32(e.value[i*8+4:])
+		if den == 0 {
+			return 0, false
+		}
+		parts[i] = float64(num) / float64(den)
+	}
+	return parts[0] + parts[1]/60 + parts[2]/3600, true
+}
+
+// ascii returns an ASCII entry without its terminating NULs.
+func ascii(e ifdEntry) string {
+	if e.typ != 2 {
+		return ""
+	}
+	return strings.TrimRight(string(e.value), "\x00 ")
+}
+
+// parseTIFF reads the fields of info from a TIFF structure.
+func parseTIFF(b []byte, info *Info) error {
+	r := &reader{b: b, order: binary.LittleEndian}
+	if b[0] == 'M' {
+		r.order = binary.BigEndian
+	}
+	ifd0 := r.ifd(r.order.Uint32(b[4:]))
+	if len(ifd0) == 0 {
+		return ErrNoExif
+	}
+
+	info.Make = ascii(ifd0[tagMake])
+	info.Model = ascii(ifd0[tagModel])
+	taken := ascii(ifd0[tagDateTime])
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if off, ok := r.long(e); ok {
+			exifIFD := r.ifd(off)
+			if s := ascii(exifIFD[tagDateTimeOriginal]); s != "" {
+				taken = s
+			}
+			if info.Width == 0 {
+				w, okW := r.long(exifIFD[tagPixelXDimension])
+				h, okH := r.long(exifIFD[tagPixelYDimension])
+				if okW && okH {
+					info.Width, info.Height = int(w), int(h)
+				}
+			}
+		}
+	}
+	if t, err := time.ParseInLocation("2006:01:02 15:04:05", taken, time.Local); err == nil && t.Year() > 1900 {
+		info.Taken = t
+	}
+
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		if off, ok := r.long(e); ok {
+			gps := r.ifd(off)
+			lat, okLat := r.degrees(gps[tagGPSLatitude])
+			lon, okLon := r.degrees(gps[tagGPSLongitude])
+			// 0,0 is what some cameras write without a fix
+			if okLat && okLon && (lat != 0 || lon != 0) {
+				if ascii(gps[tagGPSLatitudeRef]) == "S" {
+					lat = -lat
+				}
+				if ascii(gps[tagGPSLongitudeRef]) == "W" {
+					lon = -lon
+				}
+				info.HasGPS, info.Lat, info.Lon = true, lat, lon
+			}
+		}
+	}
+	return nil
+}
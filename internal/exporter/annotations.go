@@ -0,0 +1,33 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/annotations"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+)
+
+// SaveAnnotations changes the cycle so that the titles and descriptions of
+// each date's photos are saved to f before the date is selected. It wraps
+// the current Select step, so it can follow PreviewOnly.
+func (l *Loop) SaveAnnotations(f *annotations.File) {
+	next := l.Step(StateSelect)
+	l.SetStep(StateSelect, func(ctx context.Context, c *Cycle) (State, error) {
+		list, err := annotations.Collect(c.DateCtx, c.Date)
+		if err == nil {
+			err = f.Add(c.Date.Text, list)
+		}
+		switch {
+		case err != nil && browser.IsBrowserClosed(err):
+			return StateDone, err
+		case err != nil:
+			log.Printf("⚠️ Could not save the titles and descriptions of '%s': %v", c.Date.Text, err)
+			c.Stats.AddError(c.Date.Text, fmt.Sprintf("Annotations: %v", err))
+		case len(list) > 0:
+			log.Printf("📝 %d photos of '%s' have a title or description", len(list), c.Date.Text)
+		}
+		return next(ctx, c)
+	})
+}
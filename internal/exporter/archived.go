@@ -0,0 +1,46 @@
+package exporter
+
+import (
+	"context"
+	"log"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/download"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/events"
+)
+
+// SkipArchived changes the cycle so that a date is scrolled past instead of
+// downloaded again when the archives the catalog records for it are still
+// in the download directory with their recorded size, open as zip
+// archives and hold as many files as the date had items when it was
+// downloaded. A run that was interrupted can then be repeated without
+// fetching its dates again. It wraps the current Select step.
+func (l *Loop) SkipArchived() {
+	next := l.Step(StateSelect)
+	l.SetStep(StateSelect, func(ctx context.Context, c *Cycle) (State, error) {
+		paths := c.Catalog.SavedArchives(c.Date.Text)
+		if len(paths) == 0 {
+			return next(ctx, c)
+		}
+		files := 0
+		for _, path := range paths {
+			n, err := download.ArchiveEntries(path)
+			if err != nil {
+				log.Printf("⚠️ The saved archive of '%s' is unusable, downloading it again: %v", c.Date.Text, err)
+				return next(ctx, c)
+			}
+			files += n
+		}
+		if want := c.Catalog.Items(c.Date.Text); want > 0 && files != want {
+			log.Printf("⚠️ The saved archives of '%s' hold %d files but %d items were selected, downloading it again", c.Date.Text, files, want)
+			return next(ctx, c)
+		}
+
+		log.Printf("⏭️ '%s' is already downloaded (%d files). Skipping...", c.Date.Text, files)
+		c.Stats.IncrementSkippedDates()
+		c.Events.Emit(events.Event{Type: events.DateSkipped, Date: c.Date.Text, Message: "already downloaded"})
+		c.LastScrollY = scrollPastDate(ctx, c.Date, c.LastScrollY)
+		c.ConsecutiveErrors = 0
+		c.finishDate()
+		return StateFindDate, nil
+	})
+}
@@ -0,0 +1,134 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+)
+
+// DefaultErrorThreshold is the number of failed attempts in a row, of the
+// same or different dates, that triggers the next ErrorAction.
+const DefaultErrorThreshold = 3
+
+// defaultErrorWait is how long the wait action pauses without a duration.
+const defaultErrorWait = time.Minute
+
+// ErrTooManyErrors is returned when the abort action ends the run.
+var ErrTooManyErrors = errors.New("too many consecutive errors")
+
+// RestartError is returned when the restart action asks for a new browser.
+// The caller closes the browser, starts a new one and passes ScrollY and
+// Actions as the Config's ResumeScrollY and ErrorActions, so the new run
+// carries on where this one stopped.
+type RestartError struct {
+	Failures int           // Failed attempts in a row that triggered the restart
+	ScrollY  float64       // Scroll offset after the last processed date
+	Actions  []ErrorAction // ErrorActions left after the restart (the restart itself if it was the last)
+}
+
+func (e *RestartError) Error() string {
+	return fmt.Sprintf("browser restart requested after %d consecutive errors", e.Failures)
+}
+
+// ActionKind is what an ErrorAction does.
+type ActionKind string
+
+// Recovery actions, from the mildest.
+const (
+	ActionWait    ActionKind = "wait"    // Pause, for a network or service hiccup to pass
+	ActionRefresh ActionKind = "refresh" // Reload the page and scroll back
+	ActionRestart ActionKind = "restart" // Close the browser and start a new one
+	ActionAbort   ActionKind = "abort"   // End the run with an error
+)
+
+// ErrorAction is a recovery step taken when ErrorThreshold failed attempts
+// come in a row.
+type ErrorAction struct {
+	Kind ActionKind
+	Wait time.Duration // Pause of ActionWait
+}
+
+func (a ErrorAction) String() string {
+	if a.Kind == ActionWait {
+		return fmt.Sprintf("%s:%v", a.Kind, a.Wait)
+	}
+	return string(a.Kind)
+}
+
+// ParseErrorActions parses a comma-separated list of recovery actions, such
+// as "wait:2m,refresh,restart,abort". A wait takes an optional duration
+// (1 minute by default).
+func ParseErrorActions(s string) ([]ErrorAction, error) {
+	var actions []ErrorAction
+	for _, field := range strings.Split(s, ",") {
+		kind, arg, hasArg := strings.Cut(strings.TrimSpace(field), ":")
+		a := ErrorAction{Kind: ActionKind(strings.ToLower(kind))}
+		switch a.Kind {
+		case ActionWait:
+			a.Wait = defaultErrorWait
+			if hasArg {
+				d, err := time.ParseDuration(arg)
+				if err != nil || d <= 0 {
+					return nil, fmt.Errorf("invalid wait %q (use e.g. wait:2m)", field)
+				}
+				a.Wait = d
+			}
+		case ActionRefresh, ActionRestart, ActionAbort:
+			if hasArg {
+				return nil, fmt.Errorf("%s takes no duration", a.Kind)
+			}
+		default:
+			return nil, fmt.Errorf("unknown action %q (use wait, refresh, restart or abort)", field)
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
+
+// onConsecutiveErrors takes the next recovery action once ErrorThreshold
+// failed attempts came in a row. Each time the threshold is reached again
+// before a download starts, the next action of ErrorActions is taken; the
+// last one repeats. Without ErrorActions the page is refreshed.
+func (c *Cycle) onConsecutiveErrors(ctx context.Context) (bool, error) {
+	threshold := c.ErrorThreshold
+	if threshold <= 0 {
+		threshold = DefaultErrorThreshold
+	}
+	if c.ConsecutiveErrors < threshold {
+		return false, nil
+	}
+
+	actions := []ErrorAction{{Kind: ActionRefresh}}
+	if len(c.ErrorActions) > 0 {
+		actions = c.ErrorActions[min(c.errorLevel, len(c.ErrorActions)-1):]
+	}
+	action := actions[0]
+	c.errorLevel++
+	failures := c.ConsecutiveErrors
+	c.ConsecutiveErrors = 0
+	log.Printf("⚠️ Too many consecutive errors (%d). Browser may be unresponsive; recovering with %s...", failures, action)
+
+	switch action.Kind {
+	case ActionWait:
+		log.Printf("⏸️ Waiting %v before trying again...", action.Wait)
+		browser.Pause(ctx, action.Wait)
+		return true, ctx.Err()
+	case ActionRestart:
+		if len(actions) > 1 {
+			actions = actions[1:]
+		}
+		return false, &RestartError{Failures: failures, ScrollY: c.LastScrollY, Actions: actions}
+	case ActionAbort:
+		return false, fmt.Errorf("%w (%d failed attempts in a row)", ErrTooManyErrors, failures)
+	default:
+		if err := refresh(ctx, c, "page refresh"); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+}
@@ -0,0 +1,287 @@
+// Package exporter drives the per-date export cycle as an explicit state
+// machine. Each state is handled by a Step that can be replaced, so features
+// such as batching, retries or resume can hook into the cycle.
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/catalog"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/control"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/datefilter"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/datelog"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/download"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/events"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/forensics"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/logging"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/navigation"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/report"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/selection"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/throttle"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/tracing"
+)
+
+// State identifies a step of the per-date cycle.
+type State int
+
+// States of the cycle, in the order a successfully exported date goes through them.
+const (
+	StateFindDate     State = iota // Recover if needed and locate the first visible date
+	StateFilter                    // Check the date against the requested date range
+	StateSelect                    // Select all photos of the date
+	StateVerify                    // Confirm the selection and wait for the toolbar
+	StateDownload                  // Click Download, watch for error notifications and follow the archive in the background
+	StateWaitComplete              // Check throttling signals after the download
+	StateDeselect                  // Clear the selection
+	StateAdvance                   // Scroll the date off screen and move on
+	StateDone                      // Stop the run
+)
+
+var stateNames = [...]string{"FindDate", "Filter", "Select", "Verify", "Download", "WaitComplete", "Deselect", "Advance", "Done"}
+
+func (s State) String() string {
+	if s < 0 || int(s) >= len(stateNames) {
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+	return stateNames[s]
+}
+
+// Step handles one state and returns the next one. Recoverable problems are
+// handled by choosing the next state; a returned error ends the run.
+type Step func(ctx context.Context, c *Cycle) (State, error)
+
+// Config holds the settings and collaborators of an export run.
+type Config struct {
+	PhotosURL   string
+	Filter      navigation.Filter // Timeline filter, re-applied after page reloads
+	DateRange   *datefilter.DateRange
+	DateTimeout time.Duration // Watchdog deadline for each date's select→download→deselect cycle
+	ReloadEvery int           // Reload the page every N processed dates (0 disables)
+	Forensics   *forensics.Collector
+	Pacer       *throttle.Pacer
+	Stats       *report.Stats
+	Tracer      *tracing.Tracer     // Records a span per date with a child span per step (nil disables)
+	Events      *events.Emitter     // Machine-readable event stream (nil disables)
+	Control     *control.Controller // Pause/resume/stop requests from the user (nil disables)
+	Downloads   *download.Tracker   // Follows the files Chrome saves for each date
+	Health      *browser.Heartbeat  // Notices a hung page (nil disables)
+	Tab         *browser.TabWatch   // Notices a crashed or closed tab (nil disables)
+	KeepAlive   time.Duration       // Interval of the activity that keeps the session alive while waiting for archives (0 disables)
+	Catalog     *catalog.Catalog    // Persistent record of seen and exported dates (nil disables)
+	MaxInFlight int                 // Archives being prepared or downloaded before the loop waits (0 is unbounded)
+	DateRetries int                 // Times a failed date is tried again before it is recorded as failed and skipped
+	DateLogs    *datelog.Logger     // Writes a log file per processed date (nil disables)
+	ErrorLog    *logging.ErrorLog   // Receives the date being processed for its lines (nil disables)
+
+	// ErrorThreshold is the number of failed attempts in a row that takes
+	// the next of ErrorActions (0 uses DefaultErrorThreshold).
+	ErrorThreshold int
+	// ErrorActions are the recovery actions taken each time ErrorThreshold
+	// is reached before a download starts, the last one repeating (nil
+	// refreshes the page).
+	ErrorActions []ErrorAction
+	// ResumeScrollY is the scroll offset a restarted browser was brought
+	// back to, where the run it replaces stopped (0 starts at the top).
+	ResumeScrollY float64
+
+	// RetryBlacklisted processes the dates the catalog blacklisted after
+	// failing in several runs instead of skipping them.
+	RetryBlacklisted bool
+}
+
+// Cycle is the state shared by the steps of one export run.
+type Cycle struct {
+	Config
+
+	DateCtx           context.Context     // Per-date watchdog context
+	Date              *selection.DateInfo // Date being processed
+	DownloadErr       error               // Outcome of the last download
+	DownloadMark      int                 // Downloads.Expect() mark taken right before the last Download click
+	LastScrollY       float64             // Scroll offset after the last processed date, for page refreshes
+	EmptyRounds       int                 // Consecutive rounds without a visible date
+	ConsecutiveErrors int                 // Consecutive failed attempts, for wedge recovery
+
+	lastReloadAt int                // DatesProcessed value at the last periodic reload
+	errorLevel   int                // ErrorActions taken since a download last started
+	cancelDate   context.CancelFunc // Cancels DateCtx
+	inflight     chan struct{}      // One token per archive in flight (nil is unbounded)
+	started      bool               // The download of Date has started
+	awaitBegin   bool               // The last started download may not have begun in Chrome yet
+	retrying     bool               // Date failed and is tried again instead of advancing
+	failedDate   string             // Date whose failed attempts dateFailures counts
+	dateFailures int                // Failed attempts at failedDate
+}
+
+// Loop runs the cycle until a step returns StateDone.
+type Loop struct {
+	cfg   Config
+	steps map[State]Step
+}
+
+// New creates a Loop with the default steps.
+func New(cfg Config) *Loop {
+	return &Loop{
+		cfg: cfg,
+		steps: map[State]Step{
+			StateFindDate:     findDate,
+			StateFilter:       filterDate,
+			StateSelect:       selectDate,
+			StateVerify:       verifySelection,
+			StateDownload:     startDownload,
+			StateWaitComplete: waitComplete,
+			StateDeselect:     deselect,
+			StateAdvance:      advance,
+		},
+	}
+}
+
+// SetStep replaces the handler of a state.
+func (l *Loop) SetStep(s State, step Step) {
+	l.steps[s] = step
+}
+
+// Step returns the current handler of a state, so a replacement can wrap it.
+func (l *Loop) Step(s State) Step {
+	return l.steps[s]
+}
+
+// Run executes the cycle. A closed browser ends the run without an error.
+func (l *Loop) Run(ctx context.Context) error {
+	c := &Cycle{Config: l.cfg, DateCtx: ctx, cancelDate: func() {}}
+	c.LastScrollY = c.ResumeScrollY
+	if c.MaxInFlight > 0 {
+		c.inflight = make(chan struct{}, c.MaxInFlight)
+	}
+	defer func() { c.cancelDate() }()
+
+	// The date span starts with the FindDate step that located the date and
+	// ends when the cycle returns to FindDate
+	var dateSpan *tracing.Span
+	dateCtx := ctx
+	defer func() { dateSpan.End() }()
+
+	// Each downloaded date is timed from the FindDate step that located it
+	// to the return to FindDate, for the report's per-date durations
+	var dateStart time.Time
+	downloaded, skipped := false, false
+
+	state := StateFindDate
+	for state != StateDone {
+		step, ok := l.steps[state]
+		if !ok {
+			return fmt.Errorf("no step registered for state %v", state)
+		}
+
+		start := time.Now()
+		next, err := step(ctx, c)
+		end := time.Now()
+		elapsed := end.Sub(start)
+		c.Stats.RecordStep(state.String(), elapsed)
+
+		if state == StateFindDate && c.Date != nil && err == nil {
+			dateCtx, dateSpan = c.Tracer.StartAt(ctx, "date", start)
+			dateSpan.SetAttr("date", c.Date.Text)
+			dateStart, downloaded, skipped = start, false, false
+			c.DateLogs.Start(c.Date.Text)
+			c.ErrorLog.SetDate(c.Date.Text)
+		}
+		if state == StateDownload {
+			downloaded = true
+		}
+		if state == StateFilter && next == StateFindDate {
+			skipped = true
+		}
+		stepErr := err
+		if state == StateDownload && err == nil {
+			stepErr = c.DownloadErr
+		}
+		c.DateLogs.Step(state.String(), elapsed, stepErr)
+		if !dateStart.IsZero() && (next == StateFindDate || next == StateDone || err != nil) {
+			if downloaded {
+				c.Stats.RecordDate(end.Sub(dateStart))
+			}
+			c.DateLogs.Finish(dateOutcome(downloaded, skipped, c.DownloadErr, err), errors.Join(c.DownloadErr, err), !skipped)
+			c.ErrorLog.SetDate("")
+			dateStart = time.Time{}
+		}
+		if dateSpan != nil {
+			_, span := c.Tracer.StartAt(dateCtx, state.String(), start)
+			span.SetError(err)
+			if state == StateDownload {
+				span.SetError(c.DownloadErr)
+			}
+			span.EndAt(end)
+			if next == StateFindDate || next == StateDone || err != nil {
+				dateSpan.SetError(c.DownloadErr)
+				dateSpan.EndAt(end)
+				dateSpan = nil
+			}
+		}
+		if c.Date != nil {
+			logging.Debugf("Step %v took %v (date '%s')", state, elapsed.Round(time.Millisecond), c.Date.Text)
+		} else {
+			logging.Debugf("Step %v took %v", state, elapsed.Round(time.Millisecond))
+		}
+		if err != nil {
+			// Only the tab's renderer died: reload it and carry on
+			if c.Tab.Crashed() && ctx.Err() == nil {
+				log.Printf("💥 Step %v failed on the crashed tab: %v", state, err)
+				state = StateFindDate
+				continue
+			}
+			if reason := c.Tab.Detached(); reason != "" && browser.IsBrowserClosed(err) {
+				log.Printf("\n⚠️ The photos tab was closed (%s). Exiting gracefully...", reason)
+				return nil
+			}
+			if browser.IsBrowserClosed(err) {
+				log.Println("\n⚠️ Browser was closed. Exiting gracefully...")
+				return nil
+			}
+			return fmt.Errorf("%v: %w", state, err)
+		}
+		state = next
+	}
+	return nil
+}
+
+// dateOutcome names how a date's cycle ended, for its log.
+func dateOutcome(downloaded, skipped bool, downloadErr, err error) string {
+	switch {
+	case skipped:
+		return "skipped"
+	case err != nil:
+		return "run ended"
+	case downloaded && downloadErr == nil:
+		return "download started"
+	case downloaded:
+		return "download failed"
+	default:
+		return "not downloaded"
+	}
+}
+
+// startDate cancels the previous watchdog and starts a new one for the next date.
+func (c *Cycle) startDate(ctx context.Context) {
+	c.cancelDate()
+	c.DateCtx, c.cancelDate = context.WithTimeout(ctx, c.DateTimeout)
+	c.Date = nil
+	c.DownloadErr = nil
+	c.started, c.retrying = false, false
+}
+
+// extendDate restarts the per-date watchdog without forgetting the date, for
+// steps that legitimately wait longer than DateTimeout.
+func (c *Cycle) extendDate(ctx context.Context) {
+	c.cancelDate()
+	c.DateCtx, c.cancelDate = context.WithTimeout(ctx, c.DateTimeout)
+}
+
+// finishDate cancels the per-date watchdog.
+func (c *Cycle) finishDate() {
+	c.cancelDate()
+}
@@ -0,0 +1,71 @@
+package exporter
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/navigation"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/selection"
+)
+
+const (
+	// fastForwardDays is how far after the range a date must be for the
+	// exporter to jump through the timeline instead of skipping date by date.
+	fastForwardDays = 31
+	// fastForwardFirstJump and fastForwardMaxJump bound the jumps, in
+	// viewport heights.
+	fastForwardFirstJump = 3
+	fastForwardMaxJump   = 48
+	// fastForwardSettle is the wait after each jump for the timeline to
+	// load the dates it scrolled to.
+	fastForwardSettle = 1500 * time.Millisecond
+)
+
+// fastForward jumps down the timeline while the visible dates are far after
+// the requested range, sampling the first visible date after each jump. The
+// jump doubles while the sampled date is still far off; when a jump lands
+// near, in or before the range, it is undone and halved, so date-by-date
+// skipping resumes less than a screen before the range. It returns the
+// final scroll position.
+func fastForward(ctx context.Context, c *Cycle) float64 {
+	log.Printf("⏩ Date '%s' is %d days after the range. Fast-forwarding...",
+		c.Date.Text, c.DateRange.DaysAfterRange(c.Date.Text))
+
+	y, last, jumps := c.LastScrollY, c.Date.Text, 0
+	for jump := float64(fastForwardFirstJump); jump >= 1 && ctx.Err() == nil; {
+		newY, err := navigation.ScrollViewports(ctx, jump)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			break
+		}
+		if newY <= y {
+			break // Already at the end of the timeline
+		}
+		browser.Pause(ctx, fastForwardSettle)
+
+		date, err := selection.PeekFirstVisibleDate(ctx)
+		if err == nil && date != nil && c.DateRange.DaysAfterRange(date.Text) > fastForwardDays {
+			y, last = newY, date.Text
+			jumps++
+			jump = min(jump*2, fastForwardMaxJump)
+			continue
+		}
+
+		// Too close, past the range, or nothing to judge by: go back
+		if _, err := navigation.ScrollViewports(ctx, -jump); err != nil {
+			log.Printf("Warning: %v", err)
+			break
+		}
+		jump /= 2
+	}
+
+	if jumps > 0 {
+		log.Printf("⏩ Fast-forwarded %d jumps to '%s'", jumps, last)
+	}
+	if cur, err := navigation.CurrentScrollY(ctx); err == nil {
+		return cur
+	}
+	return y
+}
@@ -0,0 +1,75 @@
+package exporter
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/navigation"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/selection"
+)
+
+const (
+	// gapMaxJump bounds the jumps through a stretch without dates, in
+	// pixels.
+	gapMaxJump = 64 * navigation.DefaultScrollAmount
+	// gapSettle is the wait after each jump for the timeline to load the
+	// part it scrolled to.
+	gapSettle = 1500 * time.Millisecond
+)
+
+// scanGap scrolls down through a stretch of the timeline without dates,
+// e.g. months without photos. The first jump is a normal scroll, and the
+// jump doubles while it lands on screens without a date. A longer jump
+// that lands on a date may have passed others, so it is undone and the
+// search narrows by halving the jump, until it is down to a normal scroll
+// again, which can't pass a date. It reports whether the page moved; it
+// doesn't when the end of the timeline is reached.
+func scanGap(ctx context.Context) (moved bool, err error) {
+	y, err := navigation.CurrentScrollY(ctx)
+	if err != nil {
+		return false, err
+	}
+	start, jump, growing := y, float64(navigation.DefaultScrollAmount), true
+	defer func() {
+		if y-start > 2*navigation.DefaultScrollAmount {
+			log.Printf("🔭 Passed %.0fpx of timeline without dates", y-start)
+		}
+	}()
+	for ctx.Err() == nil {
+		newY, err := navigation.ScrollDownBy(ctx, jump)
+		if err != nil {
+			return y > start, err
+		}
+		if newY <= y {
+			return y > start, nil // End of the timeline, for now
+		}
+		browser.Pause(ctx, gapSettle)
+
+		date, err := selection.PeekFirstVisibleDate(ctx)
+		if err != nil {
+			return true, err
+		}
+		if date == nil {
+			y = newY
+			if growing {
+				jump = min(jump*2, gapMaxJump)
+			} else if jump /= 2; jump < navigation.DefaultScrollAmount {
+				return true, nil
+			}
+			continue
+		}
+		if jump <= navigation.DefaultScrollAmount {
+			return true, nil // A normal scroll passes no date
+		}
+
+		// Dates may hide between y and the date found: undo and narrow
+		if y, err = navigation.ScrollDownBy(ctx, -jump); err != nil {
+			return true, err
+		}
+		growing = false
+		jump = max(jump/2, navigation.DefaultScrollAmount)
+	}
+	return y > start, ctx.Err()
+}
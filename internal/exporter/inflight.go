@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/crash"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/download"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/events"
+)
+
+// acquireSlot blocks until fewer than MaxInFlight archives are being
+// prepared by Yandex or downloaded by Chrome. It returns ctx's error if the
+// run ends while waiting.
+func (c *Cycle) acquireSlot(ctx context.Context) error {
+	if c.inflight == nil {
+		return nil
+	}
+	select {
+	case c.inflight <- struct{}{}:
+		return nil
+	default:
+	}
+	log.Printf("⏳ %d archives in flight, waiting for one to finish...", cap(c.inflight))
+	stopKeepAlive := browser.KeepAlive(ctx, c.KeepAlive)
+	defer stopKeepAlive()
+	select {
+	case c.inflight <- struct{}{}:
+		// The date stayed selected while waiting; give it a fresh watchdog
+		c.extendDate(ctx)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// awaitPreviousBegin blocks until Chrome began the archive of the previous
+// date whose download was started, or until its slot timed out. Page
+// feedback can confirm a Download click before the archive begins; asking
+// for the next date's archive before then could label the previous date's
+// archive with the next date.
+func (c *Cycle) awaitPreviousBegin(ctx context.Context) {
+	if !c.awaitBegin {
+		return
+	}
+	c.awaitBegin = false
+	if c.Downloads.Began(c.DownloadMark) {
+		return
+	}
+	log.Println("⏳ Waiting for the previous date's archive to begin...")
+	stopKeepAlive := browser.KeepAlive(ctx, c.KeepAlive)
+	defer stopKeepAlive()
+	if !c.Downloads.WaitBegun(ctx, c.DownloadMark, ArchiveTimeout) && ctx.Err() == nil {
+		log.Println("⚠️ The previous date's archive never began")
+	}
+	// The date stayed selected while waiting; give it a fresh watchdog
+	c.extendDate(ctx)
+}
+
+// releaseSlot frees a slot taken by acquireSlot.
+func (c *Cycle) releaseSlot() {
+	if c.inflight != nil {
+		<-c.inflight
+	}
+}
+
+// trackArchive follows, in the background, the archive requested for date
+// after mark was taken, and frees its slot once it has finished. Completed
+// and canceled downloads are reported by the tracker's OnFinish callbacks;
+// this only reports archives that never arrived. Archives are matched to
+// requests in the order they begin, like Tracker.Wait does.
+func (c *Cycle) trackArchive(ctx context.Context, date string, mark int) {
+	go func() {
+		defer crash.Recover()
+		defer c.releaseSlot()
+		_, err := c.Downloads.Wait(ctx, mark, ArchiveTimeout)
+		if err == nil || errors.Is(err, download.ErrCanceled) || ctx.Err() != nil {
+			return
+		}
+		log.Printf("⚠️ Archive of '%s': %v", date, err)
+		c.Stats.AddError(date, fmt.Sprintf("Archive: %v", err))
+		c.Catalog.Failed(date, fmt.Sprintf("Archive: %v", err))
+		c.Events.Emit(events.Event{Type: events.DownloadFailed, Date: date, Message: err.Error()})
+	}()
+}
@@ -0,0 +1,34 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/inventory"
+)
+
+// InventoryOnly changes the cycle so that, instead of selecting and
+// downloading each date, it records the name, type and size of every file
+// of the date in the catalog and moves on. Like PreviewOnly, it changes
+// nothing on Yandex.
+func (l *Loop) InventoryOnly() {
+	l.SetStep(StateSelect, func(ctx context.Context, c *Cycle) (State, error) {
+		photos, err := inventory.List(c.DateCtx, c.Date)
+		if len(photos) > 0 {
+			c.Catalog.Inventoried(c.Date.Text, photos)
+			log.Printf("📋 Listed %d files of '%s'", len(photos), c.Date.Text)
+		}
+		if err != nil {
+			if browser.IsBrowserClosed(err) {
+				return StateDone, err
+			}
+			log.Printf("⚠️ Could not list the files of '%s': %v", c.Date.Text, err)
+			c.Stats.AddError(c.Date.Text, fmt.Sprintf("Inventory: %v", err))
+			c.emitError(fmt.Errorf("inventory: %w", err))
+		}
+		c.finishDate()
+		return StateAdvance, nil
+	})
+}
@@ -0,0 +1,44 @@
+package exporter
+
+import (
+	"context"
+	"log"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/events"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/inventory"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/mirror"
+)
+
+// SkipMirrored changes the cycle so that a date is scrolled past instead of
+// downloaded when all its files are already in the local Yandex.Disk folder
+// indexed by m. The files of the date come from its inventory in the
+// catalog (see InventoryOnly) or, when the whole date fits on the screen,
+// from the photos shown; a date taller than the screen without an
+// inventory is downloaded. It wraps the current Select step.
+func (l *Loop) SkipMirrored(m *mirror.Index) {
+	next := l.Step(StateSelect)
+	l.SetStep(StateSelect, func(ctx context.Context, c *Cycle) (State, error) {
+		photos := c.Catalog.Photos(c.Date.Text)
+		if len(photos) == 0 {
+			shown, complete, err := inventory.Shown(c.DateCtx, c.Date)
+			if err != nil && browser.IsBrowserClosed(err) {
+				return StateDone, err
+			}
+			if err == nil && complete {
+				photos = shown
+			}
+		}
+		if len(photos) == 0 || !m.HasAll(photos) {
+			return next(ctx, c)
+		}
+
+		log.Printf("⏭️ All %d files of '%s' are already in %s. Skipping...", len(photos), c.Date.Text, m.Dir)
+		c.Stats.IncrementSkippedDates()
+		c.Events.Emit(events.Event{Type: events.DateSkipped, Date: c.Date.Text, Message: "already in the Yandex.Disk folder"})
+		c.LastScrollY = scrollPastDate(ctx, c.Date, c.LastScrollY)
+		c.ConsecutiveErrors = 0
+		c.finishDate()
+		return StateFindDate, nil
+	})
+}
@@ -0,0 +1,43 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/preview"
+)
+
+// PreviewOnly changes the cycle so that, instead of selecting and
+// downloading each date, it saves the thumbnails shown for it into dir and
+// moves on. Nothing is selected, so previews are fast and change nothing on
+// Yandex.
+func (l *Loop) PreviewOnly(dir string) {
+	l.SetStep(StateSelect, func(ctx context.Context, c *Cycle) (State, error) {
+		return savePreview(ctx, c, dir)
+	})
+}
+
+// savePreview saves the thumbnails of the current date.
+func savePreview(ctx context.Context, c *Cycle, dir string) (State, error) {
+	thumbs, err := preview.Thumbnails(c.DateCtx, c.Date)
+	if err == nil {
+		var files int
+		var bytes int64
+		files, bytes, err = preview.Save(c.DateCtx, thumbs, dir, c.Date.Text)
+		if err == nil {
+			log.Printf("🖼️ Saved %d previews of '%s' (%d bytes)", files, c.Date.Text, bytes)
+		}
+	}
+	if err != nil {
+		if browser.IsBrowserClosed(err) {
+			return StateDone, err
+		}
+		log.Printf("⚠️ Could not save previews of '%s': %v", c.Date.Text, err)
+		c.Stats.AddError(c.Date.Text, fmt.Sprintf("Previews: %v", err))
+		c.emitError(fmt.Errorf("previews: %w", err))
+	}
+	c.finishDate()
+	return StateAdvance, nil
+}
@@ -0,0 +1,40 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/selection"
+)
+
+// retryDate counts a failed attempt at the current date and reports whether
+// the date may be tried again. Selection failures, download failures and
+// stuck cycles share one budget of DateRetries retries per date, separate
+// from ConsecutiveErrors, which only decides when the page is refreshed.
+func (c *Cycle) retryDate(reason string) bool {
+	if c.Date.Text != c.failedDate {
+		c.failedDate, c.dateFailures = c.Date.Text, 0
+	}
+	c.dateFailures++
+	if c.dateFailures <= c.DateRetries {
+		log.Printf("🔁 Retrying '%s' (%d/%d): %s", c.Date.Text, c.dateFailures, c.DateRetries, reason)
+		c.emitError(errors.New(reason))
+		return true
+	}
+	c.failedDate, c.dateFailures = "", 0
+	if c.DateRetries > 0 {
+		log.Printf("❌ Giving up on '%s' after %d attempts: %s", c.Date.Text, c.DateRetries+1, reason)
+	}
+	return false
+}
+
+// giveUpDate records the current date as failed and scrolls past it so the
+// run moves on.
+func (c *Cycle) giveUpDate(ctx context.Context, msg string) {
+	selection.Deselect(ctx)
+	c.Stats.AddError(c.Date.Text, msg)
+	c.Catalog.Failed(c.Date.Text, msg)
+	c.emitError(errors.New(msg))
+	c.LastScrollY = scrollPastDate(ctx, c.Date, c.LastScrollY)
+}
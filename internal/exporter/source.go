@@ -0,0 +1,33 @@
+package exporter
+
+import (
+	"context"
+	"log"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/inventory"
+)
+
+// TagSources changes the cycle so that, before each date is selected, the
+// storage of its photos (inventory.Unlimited or inventory.Disk) is found
+// from their badges and passed to set, e.g. to save the date's archive in
+// a folder per storage. It wraps the current Select step.
+func (l *Loop) TagSources(set func(date, source string)) {
+	next := l.Step(StateSelect)
+	l.SetStep(StateSelect, func(ctx context.Context, c *Cycle) (State, error) {
+		source, mixed, err := inventory.Source(c.DateCtx, c.Date)
+		switch {
+		case err != nil && browser.IsBrowserClosed(err):
+			return StateDone, err
+		case err != nil:
+			log.Printf("⚠️ Could not tell the storage of '%s', saving it in the download directory: %v", c.Date.Text, err)
+		case mixed:
+			log.Printf("'%s' has photos in unlimited storage and on Disk; saving it under %s", c.Date.Text, source)
+			set(c.Date.Text, source)
+		default:
+			log.Printf("Storage of '%s': %s", c.Date.Text, source)
+			set(c.Date.Text, source)
+		}
+		return next(ctx, c)
+	})
+}
@@ -0,0 +1,565 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/control"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/download"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/events"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/navigation"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/netcheck"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/overlay"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/retry"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/selection"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/snapshot"
+)
+
+// maxEmptyRounds is the number of scrolls without a date, that also can't
+// move further down, that ends the run.
+const maxEmptyRounds = 5
+
+// findDate recovers from outages and wedged pages, then locates the first
+// visible date.
+func findDate(ctx context.Context, c *Cycle) (State, error) {
+	c.finishDate()
+
+	// Check if browser/context is still valid
+	if browser.IsContextCanceled(ctx) {
+		return StateDone, ctx.Err()
+	}
+
+	// Pause and stop requests take effect between dates
+	if stop, err := waitIfPaused(ctx, c); stop || err != nil {
+		return StateDone, err
+	}
+
+	if recovered, err := recoverPage(ctx, c); err != nil {
+		return StateDone, err
+	} else if recovered {
+		return StateFindDate, nil
+	}
+
+	if err := checkFilter(ctx, c); err != nil {
+		return StateDone, err
+	}
+
+	log.Printf("\n--- Processing date %d ---", c.Stats.DatesProcessed+1)
+
+	// Watchdog: the select→download→deselect cycle must finish within DateTimeout
+	c.startDate(ctx)
+
+	// Close promo dialogs and banners that would steal clicks
+	overlay.DismissPromos(c.DateCtx)
+
+	// Check for pending selection and clear it
+	selection.ClearPendingSelection(c.DateCtx)
+
+	// Find the FIRST visible date (always the top one)
+	err := retry.Do(c.DateCtx, retry.DefaultPolicy(), "Find date", func(int) error {
+		var err error
+		c.Date, err = selection.FindFirstVisibleDate(c.DateCtx)
+		return err
+	})
+	if err != nil {
+		c.Date = nil // Nothing to retry or give up on
+		return selectionFailed(ctx, c, err)
+	}
+
+	if c.Date == nil {
+		return noDateFound(ctx, c)
+	}
+
+	c.EmptyRounds = 0
+	log.Println("✓ Date found: " + c.Date.Text)
+	c.Catalog.Seen(c.Date.Text)
+	c.Events.Emit(events.Event{Type: events.DateFound, Date: c.Date.Text})
+	return StateFilter, nil
+}
+
+// waitIfPaused blocks while the user has paused the run. It reports whether
+// the user asked to stop.
+func waitIfPaused(ctx context.Context, c *Cycle) (bool, error) {
+	paused := c.Control.State() == control.Paused
+	if paused {
+		log.Println("⏸️ Paused. Waiting to resume...")
+		c.Events.Emit(events.Event{Type: events.Paused})
+	}
+	err := c.Control.Wait(ctx)
+	if errors.Is(err, control.ErrStopped) {
+		log.Println("⏹️ Stop requested. Ending the run...")
+		return true, nil
+	}
+	if err == nil && paused {
+		log.Println("▶️ Resumed")
+		c.Events.Emit(events.Event{Type: events.Resumed})
+	}
+	return false, err
+}
+
+// recoverPage reopens a crashed tab, pauses during network outages, reloads
+// a hung page, recovers a wedged one as ErrorActions ask and performs
+// periodic reloads. It reports whether the cycle should start over.
+func recoverPage(ctx context.Context, c *Cycle) (bool, error) {
+	// A crashed renderer shows "Aw, Snap!": open the page again in the tab
+	if c.Tab.Crashed() {
+		log.Println("💥 The photos tab crashed. Reopening the photos page...")
+		c.emitError(errors.New("tab crashed"))
+		c.Tab.Reset()
+		if err := refresh(ctx, c, "tab recovery"); err != nil {
+			return false, err
+		}
+		c.Health.Reset()
+		c.ConsecutiveErrors = 0
+		return true, nil
+	}
+
+	// A hung renderer fails every step on its timeout: replace it first
+	if c.Health.Unresponsive() {
+		log.Println("🧟 The page stopped responding. Forcing a reload...")
+		if err := browser.ForceReload(ctx); err != nil {
+			return false, fmt.Errorf("%w; restart the exporter to continue", err)
+		}
+		c.Health.Reset()
+		if err := refresh(ctx, c, "page refresh"); err != nil {
+			return false, err
+		}
+		c.ConsecutiveErrors = 0
+		return true, nil
+	}
+
+	// Errors may come from a network outage: pause until it returns, then resume
+	if c.ConsecutiveErrors > 0 && netcheck.Offline(ctx) {
+		log.Println("📡 Network connection lost. Pausing until it returns...")
+		c.Stats.IncrementNetworkOutages()
+		if err := netcheck.WaitOnline(ctx); err != nil {
+			return false, err
+		}
+		if err := refresh(ctx, c, "page refresh"); err != nil {
+			return false, err
+		}
+		c.ConsecutiveErrors = 0
+		return true, nil
+	}
+
+	// Wait, reload the page or restart the browser if the UI appears to be wedged
+	if recovered, err := c.onConsecutiveErrors(ctx); recovered || err != nil {
+		return recovered, err
+	}
+
+	// Periodic reload to release the infinite-scroll DOM and caches
+	processed := c.Stats.DatesProcessed
+	if c.ReloadEvery > 0 && processed > 0 && processed%c.ReloadEvery == 0 && processed != c.lastReloadAt {
+		log.Printf("🔄 Processed %d dates, reloading page to free browser memory...", processed)
+		if err := refresh(ctx, c, "periodic reload"); err != nil {
+			return false, err
+		}
+		c.lastReloadAt = processed
+	}
+	return false, nil
+}
+
+// checkFilter re-applies the timeline filter if the page lost it, e.g. after
+// Yandex navigated or reset its state on its own, since the dates shown
+// without it are not the ones to export. A reload is tried if re-applying
+// fails; a filter still missing after that ends the run.
+func checkFilter(ctx context.Context, c *Cycle) error {
+	active, known, err := navigation.FilterActive(ctx, c.Filter)
+	if err != nil && browser.IsBrowserClosed(err) {
+		return err
+	}
+	if err != nil || !known || active {
+		return nil // A failed check is left to the next date
+	}
+
+	log.Printf("🔄 The '%s' filter was reset. Re-applying it...", c.Filter.Name)
+	err = navigation.ReapplyFilter(ctx, c.Filter, c.LastScrollY)
+	if err != nil && browser.IsBrowserClosed(err) {
+		return err
+	}
+	if err != nil {
+		log.Printf("⚠️ %v", err)
+		if err := refresh(ctx, c, "page refresh"); err != nil {
+			return err
+		}
+	}
+	if active, known, err := navigation.FilterActive(ctx, c.Filter); err == nil && known && !active {
+		return fmt.Errorf("could not re-apply the '%s' filter; stopping instead of exporting other photos", c.Filter.Name)
+	}
+	return nil
+}
+
+// refresh reloads the photos page and restores the scroll position. Only a
+// closed browser is returned as an error; other failures are logged and
+// saved as a debug bundle.
+func refresh(ctx context.Context, c *Cycle, what string) error {
+	c.Stats.IncrementPageRefreshes()
+	err := navigation.RefreshPage(ctx, c.PhotosURL, c.Filter, c.LastScrollY)
+	if err == nil {
+		return nil
+	}
+	if browser.IsBrowserClosed(err) {
+		return err
+	}
+	log.Printf("⚠️ Warning: %s failed: %v", what, err)
+	if bundle, dumpErr := c.Forensics.Dump(ctx, err); dumpErr == nil {
+		c.Stats.AddDebugBundle(bundle)
+	}
+	return nil
+}
+
+// selectionFailed handles an error while finding or selecting a date. The
+// date is found again and retried until its DateRetries are used up.
+func selectionFailed(ctx context.Context, c *Cycle, err error) (State, error) {
+	if c.Health.Unresponsive() || c.Tab.Crashed() {
+		return StateFindDate, nil // Not the date's fault; recoverPage reloads the page
+	}
+	if watchdogExpired(ctx, c.DateCtx) {
+		log.Printf("⏳ Selection timed out after %v. Forcing deselect...", c.DateTimeout)
+		selection.Deselect(ctx)
+		c.ConsecutiveErrors++
+		msg := fmt.Sprintf("Selection timed out after %v", c.DateTimeout)
+		if c.Date == nil {
+			c.emitError(errors.New(msg))
+		} else if !c.retryDate(msg) {
+			c.giveUpDate(ctx, msg)
+		}
+		return StateFindDate, nil
+	}
+	// Check if this is a fatal error (browser closed)
+	if browser.IsBrowserClosed(err) {
+		return StateDone, err
+	}
+	log.Printf("Error selecting: %v", err)
+	if overlay.DismissPromos(ctx) > 0 {
+		c.emitError(err)
+		return StateFindDate, nil // A popup was covering the grid; retry without counting an error
+	}
+	c.ConsecutiveErrors++
+	msg := fmt.Sprintf("Selection failed: %v", err)
+	if c.Date == nil {
+		c.emitError(err)
+	} else if !c.retryDate(msg) {
+		c.giveUpDate(ctx, msg)
+	}
+	return StateFindDate, nil
+}
+
+// noDateFound scrolls down looking for more dates and ends the run once the
+// timeline is exhausted.
+func noDateFound(ctx context.Context, c *Cycle) (State, error) {
+	log.Println("No date found, scrolling...")
+	moved, err := scanGap(ctx)
+	if err != nil {
+		if browser.IsBrowserClosed(err) {
+			return StateDone, err
+		}
+		log.Printf("Warning: %v", err)
+	}
+	if moved {
+		return StateFindDate, nil
+	}
+	browser.Pause(ctx, 3*time.Second)
+
+	// A maintenance or error page has no dates either: wait it out
+	// instead of mistaking it for the end of the timeline
+	if reason := navigation.ServiceStatus(ctx); reason != "" {
+		log.Printf("⚠️ Yandex Disk returned a %s", reason)
+		return StateFindDate, refresh(ctx, c, "page refresh")
+	}
+
+	c.EmptyRounds++
+	if c.EmptyRounds >= maxEmptyRounds {
+		log.Println("End of photos!")
+		if !c.DateRange.Enabled {
+			c.Catalog.FullScan(c.Stats.StartTime)
+		}
+		return StateDone, nil
+	}
+	return StateFindDate, nil
+}
+
+// filterDate skips dates after the requested range and stops at the first
+// date before it (dates are in reverse chronological order). Blacklisted
+// dates in the range are skipped too.
+func filterDate(ctx context.Context, c *Cycle) (State, error) {
+	if !c.DateRange.Enabled {
+		return skipBlacklisted(ctx, c)
+	}
+
+	inRange, err := c.DateRange.IsInRange(c.Date.Text)
+	if err != nil {
+		log.Printf("⚠️ Could not parse date '%s': %v", c.Date.Text, err)
+		// Continue processing anyway if date can't be parsed
+		return skipBlacklisted(ctx, c)
+	}
+	if inRange {
+		log.Printf("✓ Date '%s' is within range", c.Date.Text)
+		return skipBlacklisted(ctx, c)
+	}
+
+	if c.DateRange.IsBeforeRange(c.Date.Text) {
+		log.Printf("📅 Date '%s' is before the specified range. Stopping.", c.Date.Text)
+		return StateDone, nil
+	}
+
+	// Date is after range, skip it and scroll
+	log.Printf("📅 Date '%s' is after the specified range. Skipping...", c.Date.Text)
+	c.Stats.IncrementSkippedDates()
+	c.Events.Emit(events.Event{Type: events.DateSkipped, Date: c.Date.Text, Message: "after the requested range"})
+	c.LastScrollY = scrollPastDate(ctx, c.Date, c.LastScrollY)
+	if c.DateRange.DaysAfterRange(c.Date.Text) > fastForwardDays {
+		c.LastScrollY = fastForward(ctx, c)
+	}
+	c.ConsecutiveErrors = 0
+	return StateFindDate, nil
+}
+
+// skipBlacklisted scrolls past the date if the catalog blacklisted it after
+// it failed in several runs, unless RetryBlacklisted is set.
+func skipBlacklisted(ctx context.Context, c *Cycle) (State, error) {
+	e, ok := c.Catalog.Blacklisted(c.Date.Text)
+	if !ok {
+		return StateSelect, nil
+	}
+	if c.RetryBlacklisted {
+		log.Printf("⛔ Date '%s' is blacklisted (failed in %d runs). Retrying it as requested...", c.Date.Text, e.Failures)
+		return StateSelect, nil
+	}
+	log.Printf("⛔ Date '%s' is blacklisted (failed in %d runs). Skipping...", c.Date.Text, e.Failures)
+	c.Events.Emit(events.Event{Type: events.DateSkipped, Date: c.Date.Text, Message: "blacklisted"})
+	c.LastScrollY = scrollPastDate(ctx, c.Date, c.LastScrollY)
+	c.ConsecutiveErrors = 0
+	return StateFindDate, nil
+}
+
+// selectDate selects all photos of the date.
+func selectDate(ctx context.Context, c *Cycle) (State, error) {
+	err := retry.Do(c.DateCtx, retry.DefaultPolicy(), "Selection", func(int) error {
+		return selection.SelectDate(c.DateCtx, c.Date)
+	})
+	if err != nil {
+		return selectionFailed(ctx, c, err)
+	}
+	return StateVerify, nil
+}
+
+// verifySelection waits for the selection toolbar with the Download button
+// and confirms that the date is selected.
+func verifySelection(ctx context.Context, c *Cycle) (State, error) {
+	if err := download.WaitForButton(c.DateCtx); err != nil {
+		if watchdogExpired(ctx, c.DateCtx) {
+			return stuck(ctx, c)
+		}
+		if browser.IsBrowserClosed(err) {
+			return StateDone, err
+		}
+		if !selection.HasActiveSelection(c.DateCtx) {
+			return selectionFailed(ctx, c, selection.ErrNotSelected)
+		}
+		log.Printf("Download button not shown yet: %v", err)
+	}
+
+	// The toolbar counts the selected items; the date's own header doesn't
+	c.Date.Items = selection.Count(c.DateCtx)
+
+	// Select the date again, from a cleared selection, rather than download
+	// photos of other dates with it
+	if err := selection.CheckSelection(c.DateCtx, c.Date, c.Date.Items); errors.Is(err, selection.ErrSelectionBleed) {
+		return selectionFailed(ctx, c, err)
+	} else if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if c.Date.Items > 0 {
+		log.Printf("✓ Date selected: %s (%d items)", c.Date.Text, c.Date.Items)
+		c.Stats.AddSelectedItems(c.Date.Text, c.Date.Items)
+		c.Catalog.Selected(c.Date.Text, c.Date.Items)
+	} else {
+		log.Println("✓ Date selected: " + c.Date.Text)
+	}
+	c.Events.Emit(events.Event{Type: events.DateSelected, Date: c.Date.Text, Items: c.Date.Items})
+	snapshot.Capture(c.DateCtx, snapshot.StateSelectionActive)
+	return StateDownload, nil
+}
+
+// startDownload clicks Download and records the outcome. The loop moves on
+// to the next date while Yandex prepares the archive and Chrome downloads
+// it, with at most MaxInFlight archives outstanding.
+func startDownload(ctx context.Context, c *Cycle) (State, error) {
+	if err := c.acquireSlot(ctx); err != nil {
+		return StateDone, err
+	}
+	c.awaitPreviousBegin(ctx)
+	if err := ctx.Err(); err != nil {
+		return StateDone, err
+	}
+	c.DownloadMark = c.Downloads.Expect(c.Date.Text)
+	c.DownloadErr = retry.Do(c.DateCtx, retry.DefaultPolicy(), "Download", func(attempt int) error {
+		// A dropped click may still take effect late: don't click again then
+		if attempt > 1 && c.Downloads.Began(c.DownloadMark) {
+			return nil
+		}
+		before := download.State(c.DateCtx)
+		if err := download.ClickDownloadButton(c.DateCtx); err != nil {
+			return err
+		}
+		// Confirm the click took effect, and watch for Yandex's own error
+		// notifications instead of assuming success
+		return download.WaitForStart(c.DateCtx, c.Downloads, c.DownloadMark, before)
+	})
+
+	if c.DownloadErr != nil {
+		c.releaseSlot() // No archive is coming
+	}
+
+	switch {
+	case c.DownloadErr == nil:
+		log.Println("✓ Download started")
+		c.Events.Emit(events.Event{Type: events.DownloadStarted, Date: c.Date.Text})
+		c.Stats.IncrementDownloadsStarted()
+		c.ConsecutiveErrors = 0 // Reset on success
+		c.errorLevel = 0
+		c.started = true
+		c.awaitBegin = true
+		c.trackArchive(ctx, c.Date.Text, c.DownloadMark)
+	case watchdogExpired(ctx, c.DateCtx):
+		return stuck(ctx, c)
+	case browser.IsBrowserClosed(c.DownloadErr):
+		return StateDone, c.DownloadErr
+	default:
+		log.Printf("Download error: %v", c.DownloadErr)
+		c.Events.Emit(events.Event{Type: events.DownloadFailed, Date: c.Date.Text, Message: c.DownloadErr.Error()})
+		c.Stats.IncrementDownloadsFailed()
+		var notifErr *download.NotificationError
+		msg := fmt.Sprintf("Download failed: %v", c.DownloadErr)
+		if errors.As(c.DownloadErr, &notifErr) {
+			msg = fmt.Sprintf("Download failed (%s): %s", notifErr.Kind, notifErr.Message)
+		}
+		c.ConsecutiveErrors++
+		// Deselect as usual, then find the date again instead of advancing
+		if c.retrying = c.retryDate(msg); !c.retrying {
+			c.Stats.AddError(c.Date.Text, msg)
+			c.Catalog.Failed(c.Date.Text, msg)
+		}
+	}
+	return StateWaitComplete, nil
+}
+
+// waitComplete adapts the pacing if Yandex is throttling us.
+func waitComplete(ctx context.Context, c *Cycle) (State, error) {
+	if reason := c.Pacer.Check(c.DateCtx, c.DownloadErr != nil); reason != "" {
+		log.Printf("⚠️ Throttling detected: %s", reason)
+		c.Stats.IncrementThrottleEvents()
+		c.Pacer.Throttled(ctx)
+	} else {
+		c.Pacer.Succeeded()
+	}
+	return StateDeselect, nil
+}
+
+// deselect clears the selection, retrying until it is gone.
+func deselect(ctx context.Context, c *Cycle) (State, error) {
+	if err := retry.Do(c.DateCtx, retry.DefaultPolicy(), "Deselect", func(int) error {
+		if err := selection.Deselect(c.DateCtx); err != nil {
+			return err
+		}
+		if selection.HasActiveSelection(c.DateCtx) {
+			return selection.ErrSelectionActive
+		}
+		return nil
+	}); err != nil && !browser.IsBrowserClosed(err) {
+		log.Printf("⚠️ %v", err)
+	}
+	if watchdogExpired(ctx, c.DateCtx) {
+		return stuck(ctx, c)
+	}
+	c.finishDate()
+
+	// Check again if browser is still open before continuing
+	if browser.IsContextCanceled(ctx) {
+		return StateDone, ctx.Err()
+	}
+	log.Println("✓ Deselected")
+	if c.retrying {
+		return StateFindDate, nil
+	}
+	return StateAdvance, nil
+}
+
+// advance scrolls the processed date off screen and moves on to the next one.
+func advance(ctx context.Context, c *Cycle) (State, error) {
+	// IMPORTANT: Scroll to move processed date off screen
+	if err := navigation.ScrollToPosition(ctx, c.Date.YPosition); err != nil {
+		if browser.IsBrowserClosed(err) {
+			return StateDone, err
+		}
+		log.Printf("Warning: scroll to position failed: %v", err)
+	}
+	browser.Pause(ctx, time.Second)
+	if y, err := navigation.CurrentScrollY(ctx); err == nil {
+		c.LastScrollY = y
+	}
+
+	c.Stats.IncrementDatesProcessed()
+
+	// Extra delay between dates while throttled
+	c.Pacer.Wait(ctx)
+	return StateFindDate, nil
+}
+
+// stuck force-deselects after the per-date watchdog expired. The date is
+// retried unless its download already started or its DateRetries are used
+// up; then it is recorded as stuck and scrolled past so the run can move on.
+func stuck(ctx context.Context, c *Cycle) (State, error) {
+	if c.Health.Unresponsive() || c.Tab.Crashed() {
+		// Deselecting would wait on the hung page; the reload clears the selection
+		log.Printf("⏳ Date '%s' is stuck on an unresponsive page", c.Date.Text)
+		return StateFindDate, nil
+	}
+	log.Printf("⏳ Date '%s' is stuck (no progress in %v). Forcing deselect...", c.Date.Text, c.DateTimeout)
+	if err := selection.Deselect(ctx); err != nil {
+		log.Printf("Warning: force deselect failed: %v", err)
+	}
+	msg := fmt.Sprintf("Stuck: timed out after %v", c.DateTimeout)
+	if !c.started && c.retryDate(msg) {
+		return StateFindDate, nil
+	}
+	c.Stats.IncrementStuckDates()
+	c.Stats.AddError(c.Date.Text, msg)
+	c.Catalog.Failed(c.Date.Text, msg)
+	c.emitError(errors.New(msg))
+	c.LastScrollY = scrollPastDate(ctx, c.Date, c.LastScrollY)
+	return StateFindDate, nil
+}
+
+// watchdogExpired reports whether the per-date watchdog fired while the
+// browser itself is still alive.
+func watchdogExpired(ctx, dateCtx context.Context) bool {
+	return errors.Is(dateCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+}
+
+// scrollPastDate scrolls the given date off screen and returns the new scroll
+// offset, or lastScrollY if it cannot be read.
+func scrollPastDate(ctx context.Context, dateInfo *selection.DateInfo, lastScrollY float64) float64 {
+	if err := navigation.ScrollToPosition(ctx, dateInfo.YPosition); err != nil {
+		log.Printf("Warning: scroll to position failed: %v", err)
+	}
+	browser.Pause(ctx, time.Second)
+	if y, err := navigation.CurrentScrollY(ctx); err == nil {
+		return y
+	}
+	return lastScrollY
+}
+
+// emitError reports a recoverable error for the current date on the event stream.
+func (c *Cycle) emitError(err error) {
+	ev := events.Event{Type: events.Error, Message: err.Error()}
+	if c.Date != nil {
+		ev.Date = c.Date.Text
+	}
+	c.Events.Emit(ev)
+}
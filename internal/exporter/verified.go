@@ -0,0 +1,144 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/album"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/download"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/events"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/selection"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/trash"
+)
+
+// ArchiveTimeout is how long to wait for a date's download to finish before
+// giving up on verifying it.
+const ArchiveTimeout = 30 * time.Minute
+
+// ErrTrashNotConfirmed ends the run when moving a verified date to the
+// Trash is not confirmed.
+var ErrTrashNotConfirmed = errors.New("moving photos to the Trash was not confirmed")
+
+// TrashConfirm is asked before each verified date is moved to the Trash,
+// with the number of its photos, and returns an error to keep them. It may
+// ask the user only once per run and approve later dates itself.
+type TrashConfirm func(date string, count int) error
+
+// verifiedAction acts on the still-selected photos of a date whose download
+// has been verified. It reports whether the photos left the timeline, in
+// which case the date needs neither deselecting nor scrolling past.
+type verifiedAction func(ctx context.Context, c *Cycle, count int) (removed bool, err error)
+
+// TrashAfterVerify changes the cycle so that, after each download, it waits
+// for the file, verifies it against the number of selected photos and only
+// then moves the still-selected photos to the Yandex Trash, once confirm
+// (if not nil) approves. Dates that fail verification are deselected and
+// kept on Yandex; a date confirm declines ends the run with
+// ErrTrashNotConfirmed.
+func (l *Loop) TrashAfterVerify(confirm TrashConfirm) {
+	var approve func(c *Cycle, count int) error
+	if confirm != nil {
+		approve = func(c *Cycle, count int) error {
+			if err := confirm(c.Date.Text, count); err != nil {
+				return fmt.Errorf("%w: %v", ErrTrashNotConfirmed, err)
+			}
+			return nil
+		}
+	}
+	l.afterVerify("move to Trash", approve, func(ctx context.Context, c *Cycle, count int) (bool, error) {
+		if err := trash.MoveSelectionToTrash(ctx); err != nil {
+			return false, err
+		}
+		log.Printf("🗑️ Moved %d photos of '%s' to Trash", count, c.Date.Text)
+		c.Stats.IncrementTrashedDates()
+		c.Events.Emit(events.Event{Type: events.DateTrashed, Date: c.Date.Text})
+		return true, nil
+	})
+}
+
+// AddToAlbumAfterVerify changes the cycle so that the photos of each date
+// whose download was verified are added to the named Yandex album, so the
+// remote library shows what has already been backed up.
+func (l *Loop) AddToAlbumAfterVerify(name string) {
+	l.afterVerify("add to album", nil, func(ctx context.Context, c *Cycle, count int) (bool, error) {
+		if err := album.AddSelection(ctx, name); err != nil {
+			return false, err
+		}
+		log.Printf("🏷️ Added %d photos of '%s' to album '%s'", count, c.Date.Text, name)
+		c.Stats.IncrementMarkedDates()
+		c.Events.Emit(events.Event{Type: events.DateMarked, Date: c.Date.Text, Message: name})
+		return false, nil
+	})
+}
+
+// afterVerify wraps the WaitComplete step so that action runs once the
+// date's download has finished and passed verification, and approve (if not
+// nil) has not returned an error, which ends the run.
+func (l *Loop) afterVerify(what string, approve func(c *Cycle, count int) error, action verifiedAction) {
+	waitStep := l.Step(StateWaitComplete)
+	l.SetStep(StateWaitComplete, func(ctx context.Context, c *Cycle) (State, error) {
+		next, err := waitStep(ctx, c)
+		if err != nil || next != StateDeselect || c.DownloadErr != nil {
+			return next, err
+		}
+		return applyVerified(ctx, c, c.DownloadMark, what, approve, action)
+	})
+}
+
+// applyVerified verifies the date's download and applies action to its
+// photos once approve agrees.
+func applyVerified(ctx context.Context, c *Cycle, mark int, what string, approve func(c *Cycle, count int) error, action verifiedAction) (State, error) {
+	want := c.Date.Items
+	if want == 0 {
+		want = selection.Count(c.DateCtx)
+	}
+
+	log.Println("⏳ Waiting for the download to finish before verifying...")
+	stopKeepAlive := browser.KeepAlive(ctx, c.KeepAlive)
+	file, err := c.Downloads.Wait(ctx, mark, ArchiveTimeout)
+	stopKeepAlive()
+	if err == nil {
+		err = download.VerifyArchive(file.Path, file.Bytes, want)
+	}
+	// The wait may outlast the watchdog; give the rest of the date a fresh one
+	c.extendDate(ctx)
+	if err != nil {
+		log.Printf("⚠️ Verification of '%s' failed, skipping %s: %v", c.Date.Text, what, err)
+		c.Stats.AddError(c.Date.Text, fmt.Sprintf("Verification failed, skipped %s: %v", what, err))
+		c.Catalog.Failed(c.Date.Text, fmt.Sprintf("Verification failed: %v", err))
+		c.emitError(fmt.Errorf("verification failed, skipped %s: %w", what, err))
+		return StateDeselect, nil
+	}
+	log.Printf("✓ Verified %s (%d photos)", file.Name, want)
+
+	if approve != nil {
+		err := approve(c, want)
+		// The answer may take longer than the watchdog allows
+		c.extendDate(ctx)
+		if err != nil {
+			return StateDeselect, err
+		}
+	}
+
+	removed, err := action(c.DateCtx, c, want)
+	if err != nil {
+		log.Printf("⚠️ Could not %s '%s': %v", what, c.Date.Text, err)
+		c.Stats.AddError(c.Date.Text, fmt.Sprintf("Could not %s: %v", what, err))
+		c.emitError(fmt.Errorf("could not %s: %w", what, err))
+		return StateDeselect, nil
+	}
+	if !removed {
+		return StateDeselect, nil
+	}
+
+	// The date is gone from the timeline, so the next one is already at the
+	// top: no deselect and no scrolling
+	c.finishDate()
+	c.Stats.IncrementDatesProcessed()
+	c.Pacer.Wait(ctx)
+	return StateFindDate, nil
+}
@@ -0,0 +1,58 @@
+package extract
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/fsutil"
+)
+
+// Dedup modes for Options.Dedup.
+const (
+	DedupLink = "link" // Replace duplicates with hard links to the first copy
+	DedupSkip = "skip" // Delete duplicates
+)
+
+// Hashes records the content hash of every extracted file, so duplicates
+// can be found across dates and runs. catalog.Catalog implements it.
+type Hashes interface {
+	// Claim returns the path of a file already on disk with the given
+	// hash, or records path as the file for hash and returns "".
+	Claim(hash, path string) string
+}
+
+// ParseDedup validates a -dedup value.
+func ParseDedup(mode string) (string, error) {
+	switch m := strings.ToLower(mode); m {
+	case DedupLink, DedupSkip:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown mode %q (use link or skip)", mode)
+	}
+}
+
+// dedup replaces target, whose content has the given hash, according to
+// opts.Dedup if an identical file was extracted before. It reports whether
+// target was replaced or removed.
+func dedup(target, hash string, opts Options) (bool, error) {
+	if opts.Dedup == "" || opts.Hashes == nil {
+		return false, nil
+	}
+	original := opts.Hashes.Claim(hash, target)
+	if original == "" || original == target {
+		return false, nil
+	}
+	if err := os.Remove(target); err != nil {
+		return false, err
+	}
+	if opts.Dedup == DedupSkip {
+		return true, nil
+	}
+	if err := os.Link(original, target); err != nil {
+		// Hard links need both files on one file system that supports them
+		// (not FAT/exFAT); fall back to a copy
+		return false, fsutil.Copy(original, target)
+	}
+	return true, nil
+}
@@ -0,0 +1,300 @@
+// Package extract unpacks downloaded Yandex archives next to them, keeping
+// only the wanted media types.
+package extract
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// aliases maps extensions to the other spellings of the same format.
+var aliases = map[string][]string{
+	"jpg":  {"jpeg", "jpe"},
+	"jpeg": {"jpg", "jpe"},
+	"tif":  {"tiff"},
+	"tiff": {"tif"},
+	"heic": {"heif"},
+	"heif": {"heic"},
+}
+
+// Options controls what is extracted.
+type Options struct {
+	Types   map[string]bool // Lowercase extensions without dot; empty keeps every file
+	MinSize int64           // Smallest file kept, in bytes (0 disables)
+	MaxSize int64           // Largest file kept, in bytes (0 disables)
+
+	// NameTemplate renames extracted files, e.g. "{date}_{index}_{orig}"
+	// (see ValidateNameTemplate); empty keeps the names from the archive.
+	NameTemplate string
+
+	// Flatten drops the archive's internal folders, extracting every file
+	// directly into the destination.
+	Flatten bool
+
+	// Normalize converts extracted file names to Unicode "nfc" or "nfd";
+	// empty keeps them as stored in the archive.
+	Normalize string
+
+	// Dedup stores files identical to an earlier one once: DedupLink
+	// hard-links them, DedupSkip leaves them out. Empty keeps every copy.
+	Dedup  string
+	Hashes Hashes // Content hashes of extracted files; required by Dedup
+
+	// Provenance tags each extracted file with its date, RunID and name
+	// in the archive, in extended attributes (NTFS streams on Windows).
+	Provenance bool
+	RunID      string
+
+	// ReorganizeDir re-files extracted photos into folders of this
+	// directory named after their EXIF capture date (see refile), instead of
+	// the folder of their archive. Empty disables.
+	ReorganizeDir string
+
+	// SkipScreenshots leaves screenshots (see isScreenshot) out of the
+	// extraction or, if ScreenshotsDir is set, extracts them there instead.
+	SkipScreenshots bool
+	ScreenshotsDir  string
+}
+
+// ParseTypes parses a comma-separated list such as "jpg,heic,mp4" into
+// Options.Types, adding the usual alternative spellings (jpg/jpeg, tif/tiff).
+func ParseTypes(list string) (map[string]bool, error) {
+	types := make(map[string]bool)
+	for _, t := range strings.Split(list, ",") {
+		t = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(t), "."))
+		if t == "" {
+			continue
+		}
+		if strings.ContainsAny(t, `./\`) {
+			return nil, fmt.Errorf("invalid file type %q", t)
+		}
+		types[t] = true
+		for _, alias := range aliases[t] {
+			types[alias] = true
+		}
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("no file types in %q", list)
+	}
+	return types, nil
+}
+
+// ParseSize parses a size such as "500KB", "1.5GB" or "2000000" (bytes).
+// Units are binary: 1KB is 1024 bytes.
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(str, u.suffix) {
+			str, mult = strings.TrimSpace(strings.TrimSuffix(str, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 500KB, 1.5GB)", s)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// Wanted reports whether a file passes the type and size filters.
+func (o Options) Wanted(name string, size int64) bool {
+	if o.MinSize > 0 && size < o.MinSize {
+		return false
+	}
+	if o.MaxSize > 0 && size > o.MaxSize {
+		return false
+	}
+	if len(o.Types) == 0 {
+		return true
+	}
+	return o.Types[strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))]
+}
+
+// Result describes an extracted archive.
+type Result struct {
+	Files   []string // Paths of the extracted files
+	Skipped int      // Files left out by the filters
+	Bytes   int64    // Size of the extracted files
+	Entries int      // Files in the archive before any filter (1 for a single-photo download)
+
+	// Duplicates of earlier files that were hard-linked or left out, and
+	// the disk space this saved
+	Duplicates     int
+	DuplicateBytes int64
+
+	Refiled     int // Files moved by their EXIF capture date (see Options.ReorganizeDir)
+	Screenshots int // Screenshots left out or moved to Options.ScreenshotsDir
+}
+
+// Archive extracts a downloaded file into destDir. A zip archive is unpacked
+// keeping its internal layout; any other download is a single photo, which
+// is removed if the filters reject it, and is never renamed. The archive
+// itself is kept. date is the Yandex date label used by name templates.
+func Archive(path, destDir, date string, opts Options) (Result, error) {
+	var res Result
+
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		info, err := os.Stat(path)
+		if err != nil {
+			return res, err
+		}
+		res.Entries = 1
+		if !opts.Wanted(path, info.Size()) {
+			res.Skipped = 1
+			return res, os.Remove(path)
+		}
+		res.Files = []string{path}
+		res.Bytes = info.Size()
+		return res, nil
+	}
+
+	destDir, err := absDir(destDir)
+	if err != nil {
+		return res, err
+	}
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return res, fmt.Errorf("could not open %s: %w", filepath.Base(path), err)
+	}
+	defer r.Close()
+
+	used := make(map[string]bool)
+	index := 0
+	tag := opts.Provenance
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		res.Entries++
+		if !opts.Wanted(f.Name, int64(f.UncompressedSize64)) {
+			res.Skipped++
+			continue
+		}
+		dir := destDir
+		if opts.SkipScreenshots && isScreenshot(f) {
+			res.Screenshots++
+			if opts.ScreenshotsDir == "" {
+				continue
+			}
+			dir = opts.ScreenshotsDir
+		}
+		index++
+		name := normalizeName(sanitizeEntry(f.Name), opts.Normalize)
+		if opts.Flatten {
+			name = name[strings.LastIndex(name, "/")+1:]
+		}
+		if opts.NameTemplate != "" {
+			name = normalizeName(sanitizeEntry(TemplateName(opts.NameTemplate, name, date, index)), opts.Normalize)
+		}
+		// Flattening, templates, normalization and sanitizing can all give
+		// two entries the same name. The screenshots folder is shared by all
+		// dates, so there a file of an earlier archive is kept too.
+		shared := dir != destDir
+		var target string
+		var n int64
+		var hash string
+		for base := name; ; {
+			name = uniqueName(base, used)
+			if target, err = safeJoin(dir, name); err != nil {
+				return res, err
+			}
+			n, hash, err = writeEntry(f, target, shared)
+			if !shared || !errors.Is(err, fs.ErrExist) {
+				break
+			}
+		}
+		if err != nil {
+			return res, fmt.Errorf("%s: %s: %w", filepath.Base(path), f.Name, err)
+		}
+		if opts.ReorganizeDir != "" && dir == destDir {
+			moved, err := refile(target, opts.ReorganizeDir, opts)
+			if err != nil {
+				return res, fmt.Errorf("%s: %s: %w", filepath.Base(path), f.Name, err)
+			}
+			if moved != "" {
+				target = moved
+				res.Refiled++
+			}
+		}
+		duplicate, err := dedup(target, hash, opts)
+		if err != nil {
+			return res, fmt.Errorf("%s: %s: %w", filepath.Base(path), f.Name, err)
+		}
+		if duplicate {
+			res.Duplicates++
+			res.DuplicateBytes += n
+			if opts.Dedup == DedupSkip {
+				continue
+			}
+		}
+		// A hard link shares the attributes of the file it points to
+		if tag && !duplicate {
+			p := provenance{Date: date, RunID: opts.RunID, Source: filepath.Base(path) + "/" + f.Name}
+			if err := tagFile(target, p); err != nil {
+				log.Printf("⚠️ Could not tag files of %s with provenance: %v", filepath.Base(path), err)
+				tag = false
+			}
+		}
+		res.Files = append(res.Files, target)
+		res.Bytes += n
+	}
+	return res, nil
+}
+
+// safeJoin resolves an archive entry name inside dir, rejecting names that
+// would escape it.
+func safeJoin(dir, name string) (string, error) {
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if rel, err := filepath.Rel(dir, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %q points outside the destination", name)
+	}
+	return target, nil
+}
+
+// writeEntry decompresses a zip entry to target, preserving its modification
+// time, and returns its size and SHA-256 hash. If exclusive is set, an
+// existing target is left alone and fs.ErrExist returned.
+func writeEntry(f *zip.File, target string, exclusive bool) (int64, string, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, "", err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return 0, "", err
+	}
+	defer rc.Close()
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if exclusive {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+	out, err := os.OpenFile(target, flag, 0666)
+	if err != nil {
+		return 0, "", err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, h), rc)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(target)
+		return n, "", err
+	}
+	os.Chtimes(target, f.Modified, f.Modified)
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,92 @@
+package extract
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeZip creates an archive in dir holding one file per entry name, with
+// the name itself as content.
+func writeZip(t *testing.T, dir string, names ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, "archive.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestArchiveNormalizeCollision(t *testing.T) {
+	const (
+		nfc = "\u0439.jpg"       // й, composed
+		nfd = "\u0438\u0306.jpg" // и + combining breve
+	)
+	dir := t.TempDir()
+	path := writeZip(t, dir, nfc, nfd)
+	dest := filepath.Join(dir, "out")
+
+	res, err := Archive(path, dest, "", Options{Normalize: "nfc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Files) != 2 {
+		t.Fatalf("extracted %d files, want 2", len(res.Files))
+	}
+	want := map[string]string{
+		filepath.Join(dest, nfc):            nfc,
+		filepath.Join(dest, "\u0439_2.jpg"): nfd,
+	}
+	for _, file := range res.Files {
+		content, ok := want[file]
+		if !ok {
+			t.Errorf("unexpected file %q", file)
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%q holds %q, want %q", file, data, content)
+		}
+	}
+}
+
+func TestArchiveScreenshotsShared(t *testing.T) {
+	const name = "Screenshot_1.png"
+	dir := t.TempDir()
+	shots := filepath.Join(dir, "Screenshots")
+	opts := Options{SkipScreenshots: true, ScreenshotsDir: shots}
+
+	// Two dates whose archives hold a screenshot of the same name
+	for _, date := range []string{"first", "second"} {
+		path := writeZip(t, t.TempDir(), name)
+		if _, err := Archive(path, filepath.Join(dir, date), date, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{name, "Screenshot_1_2.png"} {
+		if _, err := os.Stat(filepath.Join(shots, file)); err != nil {
+			t.Errorf("screenshot of a date lost: %v", err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package extract
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/datefilter"
+)
+
+// tokenPattern matches a {token} of a name template.
+var tokenPattern = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// nameTokens are the tokens a name template may use.
+var nameTokens = map[string]bool{
+	"date":  true, // Date of the archive as YYYY-MM-DD, or its Yandex label
+	"index": true, // Position of the file in its archive, from 0001
+	"orig":  true, // Original file name without extension
+	"type":  true, // photo, video or file
+}
+
+// videoTypes are the extensions named "video" by the {type} token.
+var videoTypes = map[string]bool{
+	"mp4": true, "mov": true, "m4v": true, "avi": true, "mkv": true,
+	"3gp": true, "webm": true, "mts": true, "m2ts": true, "wmv": true,
+}
+
+// photoTypes are the extensions named "photo" by the {type} token.
+var photoTypes = map[string]bool{
+	"jpg": true, "jpeg": true, "jpe": true, "png": true, "heic": true, "heif": true,
+	"gif": true, "webp": true, "bmp": true, "tif": true, "tiff": true, "dng": true,
+	"cr2": true, "cr3": true, "nef": true, "arw": true, "raf": true, "orf": true, "rw2": true,
+}
+
+// ValidateNameTemplate checks that a -name-template only uses known tokens
+// and names a file rather than a path.
+func ValidateNameTemplate(tmpl string) error {
+	if strings.TrimSpace(tmpl) == "" {
+		return fmt.Errorf("empty template")
+	}
+	if strings.ContainsAny(tmpl, `/\`) {
+		return fmt.Errorf("template %q must not contain path separators", tmpl)
+	}
+	for _, m := range tokenPattern.FindAllStringSubmatch(tmpl, -1) {
+		if !nameTokens[m[1]] {
+			return fmt.Errorf("unknown token {%s} (use {date}, {index}, {orig} or {type})", m[1])
+		}
+	}
+	return nil
+}
+
+// ParseNormalize validates a -normalize value, returning it in lower case.
+func ParseNormalize(form string) (string, error) {
+	switch f := strings.ToLower(form); f {
+	case "nfc", "nfd":
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown form %q (use nfc or nfd)", form)
+	}
+}
+
+// normalizeName converts name to the Unicode normalization form set by
+// -normalize. macOS tends to store decomposed (NFD) names and Linux keeps
+// whatever it is given, so the same "й" can otherwise end up as two
+// visually identical but different files.
+func normalizeName(name, form string) string {
+	switch form {
+	case "nfc":
+		return norm.NFC.String(name)
+	case "nfd":
+		return norm.NFD.String(name)
+	default:
+		return name
+	}
+}
+
+// MediaType returns the {type} value of a file name: photo, video or file.
+func MediaType(name string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	switch {
+	case photoTypes[ext]:
+		return "photo"
+	case videoTypes[ext]:
+		return "video"
+	default:
+		return "file"
+	}
+}
+
+// dateToken formats a Yandex date label for file names.
+func dateToken(date string) string {
+	if date == "" {
+		return "undated"
+	}
+	return datefilter.DirName(date)
+}
+
+// TemplateName applies tmpl to an archive entry or file name, keeping its
+// directory and extension. index numbers the files of an archive from 1.
+func TemplateName(tmpl, entry, date string, index int) string {
+	base := filepath.Base(filepath.FromSlash(entry))
+	ext := filepath.Ext(base)
+	name := tokenPattern.ReplaceAllStringFunc(tmpl, func(tok string) string {
+		switch tok {
+		case "{date}":
+			return dateToken(date)
+		case "{index}":
+			return fmt.Sprintf("%04d", index)
+		case "{orig}":
+			return strings.TrimSuffix(base, ext)
+		case "{type}":
+			return MediaType(base)
+		}
+		return tok
+	})
+	if dir := filepath.Dir(filepath.FromSlash(entry)); dir != "." {
+		name = filepath.Join(dir, name)
+	}
+	return filepath.ToSlash(name + ext)
+}
+
+// uniqueName returns name, or name with a "_2", "_3"... suffix if another
+// entry of the same archive already got it, and records the result in used.
+func uniqueName(name string, used map[string]bool) string {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	candidate := name
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		candidate = stem + "_" + strconv.Itoa(n) + ext
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package extract
+
+// sanitizeEntry returns an archive entry name as is: any name Yandex can
+// store is valid on Unix file systems.
+func sanitizeEntry(name string) string {
+	return name
+}
+
+// absDir returns dir unchanged; Unix has no path length limit to work around.
+func absDir(dir string) (string, error) {
+	return dir, nil
+}
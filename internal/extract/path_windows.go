@@ -0,0 +1,52 @@
+//go:build windows
+
+package extract
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// reservedNames are the device names Windows refuses as file names, with or
+// without an extension.
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sanitizeEntry makes each element of a slash-separated archive entry name
+// valid on Windows: invalid characters become "_", trailing dots and spaces
+// are dropped and reserved device names get a "_" prefix.
+func sanitizeEntry(name string) string {
+	parts := strings.Split(name, "/")
+	for i, p := range parts {
+		if p == "" || p == "." || p == ".." {
+			continue // Left for safeJoin to resolve or reject
+		}
+		p = strings.Map(func(r rune) rune {
+			if r < 32 || strings.ContainsRune(`<>:"\|?*`, r) {
+				return '_'
+			}
+			return r
+		}, p)
+		p = strings.TrimRight(p, ". ")
+		if p == "" {
+			p = "_"
+		}
+		stem, _, _ := strings.Cut(p, ".")
+		if reservedNames[strings.ToUpper(strings.TrimRight(stem, " "))] {
+			p = "_" + p
+		}
+		parts[i] = p
+	}
+	return strings.Join(parts, "/")
+}
+
+// absDir makes dir absolute: the os package only adds the \\?\ prefix that
+// lifts the 260-character MAX_PATH limit to absolute paths.
+func absDir(dir string) (string, error) {
+	return filepath.Abs(dir)
+}
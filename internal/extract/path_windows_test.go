@@ -0,0 +1,56 @@
+//go:build windows
+
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSanitizeEntry(t *testing.T) {
+	for _, tc := range []struct{ name, want string }{
+		{"a:b.jpg", "a_b.jpg"},
+		{"photo.", "photo"},
+		{"dir /CON.jpg", "dir/_CON.jpg"},
+		{"../x?.jpg", "../x_.jpg"},
+	} {
+		if got := sanitizeEntry(tc.name); got != tc.want {
+			t.Errorf("sanitizeEntry(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestArchiveSanitizeCollision(t *testing.T) {
+	dir := t.TempDir()
+	path := writeZip(t, dir, "a_b.jpg", "a:b.jpg", "photo", "photo.")
+	dest := filepath.Join(dir, "out")
+
+	res, err := Archive(path, dest, "", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		filepath.Join(dest, "a_b.jpg"):   "a_b.jpg",
+		filepath.Join(dest, "a_b_2.jpg"): "a:b.jpg",
+		filepath.Join(dest, "photo"):     "photo",
+		filepath.Join(dest, "photo_2"):   "photo.",
+	}
+	if len(res.Files) != len(want) {
+		t.Fatalf("extracted %d files, want %d", len(res.Files), len(want))
+	}
+	for _, file := range res.Files {
+		content, ok := want[file]
+		if !ok {
+			t.Errorf("unexpected file %q", file)
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%q holds %q, want %q", file, data, content)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package extract
+
+// provenanceStream is the NTFS alternate data stream provenance is written
+// to on Windows.
+const provenanceStream = "yandex.provenance"
+
+// provenance describes where an extracted file came from. It is stored with
+// the file itself (see tagFile), so it survives even if sidecar files or the
+// catalog get separated from the export.
+type provenance struct {
+	Date   string // Yandex date label
+	RunID  string // Export run that extracted the file
+	Source string // Archive name and path of the file inside it
+}
+
+// attrs returns the provenance as attribute names and values.
+func (p provenance) attrs() map[string]string {
+	attrs := map[string]string{
+		"yandex.run":    p.RunID,
+		"yandex.source": p.Source,
+	}
+	if p.Date != "" {
+		attrs["yandex.date"] = p.Date
+	}
+	return attrs
+}
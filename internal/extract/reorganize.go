@@ -0,0 +1,45 @@
+package extract
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/exif"
+)
+
+// refile moves an extracted file into a folder of root named after its EXIF
+// capture date, e.g. root/2023-01-12/2023-01-12_143005.jpg. Files keep the
+// name set by a name template, if any. It returns the new path, or "" if the
+// file has no capture date and stays where it is.
+func refile(target, root string, opts Options) (string, error) {
+	info, err := exif.Read(target)
+	if err != nil || info.Taken.IsZero() {
+		return "", nil // Videos and stripped photos keep their place
+	}
+
+	dir := filepath.Join(root, info.Taken.Format("2006-01-02"))
+	name := filepath.Base(target)
+	if opts.NameTemplate == "" {
+		name = info.Taken.Format("2006-01-02_150405") + strings.ToLower(filepath.Ext(target))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	dest := filepath.Join(dir, name)
+	for n := 2; ; n++ {
+		if _, err := os.Lstat(dest); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		dest = filepath.Join(dir, stem+"_"+strconv.Itoa(n)+ext)
+	}
+	if err := os.Rename(target, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
@@ -0,0 +1,53 @@
+package extract
+
+import (
+	"archive/zip"
+	"path"
+	"regexp"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/exif"
+)
+
+// screenshotName matches the file names phones and desktops give screenshots.
+var screenshotName = regexp.MustCompile(`(?i)screen ?shot|screen_?capture|снимок экрана|скриншот|^scr_|^ss_`)
+
+// screenSizes are common screen resolutions, width x height in landscape.
+var screenSizes = map[[2]int]bool{
+	// Desktops and laptops
+	{1280, 720}: true, {1280, 800}: true, {1366, 768}: true, {1440, 900}: true,
+	{1536, 864}: true, {1600, 900}: true, {1680, 1050}: true, {1920, 1080}: true,
+	{1920, 1200}: true, {2560, 1440}: true, {2560, 1600}: true, {2880, 1800}: true,
+	{3024, 1964}: true, {3456, 2234}: true, {3840, 2160}: true,
+	// iPhones
+	{1334, 750}: true, {2208, 1242}: true, {2436, 1125}: true, {1792, 828}: true,
+	{2688, 1242}: true, {2532, 1170}: true, {2778, 1284}: true, {2556, 1179}: true,
+	{2796, 1290}: true, {2340, 1080}: true,
+	// Android phones
+	{2400, 1080}: true, {2220, 1080}: true, {3200, 1440}: true, {3120, 1440}: true,
+	{2960, 1440}: true, {1600, 720}: true, {2412, 1080}: true,
+	// Tablets
+	{2048, 1536}: true, {2732, 2048}: true, {2388, 1668}: true, {2160, 1620}: true,
+	{2360, 1640}: true,
+}
+
+// isScreenshot guesses whether an archive entry is a screenshot: its name
+// says so, or it has no camera model and exactly the size of a screen.
+func isScreenshot(f *zip.File) bool {
+	if screenshotName.MatchString(path.Base(f.Name)) {
+		return true
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return false
+	}
+	defer rc.Close()
+	info, err := exif.ReadFrom(rc)
+	if err != nil || info.Model != "" {
+		return false
+	}
+	w, h := info.Width, info.Height
+	if h > w {
+		w, h = h, w
+	}
+	return screenSizes[[2]int{w, h}]
+}
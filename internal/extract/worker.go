@@ -0,0 +1,98 @@
+package extract
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/crash"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/download"
+)
+
+// Worker extracts finished downloads in the background, one at a time, so
+// the export moves on to the next date meanwhile.
+type Worker struct {
+	opts Options
+	done func(download.File, Result) // Called after each successful extraction
+
+	mu sync.Mutex // Serializes extractions
+	wg sync.WaitGroup
+
+	// Totals over all archives
+	files          int
+	skipped        int
+	duplicates     int
+	duplicateBytes int64
+}
+
+// NewWorker creates a Worker. done may be nil.
+func NewWorker(opts Options, done func(download.File, Result)) *Worker {
+	return &Worker{opts: opts, done: done}
+}
+
+// Add queues a finished download. It does not block.
+func (w *Worker) Add(f download.File) {
+	w.wg.Add(1)
+	go func() {
+		defer crash.Recover()
+		defer w.wg.Done()
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		// Each archive gets its own folder: Yandex reuses archive-internal names
+		dest := strings.TrimSuffix(f.Path, filepath.Ext(f.Path))
+		res, err := Archive(f.Path, dest, f.Date, w.opts)
+		if err != nil {
+			log.Printf("⚠️ Could not extract %s: %v", f.Name, err)
+			return
+		}
+		w.files += len(res.Files)
+		w.skipped += res.Skipped
+		w.duplicates += res.Duplicates
+		w.duplicateBytes += res.DuplicateBytes
+		LogResult(f.Name, res)
+		if w.done != nil {
+			w.done(f, res)
+		}
+	}()
+}
+
+// LogResult logs the outcome of extracting the download named name.
+func LogResult(name string, res Result) {
+	var notes []string
+	if res.Skipped > 0 {
+		notes = append(notes, fmt.Sprintf("%d filtered out", res.Skipped))
+	}
+	if res.Duplicates > 0 {
+		notes = append(notes, fmt.Sprintf("%d duplicates", res.Duplicates))
+	}
+	if res.Screenshots > 0 {
+		notes = append(notes, fmt.Sprintf("%d screenshots", res.Screenshots))
+	}
+	if res.Refiled > 0 {
+		notes = append(notes, fmt.Sprintf("%d re-filed by capture date", res.Refiled))
+	}
+	if len(notes) > 0 {
+		log.Printf("📦 Extracted %s: %d files (%s)", name, len(res.Files), strings.Join(notes, ", "))
+	} else {
+		log.Printf("📦 Extracted %s: %d files", name, len(res.Files))
+	}
+}
+
+// Close waits for queued extractions and returns the totals.
+func (w *Worker) Close() (files, skipped int) {
+	w.wg.Wait()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.files, w.skipped
+}
+
+// Duplicates returns how many duplicate files were deduplicated and the
+// disk space this saved. Call it after Close.
+func (w *Worker) Duplicates() (files int, bytes int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.duplicates, w.duplicateBytes
+}
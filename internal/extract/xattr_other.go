@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package extract
+
+import "errors"
+
+// tagFile is not supported on this platform.
+func tagFile(path string, p provenance) error {
+	return errors.New("extended attributes are not supported on this platform")
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package extract
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// tagFile records provenance in an NTFS alternate data stream named
+// after provenanceStream, as name=value lines.
+func tagFile(path string, p provenance) error {
+	var lines []string
+	for name, value := range p.attrs() {
+		lines = append(lines, name+"="+value)
+	}
+	sort.Strings(lines)
+	return os.WriteFile(path+":"+provenanceStream, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0644)
+}
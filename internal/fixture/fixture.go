@@ -0,0 +1,144 @@
+// Package fixture serves a fake Yandex Disk Photos timeline for end-to-end
+// runs without an account: -target-url fixture points the exporter at it.
+// The page is a trimmed copy of the timeline markup the page scripts and
+// selectors rely on (date labels, checkboxes, the selection toolbar and the
+// "Show:" filter menu), with a small script standing in for Yandex's, and
+// Download serves a real zip archive of the date's photos. A run against it
+// goes through select, download, extract and report like a real one.
+package fixture
+
+import (
+	"archive/zip"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//go:embed page.html
+var pageHTML string
+
+var page = template.Must(template.New("page").Parse(pageHTML))
+
+// Path is the path of the timeline page, as on Yandex.
+const Path = "/client/photo"
+
+// Date is a date of the fake timeline.
+type Date struct {
+	Label  string // As Yandex shows it, e.g. "14 March"
+	Photos []Photo
+}
+
+// Photo is a photo of the fake timeline.
+type Photo struct {
+	Name string
+	Size int64 // Size of the file in the archive
+}
+
+// Dates are the dates of the fake timeline, newest first like on Yandex.
+// Labels have no year, so they parse as dates of the current year.
+var Dates = newDates([]string{"14 March", "2 March", "27 February", "9 January"}, []int{3, 5, 2, 4})
+
+// photoJPEG is the content of every photo: a small valid JPEG, so
+// extraction and the pipeline steps have a real image to work on.
+var photoJPEG = newJPEG()
+
+// newDates numbers the photos of each date in turn, IMG_0001.jpg onwards.
+func newDates(labels []string, counts []int) []Date {
+	dates := make([]Date, len(labels))
+	n := 0
+	for i, label := range labels {
+		dates[i].Label = label
+		for range counts[i] {
+			n++
+			dates[i].Photos = append(dates[i].Photos, Photo{Name: fmt.Sprintf("IMG_%04d.jpg", n), Size: int64(len(photoJPEG))})
+		}
+	}
+	return dates
+}
+
+// newJPEG encodes a 64×64 gray image.
+func newJPEG() []byte {
+	img := image.NewGray(image.Rect(0, 0, 64, 64))
+	for i := range img.Pix {
+		img.Pix[i] = color.Gray{Y: 128}.Y
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// Start listens on addr (e.g. "127.0.0.1:0") and serves the fake timeline
+// in the background. It returns the URL of the timeline page.
+func Start(addr string) (string, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("could not start fixture server on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+Path, handlePage)
+	mux.HandleFunc("GET /thumb/{name}", handleThumb)
+	mux.HandleFunc("GET /archive/{date}", handleArchive)
+
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			log.Printf("⚠️ Fixture server stopped: %v", err)
+		}
+	}()
+	return "http://" + ln.Addr().String() + Path, nil
+}
+
+// handlePage renders the timeline.
+func handlePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, Dates); err != nil {
+		log.Printf("⚠️ Fixture page failed: %v", err)
+	}
+}
+
+// handleThumb serves the preview of a photo.
+func handleThumb(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Write(photoJPEG)
+}
+
+// handleArchive serves the zip archive of the date at the given index of
+// Dates, as an attachment like Yandex's Download does.
+func handleArchive(w http.ResponseWriter, r *http.Request) {
+	i, err := strconv.Atoi(r.PathValue("date"))
+	if err != nil || i < 0 || i >= len(Dates) {
+		http.NotFound(w, r)
+		return
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, p := range Dates[i].Photos {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: p.Name, Method: zip.Store, Modified: time.Now()})
+		if err == nil {
+			_, err = f.Write(photoJPEG)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="fixture-%d.zip"`, i+1))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,216 @@
+// Package forensics collects evidence about failures (screenshot, DOM, console
+// output and recent log events) into a debug bundle for issue reports.
+package forensics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/logging"
+	cdplog "github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// MaxEntries is the number of recent log lines and console messages kept.
+	MaxEntries = 200
+	// dumpTimeout bounds how long a bundle capture may take on a wedged page.
+	dumpTimeout = 20 * time.Second
+)
+
+// Collector keeps recent log and console output and writes debug bundles.
+type Collector struct {
+	dir     string
+	mu      sync.Mutex
+	events  []string // Recent lines written to the tool's log
+	console []string // Recent browser console messages
+}
+
+// New creates a Collector that writes bundles under dir.
+func New(dir string) *Collector {
+	return &Collector{dir: dir}
+}
+
+// Write implements io.Writer so the Collector can be attached to the standard
+// logger with io.MultiWriter, keeping the most recent log lines.
+func (c *Collector) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		c.events = appendRing(c.events, line)
+	}
+	return len(p), nil
+}
+
+// Watch starts recording console output, JavaScript errors and failed network
+// requests of the given tab. Everything is kept for debug bundles; errors are
+// also surfaced in the tool's log at debug level.
+func (c *Collector) Watch(ctx context.Context) error {
+	var mu sync.Mutex
+	requests := make(map[network.RequestID]string) // In-flight request URLs, to name failures
+
+	chromedp.ListenTarget(ctx, func(ev any) {
+		switch e := ev.(type) {
+		case *runtime.EventConsoleAPICalled:
+			msg := fmt.Sprintf("[%s] %s", e.Type, formatArgs(e.Args))
+			c.AddConsole(msg)
+			if e.Type == "error" {
+				logging.Debugf("Page console: %s", msg)
+			}
+		case *runtime.EventExceptionThrown:
+			msg := "JS error: " + formatException(e.ExceptionDetails)
+			c.AddConsole(msg)
+			logging.Debugf("Page %s", msg)
+		case *cdplog.EventEntryAdded:
+			if e.Entry == nil {
+				return
+			}
+			msg := fmt.Sprintf("[%s/%s] %s", e.Entry.Source, e.Entry.Level, e.Entry.Text)
+			c.AddConsole(msg)
+			if e.Entry.Level == "error" {
+				logging.Debugf("Page log: %s", msg)
+			}
+		case *network.EventRequestWillBeSent:
+			if e.Request != nil {
+				mu.Lock()
+				requests[e.RequestID] = e.Request.URL
+				mu.Unlock()
+			}
+		case *network.EventResponseReceived:
+			mu.Lock()
+			delete(requests, e.RequestID)
+			mu.Unlock()
+			if e.Response != nil && e.Response.Status >= 400 {
+				msg := fmt.Sprintf("HTTP %d: %s", e.Response.Status, e.Response.URL)
+				c.AddConsole(msg)
+				logging.Debugf("Page request failed: %s", msg)
+			}
+		case *network.EventLoadingFailed:
+			mu.Lock()
+			url := requests[e.RequestID]
+			delete(requests, e.RequestID)
+			mu.Unlock()
+			if !e.Canceled {
+				msg := fmt.Sprintf("%s: %s", e.ErrorText, url)
+				c.AddConsole("Request failed: " + msg)
+				logging.Debugf("Page request failed: %s", msg)
+			}
+		}
+	})
+	return browser.Run(ctx, runtime.Enable(), cdplog.Enable(), network.Enable())
+}
+
+// AddConsole records a browser console message.
+func (c *Collector) AddConsole(msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.console = appendRing(c.console, time.Now().Format("15:04:05")+" "+msg)
+}
+
+// Dump writes a debug bundle for the given failure and returns its directory.
+// Each artifact is best-effort: a dead page still produces the log files.
+func (c *Collector) Dump(ctx context.Context, reason error) (string, error) {
+	dir := filepath.Join(c.dir, time.Now().Format("20060102-150405"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("could not create debug bundle directory: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dumpTimeout)
+	defer cancel()
+
+	var notes []string
+	note := func(what string, err error) {
+		notes = append(notes, fmt.Sprintf("%s: %v", what, err))
+	}
+
+	if url, err := browser.GetCurrentURL(ctx); err != nil {
+		note("url", err)
+	} else if err := os.WriteFile(filepath.Join(dir, "url.txt"), []byte(url+"\n"), 0644); err != nil {
+		note("url", err)
+	}
+
+	var screenshot []byte
+	if err := browser.Run(ctx, chromedp.CaptureScreenshot(&screenshot)); err != nil {
+		note("screenshot", err)
+	} else if err := os.WriteFile(filepath.Join(dir, "screenshot.png"), screenshot, 0644); err != nil {
+		note("screenshot", err)
+	}
+
+	var html string
+	if err := browser.Evaluate(ctx, `document.documentElement.outerHTML`, &html); err != nil {
+		note("dom", err)
+	} else if err := os.WriteFile(filepath.Join(dir, "page.html"), []byte(html), 0644); err != nil {
+		note("dom", err)
+	}
+
+	c.mu.Lock()
+	events := strings.Join(c.events, "\n")
+	console := strings.Join(c.console, "\n")
+	c.mu.Unlock()
+
+	if err := os.WriteFile(filepath.Join(dir, "events.log"), []byte(events+"\n"), 0644); err != nil {
+		note("events", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "console.log"), []byte(console+"\n"), 0644); err != nil {
+		note("console", err)
+	}
+
+	summary := fmt.Sprintf("Time: %s\nError: %v\n", time.Now().Format(time.RFC3339), reason)
+	if len(notes) > 0 {
+		summary += "\nCapture problems:\n" + strings.Join(notes, "\n") + "\n"
+	}
+	if err := os.WriteFile(filepath.Join(dir, "error.txt"), []byte(summary), 0644); err != nil {
+		return "", err
+	}
+
+	log.Printf("🧾 Debug bundle saved: %s", dir)
+	return dir, nil
+}
+
+// appendRing appends an entry, dropping the oldest once MaxEntries is reached.
+func appendRing(entries []string, entry string) []string {
+	if len(entries) >= MaxEntries {
+		entries = entries[1:]
+	}
+	return append(entries, entry)
+}
+
+// formatException renders a JavaScript exception with its location.
+func formatException(d *runtime.ExceptionDetails) string {
+	if d == nil {
+		return "unknown exception"
+	}
+	text := d.Text
+	if d.Exception != nil && d.Exception.Description != "" {
+		text = d.Exception.Description
+	}
+	if d.URL != "" {
+		text += fmt.Sprintf(" (%s:%d:%d)", d.URL, d.LineNumber+1, d.ColumnNumber+1)
+	}
+	return text
+}
+
+// formatArgs renders console call arguments as a single line.
+func formatArgs(args []*runtime.RemoteObject) string {
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch {
+		case len(arg.Value) > 0:
+			parts = append(parts, strings.Trim(string(arg.Value), `"`))
+		case arg.Description != "":
+			parts = append(parts, arg.Description)
+		default:
+			parts = append(parts, string(arg.Type))
+		}
+	}
+	return strings.Join(parts, " ")
+}
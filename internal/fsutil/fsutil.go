@@ -0,0 +1,49 @@
+// Package fsutil moves and copies files for the packages that rearrange
+// the downloads: the download tracker, extraction and pipelines.
+package fsutil
+
+import (
+	"io"
+	"os"
+)
+
+// Move moves src to dst, copying it when they are on different file
+// systems, e.g. from a sandboxed browser's staging folder to an external
+// disk.
+func Move(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := Copy(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// Copy copies src to dst, preserving its modification time. A partly
+// written dst is removed.
+func Copy(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,195 @@
+// Package geo collects the locations of exported photos and writes them as a
+// GeoJSON, GPX or KML file, e.g. to build a travel map from the archive.
+//
+// All methods are safe to call on a nil *Collector, which collects nothing.
+package geo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formats supported by New.
+var Formats = map[string]string{
+	"geojson": ".geojson",
+	"gpx":     ".gpx",
+	"kml":     ".kml",
+}
+
+// Point is the location of one photo.
+type Point struct {
+	File  string    // Path relative to the download directory
+	Date  string    // Yandex date label
+	Taken time.Time // Capture time; zero if unknown
+	Lat   float64
+	Lon   float64
+}
+
+// Collector accumulates points and writes them to one file per run.
+type Collector struct {
+	format string
+	dir    string
+	path   string
+
+	mu     sync.Mutex
+	points []Point
+}
+
+// New creates a Collector that writes to dir/yandex-geo-<runID> with the
+// extension of format ("geojson", "gpx" or "kml").
+func New(format, dir, runID string) (*Collector, error) {
+	format = strings.ToLower(format)
+	ext, ok := Formats[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q (use geojson, gpx or kml)", format)
+	}
+	return &Collector{
+		format: format,
+		dir:    dir,
+		path:   filepath.Join(dir, "yandex-geo-"+runID+ext),
+	}, nil
+}
+
+// Add records the location of file, which is made relative to the
+// collector's directory.
+func (c *Collector) Add(p Point) {
+	if c == nil {
+		return
+	}
+	if rel, err := filepath.Rel(c.dir, p.File); err == nil {
+		p.File = filepath.ToSlash(rel)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.points = append(c.points, p)
+}
+
+// Write saves the collected points, sorted by capture time, and returns the
+// file written and the number of points. Nothing is written if no photo was
+// geotagged.
+func (c *Collector) Write() (string, int, error) {
+	if c == nil {
+		return "", 0, nil
+	}
+	c.mu.Lock()
+	points := append([]Point(nil), c.points...)
+	c.mu.Unlock()
+	if len(points) == 0 {
+		return "", 0, nil
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Taken.Before(points[j].Taken)
+	})
+
+	var (
+		b   []byte
+		err error
+	)
+	switch c.format {
+	case "geojson":
+		b, err = geoJSON(points)
+	case "gpx":
+		b, err = gpx(points)
+	case "kml":
+		b, err = kml(points)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.WriteFile(c.path, b, 0644); err != nil {
+		return "", 0, err
+	}
+	return c.path, len(points), nil
+}
+
+// geoJSON encodes points as a FeatureCollection.
+func geoJSON(points []Point) ([]byte, error) {
+	type geometry struct {
+		Type        string     `json:"type"`
+		Coordinates [2]float64 `json:"coordinates"` // Longitude first
+	}
+	type feature struct {
+		Type       string            `json:"type"`
+		Geometry   geometry          `json:"geometry"`
+		Properties map[string]string `json:"properties"`
+	}
+	features := make([]feature, 0, len(points))
+	for _, p := range points {
+		props := map[string]string{"file": p.File, "date": p.Date}
+		if !p.Taken.IsZero() {
+			props["taken"] = p.Taken.Format("2006-01-02T15:04:05")
+		}
+		features = append(features, feature{
+			Type:       "Feature",
+			Geometry:   geometry{Type: "Point", Coordinates: [2]float64{p.Lon, p.Lat}},
+			Properties: props,
+		})
+	}
+	return json.MarshalIndent(map[string]any{"type": "FeatureCollection", "features": features}, "", "  ")
+}
+
+// gpx encodes points as GPX waypoints.
+func gpx(points []Point) ([]byte, error) {
+	type wpt struct {
+		Lat  float64 `xml:"lat,attr"`
+		Lon  float64 `xml:"lon,attr"`
+		Time string  `xml:"time,omitempty"`
+		Name string  `xml:"name"`
+		Desc string  `xml:"desc,omitempty"`
+	}
+	doc := struct {
+		XMLName   xml.Name `xml:"gpx"`
+		Version   string   `xml:"version,attr"`
+		Creator   string   `xml:"creator,attr"`
+		Namespace string   `xml:"xmlns,attr"`
+		Waypoints []wpt    `xml:"wpt"`
+	}{Version: "1.1", Creator: "yandex-disk-photo-exporter", Namespace: "http://www.topografix.com/GPX/1/1"}
+	for _, p := range points {
+		w := wpt{Lat: p.Lat, Lon: p.Lon, Name: p.File, Desc: p.Date}
+		if !p.Taken.IsZero() {
+			// GPX wants UTC; EXIF times have no zone, so this is approximate
+			w.Time = p.Taken.UTC().Format(time.RFC3339)
+		}
+		doc.Waypoints = append(doc.Waypoints, w)
+	}
+	return marshalXML(doc)
+}
+
+// kml encodes points as KML placemarks.
+func kml(points []Point) ([]byte, error) {
+	type placemark struct {
+		Name        string `xml:"name"`
+		Description string `xml:"description,omitempty"`
+		When        string `xml:"TimeStamp>when,omitempty"`
+		Coordinates string `xml:"Point>coordinates"`
+	}
+	doc := struct {
+		XMLName    xml.Name    `xml:"kml"`
+		Namespace  string      `xml:"xmlns,attr"`
+		Name       string      `xml:"Document>name"`
+		Placemarks []placemark `xml:"Document>Placemark"`
+	}{Namespace: "http://www.opengis.net/kml/2.2", Name: "Yandex Disk photos"}
+	for _, p := range points {
+		pm := placemark{Name: p.File, Description: p.Date, Coordinates: fmt.Sprintf("%f,%f", p.Lon, p.Lat)}
+		if !p.Taken.IsZero() {
+			pm.When = p.Taken.Format("2006-01-02T15:04:05")
+		}
+		doc.Placemarks = append(doc.Placemarks, pm)
+	}
+	return marshalXML(doc)
+}
+
+func marshalXML(v any) ([]byte, error) {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(b, '\n')...), nil
+}
@@ -0,0 +1,162 @@
+// Package inventory lists the files of a date on Yandex Disk without
+// selecting or downloading them, for a file-level manifest of the library,
+// and tells which storage they are in.
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/catalog"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/extract"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/locale"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/navigation"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/selection"
+)
+
+const (
+	// maxScrolls bounds the scrolling through one very large date.
+	maxScrolls = 200
+	// scrollViewports is how far each scroll moves, leaving some overlap
+	// so no row is skipped.
+	scrollViewports = 0.8
+	// renderWait lets the timeline render the photos scrolled into view.
+	renderWait = 700 * time.Millisecond
+	// nextDateOffset places the next date label this far below where
+	// ScrollToPosition moves the processed one, clear of the header.
+	nextDateOffset = 50
+)
+
+// shown is the result of the date_items script.
+type shown struct {
+	Items []item   `json:"items"`
+	NextY *float64 `json:"nextY"`
+}
+
+// item is a photo or video of shown.
+type item struct {
+	Name      string `json:"name"`
+	Video     bool   `json:"video"`
+	Size      string `json:"size"`
+	Unlimited bool   `json:"unlimited"`
+}
+
+// photo returns the catalog record of it.
+func (it item) photo() catalog.Photo {
+	p := catalog.Photo{Name: it.Name, Type: extract.MediaType(it.Name)}
+	if it.Video {
+		p.Type = "video"
+	}
+	if it.Size != "" {
+		p.Size, _ = extract.ParseSize(cyrillicUnits.Replace(it.Size))
+	}
+	return p
+}
+
+// cyrillicUnits maps the size units of the Russian interface to the ones
+// extract.ParseSize reads.
+var cyrillicUnits = strings.NewReplacer("ТБ", "TB", "ГБ", "GB", "МБ", "MB", "КБ", "KB", "Б", "B", "тб", "TB", "гб", "GB", "мб", "MB", "кб", "KB", "б", "B", ",", ".")
+
+// List returns the files of date in the order shown. Yandex only renders
+// the photos near the viewport, so List scrolls through dates taller than
+// the screen until the next date label appears. After scrolling, it sets
+// date.YPosition so that navigation.ScrollToPosition brings the next date
+// label near the top of the screen.
+func List(ctx context.Context, date *selection.DateInfo) ([]catalog.Photo, error) {
+	var photos []catalog.Photo
+	seen := make(map[string]bool)
+	scrolled := false
+	for range maxScrolls {
+		res, err := items(ctx, date)
+		if err != nil {
+			return nil, fmt.Errorf("could not list the files: %w", err)
+		}
+		for _, it := range res.Items {
+			if seen[it.Name] {
+				continue
+			}
+			seen[it.Name] = true
+			photos = append(photos, it.photo())
+		}
+		if res.NextY != nil {
+			if scrolled {
+				date.YPosition = *res.NextY - nextDateOffset
+			}
+			return photos, nil
+		}
+
+		before, err := navigation.CurrentScrollY(ctx)
+		if err != nil {
+			return nil, err
+		}
+		after, err := navigation.ScrollViewports(ctx, scrollViewports)
+		if err != nil {
+			return nil, err
+		}
+		if after-before < 1 {
+			return photos, nil // End of the timeline
+		}
+		date.YPosition -= after - before
+		scrolled = true
+		browser.Pause(ctx, renderWait)
+	}
+	return photos, fmt.Errorf("gave up after %d screens; the list may be incomplete", maxScrolls)
+}
+
+// Shown returns the files of date on screen, without scrolling. complete
+// reports that the next date label is on screen too, so they are all the
+// files of the date.
+func Shown(ctx context.Context, date *selection.DateInfo) (photos []catalog.Photo, complete bool, err error) {
+	res, err := items(ctx, date)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not list the files: %w", err)
+	}
+	for _, it := range res.Items {
+		photos = append(photos, it.photo())
+	}
+	return photos, res.NextY != nil, nil
+}
+
+// Storage sources told apart by Source.
+const (
+	Unlimited = "unlimited" // Unlimited storage
+	Disk      = "disk"      // Regular Disk space
+)
+
+// Source tells whether the photos of date are in unlimited storage or in
+// regular Disk space, from the badges of those shown on screen. A date
+// with photos of both is reported as Unlimited, so the photos that must be
+// kept before unlimited storage ends are all found under one source; mixed
+// reports that case.
+func Source(ctx context.Context, date *selection.DateInfo) (source string, mixed bool, err error) {
+	res, err := items(ctx, date)
+	if err != nil {
+		return "", false, fmt.Errorf("could not read the badges: %w", err)
+	}
+	if len(res.Items) == 0 {
+		return "", false, errors.New("no photos of the date are shown")
+	}
+	unlimited := 0
+	for _, it := range res.Items {
+		if it.Unlimited {
+			unlimited++
+		}
+	}
+	if unlimited == 0 {
+		return Disk, false, nil
+	}
+	return Unlimited, unlimited < len(res.Items), nil
+}
+
+// items runs the date_items script for date.
+func items(ctx context.Context, date *selection.DateInfo) (shown, error) {
+	var res shown
+	l := locale.Current()
+	err := browser.Evaluate(ctx, scripts.Call("date_items", date.YPosition, l.Months, l.Filters["unlimited"]), &res)
+	return res, err
+}
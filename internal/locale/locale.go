@@ -0,0 +1,212 @@
+// Package locale holds the Yandex Disk interface strings the exporter looks
+// for, per interface language, and detects the language of the page.
+//
+// Until a language is chosen with Use, the labels of all known languages are
+// matched at once.
+package locale
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/datefilter"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
+)
+
+// Labels are the interface strings of one language.
+type Labels struct {
+	Download []string            `json:"download"` // Name of the Download button
+	Close    []string            `json:"close"`    // Parts of the close/deselect button's name, lower case
+	Delete   []string            `json:"delete"`   // Name of the Delete button, and start of the Trash dialog's confirm button
+	Show     []string            `json:"show"`     // Start of the filter button's name, as in "Show: All photos"
+	Filters  map[string][]string `json:"filters"`  // Parts of the filter option names, lower case, by -mode name
+	Months   []string            `json:"months"`   // Month names as used in date labels ("12 January")
+}
+
+// Languages are the known interface languages, by ISO 639-1 code.
+var Languages = map[string]Labels{
+	"en": {
+		Download: []string{"Download"},
+		Close:    []string{"close", "deselect", "cancel selection"},
+		Delete:   []string{"Delete", "Move to trash"},
+		Show:     []string{"Show:"},
+		Filters: map[string][]string{
+			"unlimited": {"unlimited storage"},
+			"services":  {"services", "social"},
+			"all":       {"all photos"},
+		},
+		Months: []string{"January", "February", "March", "April", "May", "June",
+			"July", "August", "September", "October", "November", "December"},
+	},
+	"ru": {
+		Download: []string{"Скачать"},
+		Close:    []string{"снять выделение", "отменить выделение", "закрыть"},
+		Delete:   []string{"Удалить", "Переместить в корзину"},
+		Show:     []string{"Показать:"},
+		Filters: map[string][]string{
+			"unlimited": {"безлимит"},
+			"services":  {"сервис", "соцсет"},
+			"all":       {"все фото"},
+		},
+		Months: []string{"января", "февраля", "марта", "апреля", "мая", "июня",
+			"июля", "августа", "сентября", "октября", "ноября", "декабря"},
+	},
+}
+
+// order is the order labels of several languages are merged in.
+var order = []string{"en", "ru"}
+
+var (
+	mu      sync.Mutex
+	current string // Language chosen with Use (empty matches all)
+)
+
+// Use makes Current return the labels of lang. It reports false, and keeps
+// matching all languages, if lang is not known.
+func Use(lang string) bool {
+	if _, ok := Languages[lang]; !ok {
+		return false
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current = lang
+	return true
+}
+
+// Current returns the labels of the language chosen with Use, or those of
+// all known languages merged. Lists a chosen language leaves empty, as a
+// language added by Load may, are taken from the merged labels.
+func Current() Labels {
+	mu.Lock()
+	lang := current
+	mu.Unlock()
+	merged := mergedLabels()
+	if lang == "" {
+		return merged
+	}
+
+	l := Languages[lang]
+	if len(l.Download) == 0 {
+		l.Download = merged.Download
+	}
+	if len(l.Close) == 0 {
+		l.Close = merged.Close
+	}
+	if len(l.Delete) == 0 {
+		l.Delete = merged.Delete
+	}
+	if len(l.Show) == 0 {
+		l.Show = merged.Show
+	}
+	if len(l.Months) == 0 {
+		l.Months = merged.Months
+	}
+	filters := make(map[string][]string, len(merged.Filters))
+	for name, words := range merged.Filters {
+		if own := l.Filters[name]; len(own) > 0 {
+			words = own
+		}
+		filters[name] = words
+	}
+	l.Filters = filters
+	return l
+}
+
+// mergedLabels returns the labels of all known languages together.
+func mergedLabels() Labels {
+	merged := Labels{Filters: make(map[string][]string)}
+	for _, code := range order {
+		l := Languages[code]
+		merged.Download = append(merged.Download, l.Download...)
+		merged.Close = append(merged.Close, l.Close...)
+		merged.Delete = append(merged.Delete, l.Delete...)
+		merged.Show = append(merged.Show, l.Show...)
+		merged.Months = append(merged.Months, l.Months...)
+		for name, words := range l.Filters {
+			merged.Filters[name] = append(merged.Filters[name], words...)
+		}
+	}
+	return merged
+}
+
+// Load adds the labels of a JSON file to the known languages, so new
+// interface strings can be matched without rebuilding. It must be called
+// before the export starts. The file maps language codes to Labels; lists
+// of known languages are extended and unknown languages are added:
+//
+//	{"tr": {"download": ["İndir"], "close": ["kapat"], "delete": ["Sil"], "show": ["Göster:"]},
+//	 "ru": {"filters": {"services": ["другие сервисы"]}}}
+//
+// Months, when given, must list all twelve names from January on.
+func Load(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read labels: %w", err)
+	}
+	var extra map[string]Labels
+	if err := json.Unmarshal(b, &extra); err != nil {
+		return fmt.Errorf("could not parse labels %s: %w", path, err)
+	}
+
+	codes := make([]string, 0, len(extra))
+	for code := range extra {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		code, add := strings.ToLower(code), extra[code]
+		if len(add.Months) > 0 {
+			if err := datefilter.AddMonthNames(add.Months); err != nil {
+				return fmt.Errorf("labels for %q: %w", code, err)
+			}
+		}
+		l, known := Languages[code]
+		if !known {
+			order = append(order, code)
+		}
+		l.Download = append(slices.Clip(l.Download), add.Download...)
+		l.Close = append(slices.Clip(l.Close), lower(add.Close)...)
+		l.Delete = append(slices.Clip(l.Delete), add.Delete...)
+		l.Show = append(slices.Clip(l.Show), add.Show...)
+		l.Months = append(slices.Clip(l.Months), add.Months...)
+		filters := make(map[string][]string, len(l.Filters))
+		for name, words := range l.Filters {
+			filters[name] = words
+		}
+		for name, words := range add.Filters {
+			filters[name] = append(slices.Clip(filters[name]), lower(words)...)
+		}
+		l.Filters = filters
+		Languages[code] = l
+	}
+	return nil
+}
+
+// lower returns words in lower case, as Close and Filters are matched.
+func lower(words []string) []string {
+	out := make([]string, len(words))
+	for i, w := range words {
+		out[i] = strings.ToLower(w)
+	}
+	return out
+}
+
+// Detect returns the language of the open Yandex Disk page: its lang
+// attribute, or else the first known language whose labels appear on it.
+func Detect(ctx context.Context) (string, error) {
+	markers := make(map[string][]string, len(Languages))
+	for code, l := range Languages {
+		markers[code] = append(append([]string(nil), l.Show...), l.Download...)
+	}
+	var lang string
+	err := browser.Evaluate(ctx, scripts.Call("ui_language", order, markers), &lang)
+	return lang, err
+}
@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrorLogName is the default name of the error log in the log directory.
+const ErrorLogName = "errors.log"
+
+// stdPrefix matches the date and time the standard logger puts before each
+// line with its default flags.
+var stdPrefix = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} `)
+
+// StripPrefix removes the standard logger's date and time from line.
+func StripPrefix(line string) string {
+	return stdPrefix.ReplaceAllString(line, "")
+}
+
+// Severity classifies a log message as "error", "warning" or "" (neither)
+// from the markers and wording the tool's messages use. Debug messages are
+// neither.
+func Severity(msg string) string {
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.HasPrefix(msg, "[debug]"):
+		return ""
+	case strings.Contains(msg, "❌"), strings.HasPrefix(lower, "error"), strings.Contains(lower, " error:"):
+		return "error"
+	case strings.Contains(msg, "⚠"), strings.HasPrefix(lower, "warning"),
+		strings.Contains(lower, " failed"), strings.Contains(lower, "could not"),
+		strings.Contains(lower, "timed out"), strings.Contains(lower, " stuck"):
+		return "warning"
+	default:
+		return ""
+	}
+}
+
+// fatalWriter drops every line but those of errors that stop the run.
+type fatalWriter struct {
+	w io.Writer
+}
+
+// FatalOnly returns a writer for -quiet that passes on nothing but the
+// "Error: ..." lines of errors that stop the run. It accepts lines with the
+// timestamp chosen by SetTimeFormat, so it can sit under Timestamps.
+func FatalOnly(w io.Writer) io.Writer {
+	return fatalWriter{w: w}
+}
+
+func (f fatalWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.SplitAfter(string(p), "\n") {
+		if strings.HasPrefix(stripStamp(line), "Error: ") {
+			if _, err := io.WriteString(f.w, line); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// ErrorLog keeps the warnings and errors of the log in a file of their own,
+// whatever reaches the terminal, so they can be reviewed after the run
+// without its scrollback. Each line gets a full timestamp and the date
+// being processed.
+//
+// All methods are safe to call on a nil *ErrorLog, which writes nothing.
+type ErrorLog struct {
+	mu   sync.Mutex
+	f    *os.File
+	date string // Yandex date being processed ("" between dates)
+}
+
+// OpenErrorLog opens the error log at path for appending and marks the start
+// of a new run in it.
+func OpenErrorLog(path string) (*ErrorLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("could not create error log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open error log: %w", err)
+	}
+	fmt.Fprintf(f, "%s === Run started ===\n", time.Now().Format(time.RFC3339))
+	return &ErrorLog{f: f}, nil
+}
+
+// SetDate sets the date added to the following lines ("" for none).
+func (e *ErrorLog) SetDate(date string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.date = date
+}
+
+// Write implements io.Writer so the ErrorLog can be attached to the standard
+// logger with io.MultiWriter. Lines that are neither warnings nor errors are
+// dropped. Failing to write is not reported, as that would go through the
+// log again.
+func (e *ErrorLog) Write(p []byte) (int, error) {
+	if e == nil {
+		return len(p), nil
+	}
+	now := time.Now().Format(time.RFC3339)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		line = strings.TrimSpace(StripPrefix(line))
+		severity := Severity(line)
+		if severity == "" {
+			continue
+		}
+		if e.date != "" {
+			fmt.Fprintf(e.f, "%s %-7s [%s] %s\n", now, severity, e.date, line)
+		} else {
+			fmt.Fprintf(e.f, "%s %-7s %s\n", now, severity, line)
+		}
+	}
+	return len(p), nil
+}
+
+// Close closes the file.
+func (e *ErrorLog) Close() error {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.f.Close()
+}
@@ -0,0 +1,27 @@
+// Package logging provides debug-level logging on top of the standard logger.
+package logging
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+// debug reports whether debug messages are printed.
+var debug atomic.Bool
+
+// SetDebug enables or disables debug messages.
+func SetDebug(enabled bool) {
+	debug.Store(enabled)
+}
+
+// DebugEnabled reports whether debug messages are printed.
+func DebugEnabled() bool {
+	return debug.Load()
+}
+
+// Debugf logs a message only when debug logging is enabled.
+func Debugf(format string, args ...any) {
+	if debug.Load() {
+		log.Printf("[debug] "+format, args...)
+	}
+}
@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"io"
+	"strings"
+)
+
+// plainMarkers are the ASCII replacements used by -plain for the symbols
+// found in log messages and the final report.
+var plainMarkers = map[rune]string{
+	'✓': "[ok]", '✅': "[ok]", '⚠': "[warn]", '❌': "[error]",
+	'⏳': "[wait]", '⌛': "[dates]", '⏱': "[time]", '⏲': "[timings]", '⏭': "[skip]", '⏩': "[ffwd]", '🔁': "[retry]", '⛔': "[blacklist]", '🧟': "[hung]", '💥': "[crash]", '📝': "[notes]", '📋': "[inventory]",
+	'⏸': "[pause]", '▶': "[resume]", '⏹': "[stop]",
+	'📅': "[date]", '⬇': "[download]", '💾': "[size]", '📦': "[extract]",
+	'♻': "[dup]", '🗑': "[trash]", '🏷': "[album]", '🗂': "[archives]",
+	'🔄': "[reload]", '🐢': "[slow]", '📡': "[network]", '🚧': "[outage]",
+	'🌐': "[web]", '🔬': "[pprof]", '📸': "[snapshot]", '🧾': "[bundle]",
+	'🧹': "[popup]", '🍪': "[cookies]", '🗺': "[geo]", '🖼': "[preview]",
+	'📥': "[missing]", '❓': "[unknown]", '🗓': "[periods]", '🪟': "[window]", '📊': "", '☁': "[storage]",
+	'→': "->", '×': "x", '±': "+/-", '\u00a0': " ",
+}
+
+// Plain rewrites s for terminals and log collectors that cannot render
+// emoji: known symbols become ASCII markers, other pictographs and
+// box-drawing characters become "*", "-" or "|", and ANSI colors are
+// removed. Letters of any script are kept.
+func Plain(s string) string {
+	var b strings.Builder
+	inEscape := false
+	for _, r := range s {
+		if m, ok := plainMarkers[r]; ok && !inEscape {
+			b.WriteString(m)
+			continue
+		}
+		switch {
+		case r == '\033':
+			inEscape = true
+		case inEscape:
+			inEscape = r != 'm'
+		case r == '\ufe0f' || r == '\u200d':
+			// Emoji variation selector and zero-width joiner
+		case r >= 0x2500 && r <= 0x257f:
+			b.WriteString(boxChar(r))
+		case r >= 0x2190 && r <= 0x2bff, r >= 0x1f000 && r <= 0x1faff:
+			b.WriteByte('*')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// boxChar maps a box-drawing character to its closest ASCII equivalent.
+func boxChar(r rune) string {
+	switch r {
+	case '─', '━', '═':
+		return "-"
+	case '│', '┃', '║':
+		return "|"
+	default:
+		return "+"
+	}
+}
+
+// plainWriter applies Plain to everything written through it.
+type plainWriter struct {
+	w io.Writer
+}
+
+// PlainWriter returns a writer that passes each write through Plain before
+// handing it to w. Each write must hold whole lines, as the standard
+// logger's do.
+func PlainWriter(w io.Writer) io.Writer {
+	return plainWriter{w: w}
+}
+
+func (p plainWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(p.w, Plain(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// timeFormat is the -log-time format set by SetTimeFormat.
+var timeFormat = "local"
+
+// start is the reference point of "elapsed" timestamps.
+var start = time.Now()
+
+// SetTimeFormat chooses how log lines are timestamped:
+//   - local: the standard logger's local date and time (default)
+//   - rfc3339: RFC 3339 time with zone offset, to correlate with browser logs
+//   - elapsed: seconds since the program started
+//   - none: no timestamp, for journald and other collectors that add their own
+func SetTimeFormat(format string) error {
+	switch f := strings.ToLower(format); f {
+	case "local":
+		timeFormat = f
+		log.SetFlags(log.LstdFlags)
+	case "rfc3339", "elapsed", "none":
+		timeFormat = f
+		log.SetFlags(0)
+	default:
+		return fmt.Errorf("unknown log time format %q (use local, rfc3339, elapsed or none)", format)
+	}
+	return nil
+}
+
+// stampWriter prefixes each write with a timestamp.
+type stampWriter struct {
+	w io.Writer
+}
+
+// Timestamps returns a writer that adds the timestamp chosen by
+// SetTimeFormat to each line written through it. Formats the standard
+// logger handles itself leave w unchanged. Each write must hold whole
+// lines, as the standard logger's do.
+func Timestamps(w io.Writer) io.Writer {
+	switch timeFormat {
+	case "rfc3339", "elapsed":
+		return &stampWriter{w: w}
+	default:
+		return w
+	}
+}
+
+func (s *stampWriter) Write(b []byte) (int, error) {
+	var stamp string
+	if timeFormat == "elapsed" {
+		stamp = fmt.Sprintf("%9.3fs ", time.Since(start).Seconds())
+	} else {
+		stamp = time.Now().Format(time.RFC3339) + " "
+	}
+	if _, err := s.w.Write(append([]byte(stamp), b...)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// stripStamp removes the timestamp the chosen format puts before a line.
+func stripStamp(line string) string {
+	switch timeFormat {
+	case "rfc3339", "elapsed":
+		_, msg, _ := strings.Cut(strings.TrimLeft(line, " "), " ")
+		return msg
+	default:
+		return StripPrefix(line)
+	}
+}
@@ -0,0 +1,128 @@
+// Package mirror indexes the sync folder of the Yandex.Disk desktop client,
+// so dates whose photos are already on the machine need not be downloaded.
+package mirror
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/catalog"
+)
+
+// Index is the files of a sync folder, by lower-case name.
+type Index struct {
+	Dir   string
+	sizes map[string][]int64 // Sizes of the files with each name
+	files int
+}
+
+// Detect returns the sync folder of the Yandex.Disk desktop client, or ""
+// if none is found: the folder set in the Linux client's config.cfg, or the
+// client's default folder of each OS.
+func Detect() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	var candidates []string
+	switch runtime.GOOS {
+	case "windows":
+		candidates = []string{filepath.Join(home, "YandexDisk"), filepath.Join(home, "Yandex.Disk")}
+	case "darwin":
+		candidates = []string{filepath.Join(home, "Yandex.Disk.localized"), filepath.Join(home, "Yandex.Disk")}
+	default:
+		if dir := configuredDir(filepath.Join(home, ".config", "yandex-disk", "config.cfg")); dir != "" {
+			candidates = append(candidates, dir)
+		}
+		candidates = append(candidates, filepath.Join(home, "Yandex.Disk"))
+	}
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
+// configuredDir reads the dir="..." setting of the Linux client's config.
+func configuredDir(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "dir="); ok {
+			return strings.Trim(v, `"`)
+		}
+	}
+	return ""
+}
+
+// Load indexes the files under dir, skipping the client's hidden folders
+// such as .sync.
+func Load(dir string) (*Index, error) {
+	ix := &Index{Dir: dir, sizes: make(map[string][]int64)}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // Removed while walking
+		}
+		name := strings.ToLower(d.Name())
+		ix.sizes[name] = append(ix.sizes[name], info.Size())
+		ix.files++
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not index %s: %w", dir, err)
+	}
+	return ix, nil
+}
+
+// Files returns the number of files indexed.
+func (ix *Index) Files() int {
+	return ix.files
+}
+
+// Has reports whether a file named like p is in the folder, with the same
+// size when the size of p is known.
+func (ix *Index) Has(p catalog.Photo) bool {
+	sizes := ix.sizes[strings.ToLower(p.Name)]
+	if p.Size == 0 {
+		return len(sizes) > 0
+	}
+	for _, size := range sizes {
+		if size == p.Size {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAll reports whether all photos are in the folder.
+func (ix *Index) HasAll(photos []catalog.Photo) bool {
+	for _, p := range photos {
+		if !ix.Has(p) {
+			return false
+		}
+	}
+	return true
+}
@@ -3,36 +3,131 @@ package navigation
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/locale"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/snapshot"
 	"github.com/chromedp/chromedp"
 )
 
-// FilterByUnlimitedStorage clicks on the filter menu and selects "From unlimited storage"
-// to filter photos that need to be downloaded.
-func FilterByUnlimitedStorage(ctx context.Context) error {
-	log.Println("Applying filter: From unlimited storage...")
+// menuTimeout is how long to wait for the filter menu to open, update or close.
+const menuTimeout = 5 * time.Second
 
-	// Wait for page to be fully loaded
-	time.Sleep(2 * time.Second)
+// menuOpenJS evaluates to true while the filter menu options are shown.
+const menuOpenJS = `!!document.querySelector('.Menu-Item[role="option"]')`
+
+// filterRoles are the roles Yandex has used for the filter button.
+var filterRoles = []string{"button", "listbox", "combobox"}
+
+// isFilterName matches the accessible name of the filter button ("Show: All photos").
+func isFilterName(name string) bool {
+	for _, prefix := range locale.Current().Show {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter is an option of the timeline's "Show:" menu.
+type Filter struct {
+	Name string // As shown in English, for logs
+	key  string // Key of the option's labels in locale.Labels.Filters
+}
+
+var (
+	// UnlimitedStorage shows the photos kept in unlimited storage, the ones
+	// that need exporting before it ends.
+	UnlimitedStorage = Filter{Name: "From unlimited storage", key: "unlimited"}
+	// Services shows the photos saved from other services, such as
+	// Telegram, VK or mail attachments, which the other filters leave out.
+	Services = Filter{Name: "From services", key: "services"}
+	// All shows every photo, whatever its storage, unlimited or regular
+	// Disk space.
+	All = Filter{Name: "All photos", key: "all"}
+)
+
+// words returns the lower-case fragments of the option's name in the
+// interface language.
+func (f Filter) words() []string {
+	return locale.Current().Filters[f.key]
+}
+
+// Filters are the filters selectable with -mode, by name.
+var Filters = map[string]Filter{
+	"unlimited": UnlimitedStorage,
+	"services":  Services,
+	"all":       All,
+}
+
+// matches reports whether an option name belongs to f.
+func (f Filter) matches(name string) bool {
+	lower := strings.ToLower(name)
+	for _, w := range f.words() {
+		if strings.Contains(lower, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// clickFilterButton opens or closes the filter menu through the accessibility
+// tree, so it keeps working when Yandex renames CSS classes.
+func clickFilterButton(ctx context.Context) error {
+	for _, role := range filterRoles {
+		_, err := browser.ClickByRole(ctx, role, isFilterName)
+		if !errors.Is(err, browser.ErrElementNotFound) {
+			return err
+		}
+	}
+	return browser.ErrElementNotFound
+}
+
+// FilterActive reports whether the filter button shows f. known is false
+// when no filter button is found, e.g. while the page loads or after a
+// redesign, so the caller doesn't re-apply the filter on a guess.
+func FilterActive(ctx context.Context, f Filter) (active, known bool, err error) {
+	for _, role := range filterRoles {
+		els, err := browser.FindByRole(ctx, role, isFilterName)
+		if err != nil {
+			return false, false, err
+		}
+		if len(els) > 0 {
+			return f.matches(els[0].Name), true, nil
+		}
+	}
+	return false, false, nil
+}
+
+// ApplyFilter clicks on the filter menu and selects the option of f, e.g.
+// UnlimitedStorage to filter photos that need to be downloaded.
+func ApplyFilter(ctx context.Context, f Filter) error {
+	log.Printf("Applying filter: %s...", f.Name)
 
 	// Step 1: Click the filter menu button
 	// The button has aria-label starting with "Show:" and class "Select2-Button"
 	menuButtonSelector := `button.Select2-Button[aria-label^="Show:"]`
 
-	err := chromedp.Run(ctx,
-		chromedp.WaitVisible(menuButtonSelector, chromedp.ByQuery),
-		chromedp.Click(menuButtonSelector, chromedp.ByQuery),
-	)
+	err := clickFilterButton(ctx)
+	if err != nil && !browser.IsBrowserClosed(err) {
+		err = browser.WaitVisible(ctx, menuButtonSelector, chromedp.ByQuery)
+		if err == nil {
+			err = browser.Click(ctx, menuButtonSelector, chromedp.ByQuery)
+		}
+	}
 	if err != nil {
 		// Try alternative selector
 		altSelector := `button[role="listbox"].Select2-Button`
-		err = chromedp.Run(ctx,
-			chromedp.WaitVisible(altSelector, chromedp.ByQuery),
-			chromedp.Click(altSelector, chromedp.ByQuery),
-		)
+		err = browser.WaitVisible(ctx, altSelector, chromedp.ByQuery)
+		if err == nil {
+			err = browser.Click(ctx, altSelector, chromedp.ByQuery)
+		}
 		if err != nil {
 			return fmt.Errorf("could not click filter menu button: %w", err)
 		}
@@ -40,62 +135,53 @@ func FilterByUnlimitedStorage(ctx context.Context) error {
 	log.Println("✓ Filter menu opened")
 
 	// Wait for menu to appear
-	time.Sleep(500 * time.Millisecond)
+	if err := browser.WaitReact(ctx, browser.ReactMenu, menuOpenJS, menuTimeout); err != nil {
+		return fmt.Errorf("filter menu did not open: %w", err)
+	}
+	snapshot.Capture(ctx, snapshot.StateFilterMenuOpen)
 
-	// Step 2: Click "From unlimited storage" option
-	// Use JavaScript to find and click the menu item by text content
+	// Step 2: Click the filter's option
+	// Prefer the accessibility tree, then fall back to the menu item's text content
 	var clicked bool
-	err = chromedp.Run(ctx,
-		chromedp.Evaluate(`
-			(function() {
-				// Find all menu items
-				const menuItems = document.querySelectorAll('.Menu-Item[role="option"]');
-				for (const item of menuItems) {
-					if (item.textContent.includes('unlimited storage') || 
-					    item.textContent.includes('Unlimited storage')) {
-						item.click();
-						return true;
-					}
-				}
-				return false;
-			})()
-		`, &clicked),
-	)
+	if _, err := browser.ClickByRole(ctx, "option", f.matches); err == nil {
+		clicked = true
+	} else if browser.IsBrowserClosed(err) {
+		return err
+	} else {
+		err = browser.Evaluate(ctx, scripts.Call("click_filter_option", f.words()), &clicked)
 
-	if err != nil {
-		return fmt.Errorf("error executing click on menu item: %w", err)
+		if err != nil {
+			return fmt.Errorf("error executing click on menu item: %w", err)
+		}
 	}
 
 	if !clicked {
 		// Try XPath as fallback
-		xpathSelector := `//div[@role="option"][contains(., "unlimited storage")]`
-		err = chromedp.Run(ctx,
-			chromedp.Click(xpathSelector, chromedp.BySearch),
-		)
+		xpathSelector := fmt.Sprintf(`//div[@role="option"][contains(., %q)]`, f.words()[0])
+		err = browser.Click(ctx, xpathSelector, chromedp.BySearch)
 		if err != nil {
-			return fmt.Errorf("could not find 'From unlimited storage' option: %w", err)
+			return fmt.Errorf("could not find '%s' option: %w", f.Name, err)
 		}
 	}
 
-	log.Println("✓ 'From unlimited storage' filter selected")
+	log.Printf("✓ '%s' filter selected", f.Name)
 
-	// Wait a moment for selection to register
-	time.Sleep(300 * time.Millisecond)
+	// Wait for selection to register in the button label
+	browser.WaitReact(ctx, browser.ReactMenu, scripts.Call("filter_applied", f.words()), menuTimeout)
 
 	// Step 3: Close the menu by clicking the button again or clicking elsewhere
-	err = chromedp.Run(ctx,
-		chromedp.Click(menuButtonSelector, chromedp.ByQuery),
-	)
+	err = clickFilterButton(ctx)
+	if err != nil && !browser.IsBrowserClosed(err) {
+		err = browser.Click(ctx, menuButtonSelector, chromedp.ByQuery)
+	}
 	if err != nil {
 		// If clicking button fails, try clicking elsewhere on the page to close menu
-		chromedp.Run(ctx,
-			chromedp.Evaluate(`document.body.click()`, nil),
-		)
+		browser.Evaluate(ctx, `document.body.click()`, nil)
 	}
 	log.Println("✓ Filter menu closed")
 
-	// Wait for filter to be applied and page to update
-	time.Sleep(2 * time.Second)
+	// Wait for the menu to close
+	browser.WaitReact(ctx, browser.ReactMenu, "!"+menuOpenJS, menuTimeout)
 
 	log.Println("✓ Filter applied successfully")
 	return nil
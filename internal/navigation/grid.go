@@ -0,0 +1,60 @@
+package navigation
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/locale"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
+)
+
+// Grid summarizes the part of the timeline on screen.
+type Grid struct {
+	FirstDate string `json:"first"`  // Topmost date label ("" if none)
+	Dates     int    `json:"dates"`  // Date labels on screen
+	Photos    int    `json:"photos"` // Photo previews on screen
+}
+
+func (g Grid) String() string {
+	first := g.FirstDate
+	if first == "" {
+		first = "no date"
+	}
+	return fmt.Sprintf("%s first, %d dates, %d photos on screen", first, g.Dates, g.Photos)
+}
+
+// ReadGrid summarizes the timeline on screen.
+func ReadGrid(ctx context.Context) (Grid, error) {
+	var g Grid
+	if err := browser.Evaluate(ctx, scripts.Call("grid_summary", locale.Current().Months), &g); err != nil {
+		return Grid{}, fmt.Errorf("could not read the timeline: %w", err)
+	}
+	return g, nil
+}
+
+// VerifyFilter reports whether applying f changed the timeline from
+// before, which was read before the filter was applied, and logs the
+// difference. A filter that was already selected (wasActive) is expected
+// to leave the timeline as it was. It returns false when the timeline
+// looks the same although the filter wasn't selected before, or can't be
+// read, so the filter may not have taken effect.
+func VerifyFilter(ctx context.Context, f Filter, before Grid, wasActive bool) bool {
+	after, err := ReadGrid(ctx)
+	if err != nil {
+		log.Printf("⚠️ Could not check the '%s' filter: %v", f.Name, err)
+		return false
+	}
+	switch {
+	case after != before:
+		log.Printf("✓ The '%s' filter changed the timeline: %s → %s", f.Name, before, after)
+		return true
+	case wasActive:
+		log.Printf("✓ The '%s' filter was already selected (%s)", f.Name, after)
+		return true
+	default:
+		log.Printf("⚠️ The timeline looks the same after selecting the '%s' filter (%s); it may not have taken effect", f.Name, after)
+		return false
+	}
+}
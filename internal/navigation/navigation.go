@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/chromedp/chromedp"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
 )
 
 const (
@@ -16,20 +16,40 @@ const (
 
 // ScrollDown scrolls the page down by the default amount.
 func ScrollDown(ctx context.Context) error {
-	if err := chromedp.Run(ctx,
-		chromedp.Evaluate(fmt.Sprintf(`window.scrollBy(0, %d)`, DefaultScrollAmount), nil),
-	); err != nil {
+	if _, err := browser.ScrollBy(ctx, DefaultScrollAmount); err != nil {
 		return fmt.Errorf("scroll down failed: %w", err)
 	}
 	return nil
 }
 
+// ScrollDownBy scrolls down by amount pixels (up when negative) and returns
+// the new scroll position.
+func ScrollDownBy(ctx context.Context, amount float64) (float64, error) {
+	y, err := browser.ScrollBy(ctx, amount)
+	if err != nil {
+		return 0, fmt.Errorf("scroll by %.0fpx failed: %w", amount, err)
+	}
+	return y, nil
+}
+
+// ScrollViewports scrolls by n viewport heights (up when n is negative) and
+// returns the new scroll position.
+func ScrollViewports(ctx context.Context, n float64) (float64, error) {
+	var height float64
+	err := browser.Evaluate(ctx, `window.innerHeight`, &height)
+	if err == nil {
+		var y float64
+		if y, err = browser.ScrollBy(ctx, height*n); err == nil {
+			return y, nil
+		}
+	}
+	return 0, fmt.Errorf("scroll by %.0f viewports failed: %w", n, err)
+}
+
 // ScrollToPosition scrolls to move the processed date off screen.
 func ScrollToPosition(ctx context.Context, yPosition float64) error {
 	// Scroll so the date is above the top of the screen (±300px)
-	if err := chromedp.Run(ctx,
-		chromedp.Evaluate(fmt.Sprintf(`window.scrollBy(0, %f - 50)`, yPosition), nil),
-	); err != nil {
+	if _, err := browser.ScrollBy(ctx, yPosition-50); err != nil {
 		return fmt.Errorf("scroll failed: %w", err)
 	}
 	log.Printf("Scroll executed to move date (y=%.0f) off screen", yPosition)
@@ -0,0 +1,63 @@
+package navigation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser/browsertest"
+)
+
+func TestScrollViewports(t *testing.T) {
+	d := browsertest.New().
+		On("window.innerHeight", 800).
+		On("(window.scrollBy(0, ", 1600)
+	y, err := ScrollViewports(d.Context(context.Background()), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if y != 1600 {
+		t.Errorf("got y=%v, want 1600", y)
+	}
+	if d.Count("Evaluate (window.scrollBy(0, ") != 1 {
+		t.Errorf("not scrolled once: %v", d.Actions())
+	}
+}
+
+func TestScrollViewportsFailure(t *testing.T) {
+	d := browsertest.New().On("window.innerHeight", 800)
+	if _, err := ScrollViewports(d.Context(context.Background()), 1); err == nil || !strings.Contains(err.Error(), "scroll by 1 viewports failed") {
+		t.Errorf("got %v, want a scroll error", err)
+	}
+}
+
+func TestCurrentScrollY(t *testing.T) {
+	d := browsertest.New().On("window.scrollY", 1234.5)
+	y, err := CurrentScrollY(d.Context(context.Background()))
+	if err != nil || y != 1234.5 {
+		t.Errorf("got %v, %v; want 1234.5", y, err)
+	}
+}
+
+func TestRestoreScrollPosition(t *testing.T) {
+	// Each step scrolls at most RestoreStepAmount, and the page follows
+	d := browsertest.New().
+		On("window.scrollY", 0, RestoreStepAmount, RestoreStepAmount, 3000).
+		On("window.scrollBy(", nil)
+	if err := RestoreScrollPosition(d.Context(context.Background()), 3000); err != nil {
+		t.Fatal(err)
+	}
+	if n := d.Count("Evaluate window.scrollBy("); n != 2 {
+		t.Errorf("scrolled %d times, want 2: %v", n, d.Actions())
+	}
+}
+
+func TestRestoreScrollPositionReached(t *testing.T) {
+	d := browsertest.New().On("window.scrollY", 5000)
+	if err := RestoreScrollPosition(d.Context(context.Background()), 3000); err != nil {
+		t.Fatal(err)
+	}
+	if n := d.Count("Evaluate window.scrollBy("); n != 0 {
+		t.Errorf("scrolled %d times past the target", n)
+	}
+}
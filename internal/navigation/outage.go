@@ -0,0 +1,52 @@
+package navigation
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/retry"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
+)
+
+// outagePolicy controls how long to pause between checks while Yandex is down.
+var outagePolicy = retry.Policy{
+	InitialDelay: 30 * time.Second,
+	MaxDelay:     10 * time.Minute,
+	Multiplier:   2,
+	Jitter:       0.2,
+}
+
+// ServiceStatus reports why Yandex Disk is unavailable (maintenance or a 5xx
+// error page), or returns an empty string if the current page looks normal.
+func ServiceStatus(ctx context.Context) string {
+	var reason string
+	if err := browser.Evaluate(ctx, scripts.Call("service_status"), &reason); err != nil {
+		return ""
+	}
+	return reason
+}
+
+// WaitForService pauses with exponential backoff while the current page is a
+// maintenance or server error page, reloading url after each pause until the
+// service is back. It returns immediately if the page looks normal.
+func WaitForService(ctx context.Context, url string) error {
+	reason := ServiceStatus(ctx)
+	for attempt := 1; reason != ""; attempt++ {
+		delay := outagePolicy.Delay(attempt)
+		log.Printf("🚧 Yandex Disk is unavailable (%s). Retrying in %v...", reason, delay.Round(time.Second))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		if err := browser.Navigate(ctx, url); err != nil && browser.IsBrowserClosed(err) {
+			return err
+		}
+		reason = ServiceStatus(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Package navigation handles page scrolling and navigation on Yandex Disk.
+package navigation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/overlay"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/retry"
+)
+
+const (
+	// RestoreStepAmount is the number of pixels scrolled per step when restoring a position.
+	RestoreStepAmount = 2000
+	// maxRestoreStalls is how many steps without progress are tolerated while restoring.
+	maxRestoreStalls = 5
+)
+
+// CurrentScrollY returns the current vertical scroll offset of the page.
+func CurrentScrollY(ctx context.Context) (float64, error) {
+	var y float64
+	if err := browser.Evaluate(ctx, `window.scrollY`, &y); err != nil {
+		return 0, fmt.Errorf("could not read scroll position: %w", err)
+	}
+	return y, nil
+}
+
+// RestoreScrollPosition scrolls down step by step until the page reaches targetY.
+// The timeline is loaded lazily, so each step waits for new content to appear.
+func RestoreScrollPosition(ctx context.Context, targetY float64) error {
+	stalls := 0
+	for {
+		y, err := CurrentScrollY(ctx)
+		if err != nil {
+			return err
+		}
+		if y >= targetY-1 {
+			log.Printf("✓ Scroll position restored (y=%.0f)", y)
+			return nil
+		}
+
+		step := targetY - y
+		if step > RestoreStepAmount {
+			step = RestoreStepAmount
+		}
+		if err := browser.Evaluate(ctx, fmt.Sprintf(`window.scrollBy(0, %f)`, step), nil); err != nil {
+			return fmt.Errorf("scroll failed: %w", err)
+		}
+		browser.Pause(ctx, 500*time.Millisecond)
+
+		newY, err := CurrentScrollY(ctx)
+		if err != nil {
+			return err
+		}
+		if newY <= y {
+			stalls++
+			if stalls >= maxRestoreStalls {
+				return fmt.Errorf("could not reach position y=%.0f (stuck at y=%.0f)", targetY, newY)
+			}
+			// Give the timeline more time to load the next chunk
+			browser.Pause(ctx, time.Second)
+			continue
+		}
+		stalls = 0
+	}
+}
+
+// RefreshPage reloads the photos page, re-applies filter and scrolls back to
+// scrollY, recovering from a wedged UI.
+func RefreshPage(ctx context.Context, url string, filter Filter, scrollY float64) error {
+	log.Println("🔄 Reloading photos page...")
+	if err := browser.Navigate(ctx, url); err != nil {
+		return fmt.Errorf("could not reload page: %w", err)
+	}
+	if err := WaitForService(ctx, url); err != nil {
+		return err
+	}
+
+	if err := applyWithRetry(ctx, filter); err != nil {
+		log.Printf("⚠️ Warning: could not re-apply filter: %v", err)
+	}
+
+	// Wait for page to update after filter
+	browser.Pause(ctx, 2*time.Second)
+
+	if scrollY > 0 {
+		log.Printf("Restoring scroll position (y=%.0f)...", scrollY)
+		if err := RestoreScrollPosition(ctx, scrollY); err != nil {
+			return err
+		}
+	}
+
+	log.Println("✓ Page refreshed")
+	return nil
+}
+
+// ReapplyFilter selects filter again after the page lost it without a
+// reload, and scrolls back to scrollY, where the timeline was with it.
+func ReapplyFilter(ctx context.Context, filter Filter, scrollY float64) error {
+	if err := applyWithRetry(ctx, filter); err != nil {
+		return fmt.Errorf("could not re-apply filter: %w", err)
+	}
+	browser.Pause(ctx, 2*time.Second)
+	if scrollY > 0 {
+		log.Printf("Restoring scroll position (y=%.0f)...", scrollY)
+		return RestoreScrollPosition(ctx, scrollY)
+	}
+	return nil
+}
+
+// applyWithRetry applies filter, retrying while the page settles.
+func applyWithRetry(ctx context.Context, filter Filter) error {
+	return retry.Do(ctx, retry.DefaultPolicy(), "Filter", func(int) error {
+		overlay.AcceptCookies(ctx) // The consent banner covers the filter button
+		return ApplyFilter(ctx, filter)
+	})
+}
@@ -0,0 +1,118 @@
+// Package netcheck detects loss of network connectivity and waits for it to return.
+package netcheck
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+)
+
+var (
+	// Host is the server probed to decide whether the network is up.
+	Host = "disk.yandex.com"
+	// Port is the port of Host probed.
+	Port = "443"
+)
+
+const (
+	// PollInterval is how often connectivity is re-checked during an outage.
+	PollInterval = 15 * time.Second
+	// probeTimeout bounds a single DNS lookup or TCP connection attempt.
+	probeTimeout = 5 * time.Second
+)
+
+// Probe resolves Host and opens a TCP connection to Port.
+func Probe(ctx context.Context) error {
+	if err := Resolve(ctx); err != nil {
+		return err
+	}
+	return Dial(ctx)
+}
+
+// Resolve looks up the addresses of Host.
+func Resolve(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, Host)
+	if err != nil {
+		return fmt.Errorf("DNS lookup for %s failed: %w", Host, err)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("DNS lookup for %s returned no addresses", Host)
+	}
+	return nil
+}
+
+// Dial opens and closes a TCP connection to Port of Host.
+func Dial(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(Host, Port))
+	if err != nil {
+		return fmt.Errorf("could not connect to %s: %w", Host, err)
+	}
+	conn.Close()
+	return nil
+}
+
+// SetTarget makes the server of rawURL the one probed instead of Yandex
+// Disk, for -target-url. Call it before probing.
+func SetTarget(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("invalid URL %q", rawURL)
+	}
+	Host, Port = u.Hostname(), u.Port()
+	if Port == "" {
+		Port = "443"
+		if u.Scheme == "http" {
+			Port = "80"
+		}
+	}
+	return nil
+}
+
+// Offline reports whether the page or the machine has lost connectivity:
+// the browser reports itself offline, shows its network error page, or Host
+// cannot be reached.
+func Offline(ctx context.Context) bool {
+	var pageOffline bool
+	if err := browser.Evaluate(ctx, `!navigator.onLine || location.protocol === 'chrome-error:'`, &pageOffline); err == nil && pageOffline {
+		return true
+	}
+	return Probe(ctx) != nil
+}
+
+// IsNetworkError reports whether err is a Chrome network error such as
+// net::ERR_INTERNET_DISCONNECTED or net::ERR_NAME_NOT_RESOLVED.
+func IsNetworkError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "net::ERR_")
+}
+
+// WaitOnline blocks until Host is reachable again or ctx is done.
+func WaitOnline(ctx context.Context) error {
+	start := time.Now()
+	for {
+		err := Probe(ctx)
+		if err == nil {
+			log.Printf("✓ Network is back after %v", time.Since(start).Round(time.Second))
+			return nil
+		}
+		log.Printf("📡 Network unavailable (%v). Checking again in %v...", err, PollInterval)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(PollInterval):
+		}
+	}
+}
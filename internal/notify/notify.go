@@ -0,0 +1,167 @@
+// Package notify POSTs the progress of the export to a webhook as JSON:
+// when the run ends and, optionally, every few dates or minutes while it
+// runs, so remote monitoring notices a stalled run within minutes.
+//
+// All methods are safe to call on a nil *Notifier, which sends nothing.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/events"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/progress"
+)
+
+// postTimeout bounds a single webhook request.
+const postTimeout = 10 * time.Second
+
+// Status values of a Message.
+const (
+	StatusRunning  = "running"
+	StatusFinished = "finished"
+	StatusFailed   = "failed"
+)
+
+// Message is the JSON body of a webhook request.
+type Message struct {
+	Status   string            `json:"status"`
+	Message  string            `json:"message,omitempty"` // Summary or error of a finished run
+	Progress progress.Progress `json:"progress"`
+}
+
+// Notifier sends Messages to a webhook.
+type Notifier struct {
+	url        string
+	everyDates int           // Send after this many finished dates (0 disables)
+	every      time.Duration // Send at this interval (0 disables)
+	client     *http.Client
+	tracker    *progress.Tracker
+
+	mu        sync.Mutex
+	sinceLast int  // Dates finished since the last message
+	failing   bool // The last request failed; logged once per streak
+	finished  bool
+
+	send chan struct{} // Requests an interim message
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New creates a Notifier posting to url, fed by the emitter's events.
+// Interim messages are sent every everyDates finished dates and every
+// interval; with both 0, only the final message is sent. expected is as
+// for progress.NewTracker.
+func New(url string, emitter *events.Emitter, expected, everyDates int, every time.Duration) *Notifier {
+	n := &Notifier{
+		url:        url,
+		everyDates: everyDates,
+		every:      every,
+		client:     &http.Client{Timeout: postTimeout},
+		tracker:    progress.NewTracker(emitter, expected),
+		send:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	emitter.Subscribe(n.record)
+	go n.loop()
+	return n
+}
+
+// record counts finished dates and requests an interim message every
+// everyDates of them. The request itself is sent from loop, so a slow
+// webhook never holds up the emitter.
+func (n *Notifier) record(ev events.Event) {
+	if ev.Type != events.DownloadCompleted && ev.Type != events.DownloadFailed {
+		return
+	}
+	n.mu.Lock()
+	n.sinceLast++
+	due := n.everyDates > 0 && n.sinceLast >= n.everyDates
+	n.mu.Unlock()
+	if due {
+		select {
+		case n.send <- struct{}{}:
+		default: // A message is already pending
+		}
+	}
+}
+
+// loop sends the interim messages until Finish.
+func (n *Notifier) loop() {
+	defer close(n.done)
+	var tick <-chan time.Time
+	if n.every > 0 {
+		ticker := time.NewTicker(n.every)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-tick:
+		case <-n.send:
+		case <-n.stop:
+			return
+		}
+		n.post(Message{Status: StatusRunning})
+	}
+}
+
+// Finish stops the interim messages and sends the final one: finished
+// with summary, or failed with err.
+func (n *Notifier) Finish(summary string, err error) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	if n.finished {
+		n.mu.Unlock()
+		return
+	}
+	n.finished = true
+	n.mu.Unlock()
+
+	close(n.stop)
+	<-n.done
+	msg := Message{Status: StatusFinished, Message: summary}
+	if err != nil {
+		msg = Message{Status: StatusFailed, Message: err.Error()}
+	}
+	n.post(msg)
+}
+
+// post sends msg with the current progress.
+func (n *Notifier) post(msg Message) {
+	n.mu.Lock()
+	n.sinceLast = 0
+	n.mu.Unlock()
+
+	msg.Progress = n.tracker.Snapshot()
+	body, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Warning: could not encode webhook message: %v", err)
+		return
+	}
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			err = fmt.Errorf("webhook responded %s", resp.Status)
+		}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err != nil && !n.failing {
+		// Monitoring must not stop the export
+		log.Printf("Warning: could not send webhook: %v", err)
+	} else if err == nil && n.failing {
+		log.Println("✓ Webhook reachable again")
+	}
+	n.failing = err != nil
+}
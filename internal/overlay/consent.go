@@ -0,0 +1,55 @@
+package overlay
+
+import (
+	"context"
+	"log"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+)
+
+// acceptConsentJS clicks the accept (or, failing that, the dismiss) button of
+// a visible cookie-consent overlay and returns the button's label, or an empty
+// string if no consent overlay is shown.
+const acceptConsentJS = `
+	(function() {
+		const consent = /cookie|cookies|файл(ы|ов)? cookie|куки|gdpr|персональн|personal data|privacy/i;
+		const accept = /^(accept|accept all|allow all|agree|i agree|ok|got it|принять|принять все|разрешить все|согласен|хорошо|понятно)$/i;
+		const dismiss = /close|dismiss|reject|decline|only necessary|закрыть|отклонить|только необходимые/i;
+		const overlays = document.querySelectorAll('[role="dialog"], [aria-modal="true"], [class*="cookie"], [class*="Cookie"], [class*="consent"], [class*="Consent"], [class*="gdpr"], [class*="Gdpr"], [id*="cookie"], [id*="consent"], [id*="gdpr"]');
+
+		for (const el of overlays) {
+			const style = getComputedStyle(el);
+			if (style.display === 'none' || style.visibility === 'hidden') continue;
+			const text = (el.textContent || '').trim();
+			if (text.length > 3000 || !consent.test(text)) continue;
+
+			const buttons = Array.from(el.querySelectorAll('button, [role="button"], a'));
+			const label = btn => ((btn.textContent || '').trim() || btn.getAttribute('aria-label') || '').trim();
+			const target = buttons.find(btn => accept.test(label(btn))) ||
+				buttons.find(btn => dismiss.test(label(btn) + ' ' + (btn.getAttribute('aria-label') || '')));
+			if (target) {
+				target.click();
+				return label(target) || 'close';
+			}
+		}
+		return '';
+	})()
+`
+
+// AcceptCookies accepts or dismisses the regional cookie-consent overlay shown
+// on the first visit with a fresh profile, which otherwise blocks the filter
+// button. It reports whether an overlay was found.
+func AcceptCookies(ctx context.Context) bool {
+	var clicked string
+	if err := browser.Evaluate(ctx, acceptConsentJS, &clicked); err != nil {
+		if !browser.IsBrowserClosed(err) {
+			log.Printf("Warning: could not check for cookie banner: %v", err)
+		}
+		return false
+	}
+	if clicked == "" {
+		return false
+	}
+	log.Printf("🍪 Cookie banner dismissed (%q)", clicked)
+	return true
+}
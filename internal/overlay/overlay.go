@@ -0,0 +1,78 @@
+// Package overlay detects and closes dialogs and banners that Yandex shows over
+// the photo grid (subscription upsells, app banners, surveys), since they steal
+// clicks and silently break selections.
+package overlay
+
+import (
+	"context"
+	"log"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+)
+
+// dismissPromosJS closes every visible promo overlay it recognizes and returns
+// a short description of each one. Overlays without a close button are
+// reported with escape=true so the caller can fall back to the Escape key.
+const dismissPromosJS = `
+	(function() {
+		const promo = /yandex 360|яндекс 360|subscription|подписк|upgrade|premium|get more space|больше места|install the app|download the app|установите приложение|скачайте приложение|mobile app|мобильное приложение|survey|опрос|rate us|оцените|what do you think|как вам/i;
+		const closeLabel = /close|dismiss|not now|no thanks|later|закрыть|не сейчас|нет, спасибо|позже/i;
+		const overlays = document.querySelectorAll('[role="dialog"], [aria-modal="true"], [class*="Modal"], [class*="modal"], [class*="Popup"], [class*="popup"], [class*="Banner"], [class*="banner"], [class*="Promo"], [class*="promo"], [class*="Survey"]');
+
+		const closed = [];
+		let escape = false;
+		for (const el of overlays) {
+			const style = getComputedStyle(el);
+			if (style.display === 'none' || style.visibility === 'hidden') continue;
+			// Only floating elements: never touch the page layout or the grid itself
+			const floating = el.getAttribute('role') === 'dialog' || el.getAttribute('aria-modal') === 'true' ||
+				style.position === 'fixed' || style.position === 'sticky';
+			if (!floating) continue;
+			const text = (el.textContent || '').trim();
+			if (text.length > 1000 || !promo.test(text)) continue;
+
+			let closer = null;
+			for (const btn of el.querySelectorAll('button, [role="button"], a')) {
+				const label = (btn.getAttribute('aria-label') || '') + ' ' + (btn.getAttribute('title') || '') + ' ' + (btn.textContent || '');
+				const cls = String(btn.className || '');
+				if (closeLabel.test(label) || /close|Close|cross|Cross/.test(cls)) {
+					closer = btn;
+					break;
+				}
+			}
+			if (closer) {
+				closer.click();
+			} else {
+				escape = true;
+			}
+			closed.push(text.substring(0, 60));
+		}
+		return {closed: closed, escape: escape};
+	})()
+`
+
+// DismissPromos closes promo dialogs, app banners and survey popups covering
+// the page and returns how many were found. Errors are logged, not returned,
+// since a failed dismissal should not stop the run.
+func DismissPromos(ctx context.Context) int {
+	var res struct {
+		Closed []string `json:"closed"`
+		Escape bool     `json:"escape"`
+	}
+	if err := browser.Evaluate(ctx, dismissPromosJS, &res); err != nil {
+		if !browser.IsBrowserClosed(err) {
+			log.Printf("Warning: could not check for popups: %v", err)
+		}
+		return 0
+	}
+
+	if res.Escape {
+		if err := browser.KeyEvent(ctx, "\x1b"); err != nil {
+			log.Printf("Warning: could not close popup with Escape: %v", err)
+		}
+	}
+	for _, text := range res.Closed {
+		log.Printf("🧹 Closed popup: %q", text)
+	}
+	return len(res.Closed)
+}
@@ -0,0 +1,193 @@
+// Package pipeline runs an ordered list of post-processing steps, read from
+// a JSON file, on each finished download: extract, convert, rename,
+// set-mtime, hook and sink, in any order and as often as needed.
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/crash"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/download"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/extract"
+)
+
+// StepConfig is one step of the pipeline file. Step names the kind of
+// step; the other fields are the settings of that kind.
+type StepConfig struct {
+	Step string `json:"step"` // extract, convert, rename, set-mtime, hook or sink
+
+	// extract: the filters and layout of -extract
+	Types     string `json:"types,omitempty"`     // e.g. "jpg,heic,mp4"
+	MinSize   string `json:"minSize,omitempty"`   // e.g. "100KB"
+	MaxSize   string `json:"maxSize,omitempty"`   // e.g. "1GB"
+	Structure string `json:"structure,omitempty"` // preserve (default) or flat
+	Normalize string `json:"normalize,omitempty"` // nfc or nfd
+	Dedup     string `json:"dedup,omitempty"`     // link or skip
+
+	// convert: run Command on the files with an extension of From, which
+	// writes a file with the extension To next to each
+	From string `json:"from,omitempty"` // e.g. "heic,heif"
+	To   string `json:"to,omitempty"`   // e.g. "jpg"
+	Keep bool   `json:"keep,omitempty"` // Keep the original files
+
+	// convert and hook: the program and its arguments (see expand)
+	Command []string `json:"command,omitempty"`
+
+	// rename: a -name-template, e.g. "{date}_{index}_{orig}"
+	Template string `json:"template,omitempty"`
+
+	// set-mtime: exif (capture time, or the date when unknown; default)
+	// or date (the Yandex date)
+	Source string `json:"source,omitempty"`
+
+	// sink: copy the files into Dir (relative to the download directory
+	// unless absolute), keeping their folders, or move them with Move
+	Dir  string `json:"dir,omitempty"`
+	Move bool   `json:"move,omitempty"`
+}
+
+// Config is a pipeline file.
+type Config struct {
+	Steps []StepConfig `json:"steps"`
+
+	steps []step
+}
+
+// step is a compiled StepConfig.
+type step struct {
+	name string
+	run  func(p *Pipeline, b *batch) error
+}
+
+// batch is the download a pipeline is working on and the files it has
+// become so far: the download itself, until an extract step replaces it
+// with the extracted files.
+type batch struct {
+	download.File
+	files []string
+}
+
+// Load reads and validates the pipeline file at path.
+func Load(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read pipeline: %w", err)
+	}
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields() // A misspelled setting would silently do nothing
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("could not parse pipeline %s: %w", path, err)
+	}
+	if len(cfg.Steps) == 0 {
+		return nil, fmt.Errorf("pipeline %s has no steps", path)
+	}
+	for i, sc := range cfg.Steps {
+		s, err := compile(sc)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline step %d (%s): %w", i+1, sc.Step, err)
+		}
+		cfg.steps = append(cfg.steps, s)
+	}
+	return &cfg, nil
+}
+
+// compile checks the settings of a step and returns it.
+func compile(sc StepConfig) (step, error) {
+	var run func(*Pipeline, *batch) error
+	var err error
+	switch sc.Step {
+	case "extract":
+		run, err = extractStep(sc)
+	case "convert":
+		run, err = convertStep(sc)
+	case "rename":
+		run, err = renameStep(sc)
+	case "set-mtime":
+		run, err = mtimeStep(sc)
+	case "hook":
+		run, err = hookStep(sc)
+	case "sink":
+		run, err = sinkStep(sc)
+	default:
+		err = fmt.Errorf("unknown step (use extract, convert, rename, set-mtime, hook or sink)")
+	}
+	return step{name: sc.Step, run: run}, err
+}
+
+// Pipeline runs the steps of a Config on finished downloads in the
+// background, one download at a time, so the export moves on meanwhile.
+type Pipeline struct {
+	steps     []step
+	root      string                              // Download directory
+	hashes    extract.Hashes                      // For the dedup setting of extract steps
+	extracted func(download.File, extract.Result) // Called after each extract step; may be nil
+
+	mu sync.Mutex // Serializes downloads
+	wg sync.WaitGroup
+
+	// Totals of the extract steps
+	files          int
+	skipped        int
+	duplicates     int
+	duplicateBytes int64
+}
+
+// New creates a Pipeline for the downloads of root. extracted may be nil.
+func New(cfg *Config, root string, hashes extract.Hashes, extracted func(download.File, extract.Result)) *Pipeline {
+	return &Pipeline{steps: cfg.steps, root: root, hashes: hashes, extracted: extracted}
+}
+
+// Add queues a finished download. It does not block. A failing step is
+// logged and skips the remaining steps for that download.
+func (p *Pipeline) Add(f download.File) {
+	p.wg.Add(1)
+	go func() {
+		defer crash.Recover()
+		defer p.wg.Done()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		b := &batch{File: f, files: []string{f.Path}}
+		for i, s := range p.steps {
+			if err := s.run(p, b); err != nil {
+				log.Printf("⚠️ Post-processing of %s stopped at step %d (%s): %v", f.Name, i+1, s.name, err)
+				return
+			}
+		}
+	}()
+}
+
+// Close waits for queued downloads and returns the totals of the extract
+// steps.
+func (p *Pipeline) Close() (files, skipped int) {
+	p.wg.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.files, p.skipped
+}
+
+// Duplicates returns how many duplicate files the extract steps
+// deduplicated and the disk space this saved. Call it after Close.
+func (p *Pipeline) Duplicates() (files int, bytes int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.duplicates, p.duplicateBytes
+}
+
+// replaceExt returns path with its extension replaced by ext (without dot).
+func replaceExt(path, ext string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "." + ext
+}
+
+// hasExt reports whether path has one of exts, lowercase without dot.
+func hasExt(path string, exts map[string]bool) bool {
+	return exts[strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))]
+}
@@ -0,0 +1,305 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/datefilter"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/exif"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/extract"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/fsutil"
+)
+
+// commandTimeout bounds one run of a convert or hook command.
+const commandTimeout = 10 * time.Minute
+
+// extractStep unpacks the zip archives among the files, replacing them
+// with the extracted files. Other files pass through the filters.
+func extractStep(sc StepConfig) (func(*Pipeline, *batch) error, error) {
+	var opts extract.Options
+	var err error
+	if sc.Types != "" {
+		if opts.Types, err = extract.ParseTypes(sc.Types); err != nil {
+			return nil, fmt.Errorf("types: %w", err)
+		}
+	}
+	if sc.MinSize != "" {
+		if opts.MinSize, err = extract.ParseSize(sc.MinSize); err != nil {
+			return nil, fmt.Errorf("minSize: %w", err)
+		}
+	}
+	if sc.MaxSize != "" {
+		if opts.MaxSize, err = extract.ParseSize(sc.MaxSize); err != nil {
+			return nil, fmt.Errorf("maxSize: %w", err)
+		}
+	}
+	switch strings.ToLower(sc.Structure) {
+	case "", "preserve":
+	case "flat":
+		opts.Flatten = true
+	default:
+		return nil, fmt.Errorf("unknown structure %q (use flat or preserve)", sc.Structure)
+	}
+	if sc.Normalize != "" {
+		if opts.Normalize, err = extract.ParseNormalize(sc.Normalize); err != nil {
+			return nil, fmt.Errorf("normalize: %w", err)
+		}
+	}
+	if sc.Dedup != "" {
+		if opts.Dedup, err = extract.ParseDedup(sc.Dedup); err != nil {
+			return nil, fmt.Errorf("dedup: %w", err)
+		}
+	}
+	return func(p *Pipeline, b *batch) error {
+		opts := opts
+		opts.Hashes = p.hashes
+		var files []string
+		for _, path := range b.files {
+			// Each archive gets its own folder: Yandex reuses archive-internal names
+			dest := strings.TrimSuffix(path, filepath.Ext(path))
+			res, err := extract.Archive(path, dest, b.Date, opts)
+			if err != nil {
+				return err
+			}
+			p.files += len(res.Files)
+			p.skipped += res.Skipped
+			p.duplicates += res.Duplicates
+			p.duplicateBytes += res.DuplicateBytes
+			extract.LogResult(filepath.Base(path), res)
+			if p.extracted != nil {
+				p.extracted(b.File, res)
+			}
+			files = append(files, res.Files...)
+		}
+		b.files = files
+		return nil
+	}, nil
+}
+
+// convertStep runs a command on the files with one of the From extensions,
+// e.g. to turn HEIC photos into JPEG, and continues with the converted
+// files.
+func convertStep(sc StepConfig) (func(*Pipeline, *batch) error, error) {
+	if sc.From == "" || sc.To == "" {
+		return nil, errors.New("from and to are required")
+	}
+	from, err := extract.ParseTypes(sc.From)
+	if err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	to := strings.ToLower(strings.TrimPrefix(sc.To, "."))
+	if err := checkCommand(sc.Command, "{out}"); err != nil {
+		return nil, err
+	}
+	return func(p *Pipeline, b *batch) error {
+		for i, path := range b.files {
+			if !hasExt(path, from) {
+				continue
+			}
+			out := replaceExt(path, to)
+			if err := runCommand(expand(sc.Command, b, map[string]string{"{in}": path, "{out}": out})); err != nil {
+				return fmt.Errorf("%s: %w", filepath.Base(path), err)
+			}
+			if _, err := os.Stat(out); err != nil {
+				return fmt.Errorf("%s: the command did not write %s", filepath.Base(path), filepath.Base(out))
+			}
+			if !sc.Keep {
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+			}
+			b.files[i] = out
+		}
+		return nil
+	}, nil
+}
+
+// renameStep renames the files with a -name-template, numbering them in
+// order.
+func renameStep(sc StepConfig) (func(*Pipeline, *batch) error, error) {
+	if err := extract.ValidateNameTemplate(sc.Template); err != nil {
+		return nil, fmt.Errorf("template: %w", err)
+	}
+	return func(p *Pipeline, b *batch) error {
+		for i, path := range b.files {
+			name := extract.TemplateName(sc.Template, filepath.Base(path), b.Date, i+1)
+			target := freePath(filepath.Join(filepath.Dir(path), name), path)
+			if target == path {
+				continue
+			}
+			if err := os.Rename(path, target); err != nil {
+				return err
+			}
+			b.files[i] = target
+		}
+		return nil
+	}, nil
+}
+
+// mtimeStep sets the modification time of the files to their EXIF capture
+// time or to their Yandex date, so file browsers sort them by when they
+// were taken.
+func mtimeStep(sc StepConfig) (func(*Pipeline, *batch) error, error) {
+	useExif := true
+	switch strings.ToLower(sc.Source) {
+	case "", "exif":
+	case "date":
+		useExif = false
+	default:
+		return nil, fmt.Errorf("unknown source %q (use exif or date)", sc.Source)
+	}
+	return func(p *Pipeline, b *batch) error {
+		// Noon of the date, so it reads as the same day in any time zone
+		var date time.Time
+		if d, err := datefilter.ParseYandexDate(b.Date); err == nil {
+			date = time.Date(d.Year(), d.Month(), d.Day(), 12, 0, 0, 0, time.Local)
+		}
+		for _, path := range b.files {
+			t := date
+			if useExif {
+				if info, err := exif.Read(path); err == nil && !info.Taken.IsZero() {
+					t = info.Taken
+				}
+			}
+			if t.IsZero() {
+				continue // Neither a capture time nor a date
+			}
+			if err := os.Chtimes(path, t, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+// hookStep runs a command once per download or, if an argument contains
+// {file}, once per file.
+func hookStep(sc StepConfig) (func(*Pipeline, *batch) error, error) {
+	if err := checkCommand(sc.Command, ""); err != nil {
+		return nil, err
+	}
+	perFile := false
+	for _, arg := range sc.Command {
+		perFile = perFile || strings.Contains(arg, "{file}")
+	}
+	return func(p *Pipeline, b *batch) error {
+		if !perFile {
+			return runCommand(expand(sc.Command, b, nil))
+		}
+		for _, path := range b.files {
+			if err := runCommand(expand(sc.Command, b, map[string]string{"{file}": path})); err != nil {
+				return fmt.Errorf("%s: %w", filepath.Base(path), err)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// sinkStep copies or moves the files into another directory, such as a NAS
+// share or a photo library, keeping their place relative to the download
+// directory. Files already there are not overwritten.
+func sinkStep(sc StepConfig) (func(*Pipeline, *batch) error, error) {
+	if sc.Dir == "" {
+		return nil, errors.New("dir is required")
+	}
+	return func(p *Pipeline, b *batch) error {
+		dir := sc.Dir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(p.root, dir)
+		}
+		for i, path := range b.files {
+			rel, err := filepath.Rel(p.root, path)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				rel = filepath.Base(path)
+			}
+			target := freePath(filepath.Join(dir, rel), "")
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if sc.Move {
+				err = fsutil.Move(path, target)
+			} else {
+				err = fsutil.Copy(path, target)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", filepath.Base(path), err)
+			}
+			if sc.Move {
+				b.files[i] = target
+			}
+		}
+		return nil
+	}, nil
+}
+
+// checkCommand validates the command of a step, which must mention the
+// placeholder need, if any.
+func checkCommand(command []string, need string) error {
+	if len(command) == 0 || command[0] == "" {
+		return errors.New("command is required")
+	}
+	if need != "" && !strings.Contains(strings.Join(command, " "), need) {
+		return fmt.Errorf("command must contain %s", need)
+	}
+	return nil
+}
+
+// expand replaces the placeholders in the arguments of a command: {date}
+// (YYYY-MM-DD), {archive} (the downloaded file), the step's own vars such
+// as {in}, {out} and {file}, and an argument that is just {files} by all
+// current files.
+func expand(command []string, b *batch, vars map[string]string) []string {
+	pairs := []string{"{date}", datefilter.DirName(b.Date), "{archive}", b.Path}
+	for k, v := range vars {
+		pairs = append(pairs, k, v)
+	}
+	r := strings.NewReplacer(pairs...)
+	var args []string
+	for _, arg := range command {
+		if arg == "{files}" {
+			args = append(args, b.files...)
+			continue
+		}
+		args = append(args, r.Replace(arg))
+	}
+	return args
+}
+
+// runCommand runs args, returning the end of its output on failure.
+func runCommand(args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			if len(msg) > 300 {
+				msg = "..." + msg[len(msg)-300:]
+			}
+			return fmt.Errorf("%s: %w: %s", args[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	return nil
+}
+
+// freePath returns path, or path with a "_2", "_3"... suffix if another
+// file than self already has it.
+func freePath(path, self string) string {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	candidate := path
+	for n := 2; candidate != self; n++ {
+		if _, err := os.Lstat(candidate); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		candidate = stem + "_" + strconv.Itoa(n) + ext
+	}
+	return candidate
+}
@@ -0,0 +1,70 @@
+// Package preflight verifies the environment before the browser is launched,
+// so common problems fail early with actionable messages instead of surfacing
+// as chromedp errors mid-run.
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/netcheck"
+)
+
+// Config controls which checks are run.
+type Config struct {
+	DownloadDir  string
+	MinFreeBytes uint64 // Minimum free space in DownloadDir (0 to skip)
+}
+
+// Run performs all checks and returns an error listing every problem found.
+func Run(ctx context.Context, cfg Config) error {
+	var problems []string
+	fail := func(msg string, err error) {
+		problems = append(problems, fmt.Sprintf("%s\n     (%v)", msg, err))
+	}
+
+	if err := netcheck.Resolve(ctx); err != nil {
+		fail(fmt.Sprintf("Cannot resolve %s. Check your DNS settings, VPN or internet connection.", netcheck.Host), err)
+	} else if err := netcheck.Dial(ctx); err != nil {
+		fail(fmt.Sprintf("Cannot reach %s. Check your internet connection, proxy or firewall.", netcheck.Host), err)
+	}
+
+	if err := checkWritable(cfg.DownloadDir); err != nil {
+		fail(fmt.Sprintf("Download directory %s is not writable. Choose another one with -download or fix its permissions.", cfg.DownloadDir), err)
+	} else if cfg.MinFreeBytes > 0 {
+		free, err := freeSpace(cfg.DownloadDir)
+		switch {
+		case err != nil:
+			log.Printf("⚠️ Warning: could not check free space in %s: %v", cfg.DownloadDir, err)
+		case free < cfg.MinFreeBytes:
+			fail(fmt.Sprintf("Not enough free space in %s: %s available, %s required. Free up space, choose another -download directory or lower -min-free-gb.",
+				cfg.DownloadDir, formatGB(free), formatGB(cfg.MinFreeBytes)), errors.New("insufficient disk space"))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("pre-flight checks failed:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	log.Println("✓ Pre-flight checks passed")
+	return nil
+}
+
+// checkWritable creates and removes a temporary file in dir.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".preflight-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
+
+// formatGB renders a byte count in gigabytes.
+func formatGB(bytes uint64) string {
+	return fmt.Sprintf("%.1f GB", float64(bytes)/(1<<30))
+}
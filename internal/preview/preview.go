@@ -0,0 +1,138 @@
+// Package preview saves the thumbnails Yandex shows for a date instead of
+// downloading the originals, for a quick look at what a full export would
+// contain.
+package preview
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/datefilter"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/locale"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/selection"
+)
+
+// fetchTimeout bounds the download of a single thumbnail.
+const fetchTimeout = 30 * time.Second
+
+// Thumbnail is a preview image shown in the timeline.
+type Thumbnail struct {
+	Src  string `json:"src"`
+	Name string `json:"name"` // Alt text, usually the file name; may be empty
+}
+
+// Thumbnails returns the previews shown for date. Yandex only renders the
+// photos near the viewport, so very large dates may be partially covered.
+func Thumbnails(ctx context.Context, date *selection.DateInfo) ([]Thumbnail, error) {
+	var thumbs []Thumbnail
+	if err := browser.Evaluate(ctx, scripts.Call("date_thumbnails", date.YPosition, locale.Current().Months), &thumbs); err != nil {
+		return nil, fmt.Errorf("could not list thumbnails: %w", err)
+	}
+	return thumbs, nil
+}
+
+// Save downloads thumbs into a folder of dir named after date, with the
+// browser's cookies, and returns the number of files and bytes saved.
+func Save(ctx context.Context, thumbs []Thumbnail, dir, date string) (int, int64, error) {
+	if len(thumbs) == 0 {
+		return 0, 0, nil
+	}
+	dest := filepath.Join(dir, datefilter.DirName(date))
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return 0, 0, err
+	}
+
+	urls := make([]string, len(thumbs))
+	for i, t := range thumbs {
+		urls[i] = t.Src
+	}
+	var cookies []*network.Cookie
+	if err := browser.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) (err error) {
+		cookies, err = network.GetCookies().WithURLs(urls).Do(ctx)
+		return err
+	})); err != nil {
+		return 0, 0, fmt.Errorf("could not read cookies: %w", err)
+	}
+	var userAgent string
+	browser.Evaluate(ctx, `navigator.userAgent`, &userAgent)
+
+	client := &http.Client{Timeout: fetchTimeout}
+	files, total := 0, int64(0)
+	var firstErr error
+	for i, t := range thumbs {
+		n, err := fetch(ctx, client, t.Src, cookies, userAgent, filepath.Join(dest, fileName(t, i)))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		files++
+		total += n
+	}
+	if files == 0 && firstErr != nil {
+		return 0, 0, firstErr
+	}
+	return files, total, nil
+}
+
+// fileName names a saved thumbnail after the photo if Yandex tells its name.
+func fileName(t Thumbnail, i int) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 32 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(t.Name))
+	if name == "" {
+		name = fmt.Sprintf("%04d", i+1)
+	}
+	// Previews are JPEG whatever the original format
+	return fmt.Sprintf("%04d_%s.jpg", i+1, strings.TrimSuffix(name, filepath.Ext(name)))
+}
+
+// fetch downloads url to path.
+func fetch(ctx context.Context, client *http.Client, url string, cookies []*network.Cookie, userAgent, path string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range cookies {
+		req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("thumbnail request failed: %s", resp.Status)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, resp.Body)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return n, err
+}
@@ -0,0 +1,168 @@
+// Package progress keeps a small JSON file with the status of the running
+// export up to date, so NAS dashboards and scripts can follow a run by
+// reading a file instead of polling an HTTP endpoint.
+//
+// All methods are safe to call on a nil *File, which writes nothing.
+package progress
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/events"
+)
+
+// FileName is the name of the progress file in the state directory.
+const FileName = "yandex-progress.json"
+
+// Progress is the content of the progress file.
+type Progress struct {
+	Updated         time.Time  `json:"updated"`
+	Started         time.Time  `json:"started"`
+	Finished        bool       `json:"finished"`
+	CurrentDate     string     `json:"current_date,omitempty"` // Date being exported
+	DatesFound      int        `json:"dates_found"`
+	DatesSkipped    int        `json:"dates_skipped"`
+	DatesDone       int        `json:"dates_done"` // Dates whose download finished or failed
+	DownloadsFailed int        `json:"downloads_failed"`
+	Items           int        `json:"items"` // Photos and videos in the selected dates
+	Bytes           int64      `json:"bytes"`
+	DatesExpected   int        `json:"dates_expected,omitempty"` // Dates in range known from earlier runs
+	ETA             *time.Time `json:"eta,omitempty"`            // Estimated end, once DatesExpected is known
+}
+
+// Tracker builds a Progress from export events.
+type Tracker struct {
+	mu sync.Mutex
+	p  Progress
+}
+
+// NewTracker creates a Tracker fed by the emitter's events. expected is the
+// number of dates the run is expected to export (0 if unknown); it enables
+// the ETA.
+func NewTracker(emitter *events.Emitter, expected int) *Tracker {
+	t := &Tracker{p: Progress{Started: time.Now(), DatesExpected: expected}}
+	emitter.Subscribe(t.record)
+	return t
+}
+
+// Snapshot returns the current progress, with its ETA.
+func (t *Tracker) Snapshot() Progress {
+	t.mu.Lock()
+	p := t.p
+	t.mu.Unlock()
+
+	p.Updated = time.Now()
+	p.ETA = eta(p)
+	return p
+}
+
+// File writes a Progress fed by export events to a file at a fixed interval.
+type File struct {
+	path    string
+	tracker *Tracker
+	stop    chan struct{}
+	done    chan struct{}
+	warned  bool // A write error was already logged
+}
+
+// New creates a File at path fed by the emitter's events. expected is as
+// for NewTracker.
+func New(path string, emitter *events.Emitter, expected int) *File {
+	return &File{
+		path:    path,
+		tracker: NewTracker(emitter, expected),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start writes the file now, then every interval in the background until
+// Close.
+func (f *File) Start(interval time.Duration) {
+	if f == nil {
+		return
+	}
+	f.write()
+	go func() {
+		defer close(f.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				f.write()
+			case <-f.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the background writes and writes the final state.
+func (f *File) Close() {
+	if f == nil {
+		return
+	}
+	close(f.stop)
+	<-f.done
+	f.write()
+}
+
+// record updates the progress from an event.
+func (t *Tracker) record(ev events.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch ev.Type {
+	case events.RunStarted:
+		t.p.Started = ev.Time
+	case events.RunFinished:
+		t.p.Finished = true
+		t.p.CurrentDate = ""
+	case events.DateFound:
+		t.p.DatesFound++
+	case events.DateSkipped:
+		t.p.DatesSkipped++
+	case events.DateSelected:
+		t.p.CurrentDate = ev.Date
+		t.p.Items += ev.Items
+	case events.DownloadCompleted:
+		t.p.DatesDone++
+		t.p.Bytes += ev.Bytes
+	case events.DownloadFailed:
+		t.p.DatesDone++
+		t.p.DownloadsFailed++
+	}
+}
+
+// write saves the progress atomically.
+func (f *File) write() {
+	b, err := json.MarshalIndent(f.tracker.Snapshot(), "", "  ")
+	if err == nil {
+		tmp := f.path + ".tmp"
+		if err = os.WriteFile(tmp, b, 0644); err == nil {
+			err = os.Rename(tmp, f.path)
+		}
+	}
+	if err != nil && !f.warned {
+		// A stale progress file must not stop the export
+		log.Printf("Warning: could not write progress file: %v", err)
+		f.warned = true
+	}
+}
+
+// eta estimates when the run ends from the average time per finished date,
+// or returns nil when it cannot tell.
+func eta(p Progress) *time.Time {
+	if p.Finished || p.DatesDone == 0 || p.DatesExpected <= p.DatesDone {
+		return nil
+	}
+	elapsed := p.Updated.Sub(p.Started)
+	perDate := elapsed / time.Duration(p.DatesDone)
+	end := p.Updated.Add(perDate * time.Duration(p.DatesExpected-p.DatesDone)).Truncate(time.Second)
+	return &end
+}
@@ -0,0 +1,76 @@
+// Package quota reads how much of the account's Yandex Disk storage is used,
+// from the storage indicator in the sidebar of the Yandex Disk page.
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/extract"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
+)
+
+// ErrNotFound is returned when the page shows no storage indicator.
+var ErrNotFound = errors.New("storage indicator not found")
+
+// Usage is the storage of a Yandex account at one point of the run.
+type Usage struct {
+	Used  int64 `json:"used"`  // Bytes taken, including the Trash
+	Total int64 `json:"total"` // Bytes available to the account
+}
+
+// size matches an amount with its unit, as in "7.9 GB" or "2,1 ГБ".
+var size = regexp.MustCompile(`\d+(?:[.,]\d+)?[KMGT]?B|\d+(?:[.,]\d+)?[КМГТ]?Б`)
+
+// free matches indicators that tell the free space instead of the used one,
+// as in "2.1 GB free of 10 GB" or "Свободно 2,1 ГБ из 10 ГБ".
+var free = regexp.MustCompile(`(?i)free|available|left|свободно|доступно|осталось`)
+
+// cyrillicUnits maps the size units of the Russian interface to the ones
+// extract.ParseSize reads.
+var cyrillicUnits = strings.NewReplacer("ТБ", "TB", "ГБ", "GB", "МБ", "MB", "КБ", "KB", "Б", "B", ",", ".")
+
+func (u Usage) String() string {
+	return fmt.Sprintf("%.1f GB of %.1f GB", float64(u.Used)/(1<<30), float64(u.Total)/(1<<30))
+}
+
+// Read returns the storage usage shown in the sidebar of the page.
+func Read(ctx context.Context) (Usage, error) {
+	var text string
+	if err := browser.Evaluate(ctx, scripts.Call("storage_usage"), &text); err != nil {
+		return Usage{}, fmt.Errorf("could not read the storage indicator: %w", err)
+	}
+	if text == "" {
+		return Usage{}, ErrNotFound
+	}
+	return Parse(text)
+}
+
+// Parse reads the text of a storage indicator: the first amount is the
+// used space (or the free one, if the text says so) and the second the
+// account's total.
+func Parse(text string) (Usage, error) {
+	// Yandex separates thousands with (non-breaking) spaces: "1 024 ГБ"
+	compact := strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "").Replace(text)
+	m := size.FindAllStringSubmatch(compact, -1)
+	if len(m) != 2 {
+		return Usage{}, fmt.Errorf("unexpected storage indicator %q", text)
+	}
+	var amounts [2]int64
+	for i, s := range m {
+		n, err := extract.ParseSize(cyrillicUnits.Replace(s[0]))
+		if err != nil {
+			return Usage{}, fmt.Errorf("unexpected storage indicator %q: %w", text, err)
+		}
+		amounts[i] = n
+	}
+	u := Usage{Used: amounts[0], Total: amounts[1]}
+	if free.MatchString(text) {
+		u.Used = u.Total - amounts[0]
+	}
+	return u, nil
+}
@@ -0,0 +1,93 @@
+package report
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/datefilter"
+)
+
+// undated groups the dates whose label could not be parsed.
+const undated = "undated"
+
+// PeriodTotal sums the exported dates of a month or a year.
+type PeriodTotal struct {
+	Period string `json:"period"` // YYYY-MM or YYYY, or "undated"
+	Dates  int    `json:"dates"`
+	Files  int    `json:"files"` // Items selected in those dates
+	Bytes  int64  `json:"bytes"` // Size of their saved archives
+}
+
+// dateResult is what a date contributed to the export.
+type dateResult struct {
+	items int   // Items selected, from the last selection of the date
+	bytes int64 // Size of the archives saved for the date
+	saved bool  // At least one archive was saved
+}
+
+// result returns the entry of date, adding it if needed. The caller must
+// hold s.mu.
+func (s *Stats) result(date string) *dateResult {
+	if s.dates == nil {
+		s.dates = make(map[string]*dateResult)
+	}
+	r, ok := s.dates[date]
+	if !ok {
+		r = &dateResult{}
+		s.dates[date] = r
+	}
+	return r
+}
+
+// mergeDates adds the per-date results of other.
+func (s *Stats) mergeDates(other *Stats) {
+	for date, o := range other.dates {
+		r := s.result(date)
+		r.items = max(r.items, o.items)
+		r.bytes += o.bytes
+		r.saved = r.saved || o.saved
+	}
+}
+
+// sumPeriods totals the dates with a saved archive by month and by year,
+// in chronological order with undated dates last.
+func (s *Stats) sumPeriods() {
+	months := make(map[string]*PeriodTotal)
+	years := make(map[string]*PeriodTotal)
+	add := func(totals map[string]*PeriodTotal, period string, r *dateResult) {
+		t, ok := totals[period]
+		if !ok {
+			t = &PeriodTotal{Period: period}
+			totals[period] = t
+		}
+		t.Dates++
+		t.Files += r.items
+		t.Bytes += r.bytes
+	}
+	for date, r := range s.dates {
+		if !r.saved {
+			continue
+		}
+		month, year := undated, undated
+		if t, err := datefilter.ParseYandexDate(date); err == nil {
+			month, year = t.Format("2006-01"), t.Format("2006")
+		}
+		add(months, month, r)
+		add(years, year, r)
+	}
+	s.Months = sortedPeriods(months)
+	s.Years = sortedPeriods(years)
+}
+
+// sortedPeriods returns the totals in chronological order, undated last.
+func sortedPeriods(totals map[string]*PeriodTotal) []PeriodTotal {
+	periods := slices.Sorted(maps.Keys(totals))
+	if i := slices.Index(periods, undated); i >= 0 {
+		periods = append(slices.Delete(periods, i, i+1), undated)
+	}
+	sorted := make([]PeriodTotal, 0, len(periods))
+	for _, p := range periods {
+		sorted = append(sorted, *totals[p])
+	}
+	return sorted
+}
@@ -2,31 +2,106 @@
 package report
 
 import (
+	"cmp"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+
+	"golang.org/x/text/width"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/logging"
 )
 
 // ErrorEntry represents a single error that occurred during execution.
 type ErrorEntry struct {
-	Timestamp time.Time
-	DateInfo  string // The date being processed when error occurred
-	Message   string
+	Timestamp time.Time `json:"time"`
+	DateInfo  string    `json:"date,omitempty"` // The date being processed when error occurred
+	Message   string    `json:"message"`
+}
+
+// Mismatch is an archive whose number of files differs from the number of
+// items Yandex reported for its date when it was selected.
+type Mismatch struct {
+	File     string `json:"file"` // Name of the archive on disk
+	Date     string `json:"date"`
+	Expected int    `json:"expected"` // Items selected
+	Actual   int    `json:"actual"`   // Files in the archive
+}
+
+// BlacklistEntry is a date that failed in too many runs and is skipped.
+type BlacklistEntry struct {
+	Date     string `json:"date"`
+	Failures int    `json:"failures"` // Runs in which the date failed
+	Error    string `json:"error"`    // Last error
+	New      bool   `json:"new"`      // Blacklisted during this run
+}
+
+// Archive statuses.
+const (
+	ArchiveBegun  = "begun"  // Chrome began downloading it; not finished yet
+	ArchiveSaved  = "saved"  // Saved to disk
+	ArchiveFailed = "failed" // Canceled or interrupted
+)
+
+// ArchiveEntry maps an archive Chrome began downloading to the date it was
+// downloaded for.
+type ArchiveEntry struct {
+	Name   string `json:"name"`           // Name suggested by Yandex
+	File   string `json:"file,omitempty"` // Name of the archive on disk, once saved
+	Date   string `json:"date"`           // Yandex date label
+	Status string `json:"status"`
+}
+
+// StorageUsage is the storage of the Yandex account at one point of the run.
+type StorageUsage struct {
+	Used  int64 `json:"used"`  // Bytes taken, including the Trash
+	Total int64 `json:"total"` // Bytes available to the account
 }
 
-// Stats holds all statistics collected during execution.
+// Stats holds all statistics collected during execution. Its methods are
+// safe for concurrent use: the download tracker and the extract workers
+// record into it from their own goroutines while the export loop runs.
 type Stats struct {
-	StartTime        time.Time
-	EndTime          time.Time
-	DatesProcessed   int
-	DownloadsStarted int
-	DownloadsFailed  int
-	SkippedDates     int   // Dates skipped (out of range)
-	TotalSize        int64 // Total size of downloaded files in bytes
-	DownloadDir      string
-	Errors           []ErrorEntry
+	StartTime        time.Time        `json:"start_time"`
+	EndTime          time.Time        `json:"end_time"`
+	DatesProcessed   int              `json:"dates_processed"`
+	DownloadsStarted int              `json:"downloads_started"`
+	DownloadsFailed  int              `json:"downloads_failed"`
+	ItemsSelected    int              `json:"items_selected"`  // Photos and videos in the selected dates
+	SkippedDates     int              `json:"skipped_dates"`   // Dates skipped (out of range)
+	PageRefreshes    int              `json:"page_refreshes"`  // Page reloads (wedged UI recovery and periodic reloads)
+	ThrottleEvents   int              `json:"throttle_events"` // Throttling signals detected (429s, rate-limit toasts)
+	StuckDates       int              `json:"stuck_dates"`     // Dates abandoned by the per-date watchdog
+	NetworkOutages   int              `json:"network_outages"` // Pauses caused by lost network connectivity
+	TrashedDates     int              `json:"trashed_dates"`   // Dates moved to the Yandex Trash after verification
+	MarkedDates      int              `json:"marked_dates"`    // Dates added to the "exported" album after verification
+	FilesExtracted   int              `json:"files_extracted"` // Files unpacked from downloaded archives
+	FilesSkipped     int              `json:"files_skipped"`   // Files left out of extraction by the filters
+	DuplicateFiles   int              `json:"duplicate_files"` // Extracted files identical to an earlier one
+	DuplicateBytes   int64            `json:"duplicate_bytes"` // Disk space saved by deduplication
+	TotalSize        int64            `json:"total_size"`      // Total size of downloaded files in bytes
+	DownloadDir      string           `json:"download_dir"`
+	StorageBefore    *StorageUsage    `json:"storage_before,omitempty"` // Account storage when the run started
+	StorageAfter     *StorageUsage    `json:"storage_after,omitempty"`  // Account storage when the run ended
+	Errors           []ErrorEntry     `json:"errors"`
+	Archives         []ArchiveEntry   `json:"archives"`         // Archives Chrome began downloading, in the order they began
+	CountMismatches  []Mismatch       `json:"count_mismatches"` // Extracted archives with more or fewer files than items selected
+	Blacklisted      []BlacklistEntry `json:"blacklisted"`      // Blacklisted dates seen during the run
+	DebugBundles     []string         `json:"debug_bundles"`    // Directories of debug bundles written on failures
+	Timings          StepTimings      `json:"timings"`          // Durations of each step of the per-date cycle
+	DateTimings      DateTimings      `json:"date_timings"`     // Durations of each downloaded date
+	Months           []PeriodTotal    `json:"months"`           // Exported dates, files and bytes by month
+	Years            []PeriodTotal    `json:"years"`            // Exported dates, files and bytes by year
+
+	mu    sync.Mutex
+	dates map[string]*dateResult // What each date contributed, summed into Months and Years
 }
 
 // New creates a new Stats instance with StartTime set to now.
@@ -37,8 +112,58 @@ func New() *Stats {
 	}
 }
 
+// Merge combines the stats of several concurrent runs into a single report.
+// The earliest start time is kept and all counters and errors are summed.
+func Merge(parts ...*Stats) *Stats {
+	merged := New()
+	for i, p := range parts {
+		p.mu.Lock()
+		if i == 0 || p.StartTime.Before(merged.StartTime) {
+			merged.StartTime = p.StartTime
+		}
+		if merged.DownloadDir == "" {
+			merged.DownloadDir = p.DownloadDir
+		}
+		// All shards share the account: keep the first reading before and
+		// the last one after
+		if merged.StorageBefore == nil {
+			merged.StorageBefore = p.StorageBefore
+		}
+		if p.StorageAfter != nil {
+			merged.StorageAfter = p.StorageAfter
+		}
+		merged.DatesProcessed += p.DatesProcessed
+		merged.DownloadsStarted += p.DownloadsStarted
+		merged.DownloadsFailed += p.DownloadsFailed
+		merged.ItemsSelected += p.ItemsSelected
+		merged.SkippedDates += p.SkippedDates
+		merged.PageRefreshes += p.PageRefreshes
+		merged.ThrottleEvents += p.ThrottleEvents
+		merged.StuckDates += p.StuckDates
+		merged.NetworkOutages += p.NetworkOutages
+		merged.TrashedDates += p.TrashedDates
+		merged.MarkedDates += p.MarkedDates
+		merged.FilesExtracted += p.FilesExtracted
+		merged.FilesSkipped += p.FilesSkipped
+		merged.DuplicateFiles += p.DuplicateFiles
+		merged.DuplicateBytes += p.DuplicateBytes
+		merged.Errors = append(merged.Errors, p.Errors...)
+		merged.Archives = append(merged.Archives, p.Archives...)
+		merged.CountMismatches = append(merged.CountMismatches, p.CountMismatches...)
+		merged.Blacklisted = append(merged.Blacklisted, p.Blacklisted...)
+		merged.DebugBundles = append(merged.DebugBundles, p.DebugBundles...)
+		merged.Timings.merge(p.Timings)
+		merged.DateTimings.merge(p.DateTimings)
+		merged.mergeDates(p)
+		p.mu.Unlock()
+	}
+	return merged
+}
+
 // AddError records an error that occurred during processing.
 func (s *Stats) AddError(dateInfo, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.Errors = append(s.Errors, ErrorEntry{
 		Timestamp: time.Now(),
 		DateInfo:  dateInfo,
@@ -46,37 +171,240 @@ func (s *Stats) AddError(dateInfo, message string) {
 	})
 }
 
+// AddDebugBundle records the location of a debug bundle written for a failure.
+func (s *Stats) AddDebugBundle(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.DebugBundles = append(s.DebugBundles, dir)
+}
+
 // IncrementDownloadsStarted increments the successful downloads counter.
 func (s *Stats) IncrementDownloadsStarted() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.DownloadsStarted++
 }
 
+// AddSelectedItems adds the item count of a selected date.
+func (s *Stats) AddSelectedItems(date string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ItemsSelected += n
+	s.result(date).items = n
+}
+
+// AddMismatch records an extracted archive holding actual files where
+// expected items were selected, a sign of a truncated archive or a partial
+// selection.
+func (s *Stats) AddMismatch(file, date string, expected, actual int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.CountMismatches = append(s.CountMismatches, Mismatch{File: file, Date: date, Expected: expected, Actual: actual})
+}
+
+// AddBlacklisted records a blacklisted date seen during the run; isNew
+// tells whether it was blacklisted during this run.
+func (s *Stats) AddBlacklisted(date, lastError string, failures int, isNew bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Blacklisted = append(s.Blacklisted, BlacklistEntry{Date: date, Failures: failures, Error: lastError, New: isNew})
+}
+
+// BeginArchive records the name of an archive Chrome began downloading for date.
+func (s *Stats) BeginArchive(name, date string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Archives = append(s.Archives, ArchiveEntry{Name: name, Date: date, Status: ArchiveBegun})
+}
+
+// AddArchive records that the archive begun as name for date was saved as
+// file, of the given size.
+func (s *Stats) AddArchive(name, file, date string, bytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.begunArchive(name, date)
+	a.File, a.Status = file, ArchiveSaved
+	r := s.result(date)
+	r.bytes += bytes
+	r.saved = true
+}
+
+// FailArchive records that the archive begun as name for date was not saved.
+func (s *Stats) FailArchive(name, date string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.begunArchive(name, date).Status = ArchiveFailed
+}
+
+// begunArchive returns the oldest unfinished entry for the archive, adding
+// one if it was not recorded as begun. The caller must hold s.mu.
+func (s *Stats) begunArchive(name, date string) *ArchiveEntry {
+	for i := range s.Archives {
+		if a := &s.Archives[i]; a.Name == name && a.Date == date && a.Status == ArchiveBegun {
+			return a
+		}
+	}
+	s.Archives = append(s.Archives, ArchiveEntry{Name: name, Date: date, Status: ArchiveBegun})
+	return &s.Archives[len(s.Archives)-1]
+}
+
+// archiveOrder sorts unfinished and failed archives before saved ones in the
+// report, since those are the ones to look for on disk.
+func archiveOrder(status string) int {
+	switch status {
+	case ArchiveBegun:
+		return 0
+	case ArchiveFailed:
+		return 1
+	default:
+		return 2
+	}
+}
+
 // IncrementDownloadsFailed increments the failed downloads counter.
 func (s *Stats) IncrementDownloadsFailed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.DownloadsFailed++
 }
 
 // IncrementDatesProcessed increments the processed dates counter.
 func (s *Stats) IncrementDatesProcessed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.DatesProcessed++
 }
 
 // IncrementSkippedDates increments the skipped dates counter.
 func (s *Stats) IncrementSkippedDates() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.SkippedDates++
 }
 
+// IncrementPageRefreshes increments the page refresh counter.
+func (s *Stats) IncrementPageRefreshes() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.PageRefreshes++
+}
+
+// IncrementThrottleEvents increments the throttling signal counter.
+func (s *Stats) IncrementThrottleEvents() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ThrottleEvents++
+}
+
+// IncrementStuckDates increments the stuck dates counter.
+func (s *Stats) IncrementStuckDates() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.StuckDates++
+}
+
+// RecordStep records how long a step of the per-date cycle took.
+func (s *Stats) RecordStep(step string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Timings.Record(step, d)
+}
+
+// RecordDate records how long a downloaded date took from start to finish.
+func (s *Stats) RecordDate(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.DateTimings.Record(d)
+}
+
+// IncrementNetworkOutages increments the network outage counter.
+func (s *Stats) IncrementNetworkOutages() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.NetworkOutages++
+}
+
+// IncrementTrashedDates increments the counter of dates moved to Trash.
+func (s *Stats) IncrementTrashedDates() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.TrashedDates++
+}
+
+// IncrementMarkedDates increments the counter of dates added to the album.
+func (s *Stats) IncrementMarkedDates() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.MarkedDates++
+}
+
+// SetExtraction records the totals of archive extraction.
+func (s *Stats) SetExtraction(files, skipped int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.FilesExtracted = files
+	s.FilesSkipped = skipped
+}
+
+// SetDuplicates records the totals of deduplication.
+func (s *Stats) SetDuplicates(files int, bytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.DuplicateFiles = files
+	s.DuplicateBytes = bytes
+}
+
+// SetStorageBefore records the account's storage when the run started.
+func (s *Stats) SetStorageBefore(used, total int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.StorageBefore = &StorageUsage{Used: used, Total: total}
+}
+
+// SetStorageAfter records the account's storage when the run ended.
+func (s *Stats) SetStorageAfter(used, total int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.StorageAfter = &StorageUsage{Used: used, Total: total}
+}
+
+// Reclaimed returns how many bytes of the account's storage the run freed
+// (negative if usage grew), and false if it wasn't read at both ends.
+func (s *Stats) Reclaimed() (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reclaimed()
+}
+
+// reclaimed is Reclaimed for callers holding s.mu.
+func (s *Stats) reclaimed() (int64, bool) {
+	if s.StorageBefore == nil || s.StorageAfter == nil {
+		return 0, false
+	}
+	return s.StorageBefore.Used - s.StorageAfter.Used, true
+}
+
 // Finish marks the end time of the execution and calculates final stats.
 func (s *Stats) Finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finish()
+}
+
+// finish is Finish for callers holding s.mu.
+func (s *Stats) finish() {
 	s.EndTime = time.Now()
 	// Calculate total size of downloaded files
 	if s.DownloadDir != "" {
 		s.TotalSize = calculateDirSize(s.DownloadDir)
 	}
+	s.sumPeriods()
 }
 
 // SetDownloadDir sets the download directory for size calculation.
 func (s *Stats) SetDownloadDir(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.DownloadDir = dir
 }
 
@@ -115,8 +443,20 @@ func formatBytes(bytes int64) string {
 	}
 }
 
+// formatPeriod formats the totals of a month or a year for the report.
+func formatPeriod(t PeriodTotal) string {
+	return fmt.Sprintf("%d dates, %d files, %s", t.Dates, t.Files, formatBytes(t.Bytes))
+}
+
 // Duration returns the total execution duration.
 func (s *Stats) Duration() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.duration()
+}
+
+// duration is Duration for callers holding s.mu.
+func (s *Stats) duration() time.Duration {
 	if s.EndTime.IsZero() {
 		return time.Since(s.StartTime)
 	}
@@ -141,41 +481,61 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%ds", s)
 }
 
-// ANSI color codes
-const (
-	colorReset  = "\033[0m"
-	colorGreen  = "\033[32m"
-	colorYellow = "\033[33m"
-	colorRed    = "\033[31m"
-	colorCyan   = "\033[36m"
-	colorBold   = "\033[1m"
-	colorDim    = "\033[2m"
-)
+// plain makes Print use ASCII markers instead of emoji and drop colors.
+var plain bool
+
+// out is where Print writes; it is set at the start of each Print.
+var out io.Writer = os.Stderr
+
+// SetPlain enables or disables ASCII-only output for Print (-plain).
+func SetPlain(enabled bool) {
+	plain = enabled
+}
 
-// Print outputs the final report to the console with colors.
+// symbol returns an emoji or title as Print should show it.
+func symbol(s string) string {
+	if !plain {
+		return s
+	}
+	return strings.TrimSpace(logging.Plain(s))
+}
+
+// Print outputs the final report to the console with colors. Like the log,
+// it goes to stderr, which leaves stdout to machine-readable output such as
+// -json and -output jsonl.
 func (s *Stats) Print() {
-	s.Finish()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finish()
+
+	out = os.Stderr
+	if plain {
+		out = logging.PlainWriter(os.Stderr)
+	}
 
 	// Box width (internal content width, excluding borders)
 	contentWidth := 52
 	
-	fmt.Println()
+	fmt.Fprintln(out)
 	printBoxTop(contentWidth)
 	printBoxTitle("📊 FINAL REPORT", contentWidth)
 	printBoxSeparator(contentWidth)
 	
 	// Duration
-	printDataRow("⏱️ ", "Duration", formatDuration(s.Duration()), contentWidth, "")
+	printDataRow("⏱️ ", "Duration", formatDuration(s.duration()), contentWidth, "")
 	
 	// Dates processed
 	printDataRow("📅", "Dates processed", fmt.Sprintf("%d", s.DatesProcessed), contentWidth, "")
 	
 	// Downloads
 	downloadValue := fmt.Sprintf("%d started", s.DownloadsStarted)
-	downloadColor := colorGreen
+	if s.ItemsSelected > 0 {
+		downloadValue += fmt.Sprintf(" (%d items)", s.ItemsSelected)
+	}
+	downloadColor := theme.OK
 	if s.DownloadsFailed > 0 {
 		downloadValue += fmt.Sprintf(", %d failed", s.DownloadsFailed)
-		downloadColor = colorYellow
+		downloadColor = theme.Warn
 	}
 	printDataRow("⬇️ ", "Downloads", downloadValue, contentWidth, downloadColor)
 	
@@ -184,17 +544,181 @@ func (s *Stats) Print() {
 		printDataRow("💾", "Total size", formatBytes(s.TotalSize), contentWidth, "")
 	}
 	
+	// Extracted files (if any)
+	if s.FilesExtracted > 0 || s.FilesSkipped > 0 {
+		extractValue := fmt.Sprintf("%d files", s.FilesExtracted)
+		if s.FilesSkipped > 0 {
+			extractValue += fmt.Sprintf(", %d filtered out", s.FilesSkipped)
+		}
+		printDataRow("📦", "Extracted", extractValue, contentWidth, "")
+	}
+
+	// Deduplicated files (if any)
+	if s.DuplicateFiles > 0 {
+		dupValue := fmt.Sprintf("%d files, %s saved", s.DuplicateFiles, formatBytes(s.DuplicateBytes))
+		printDataRow("♻️ ", "Duplicates", dupValue, contentWidth, "")
+	}
+
+	// Dates moved to Trash (if any)
+	if s.TrashedDates > 0 {
+		printDataRow("🗑️ ", "Moved to Trash", fmt.Sprintf("%d dates (verified)", s.TrashedDates), contentWidth, "")
+	}
+
+	// Dates added to the album (if any)
+	if s.MarkedDates > 0 {
+		printDataRow("🏷️ ", "Added to album", fmt.Sprintf("%d dates (verified)", s.MarkedDates), contentWidth, "")
+	}
+
+	// Account storage (if read)
+	if usage := s.StorageAfter; usage != nil || s.StorageBefore != nil {
+		if usage == nil {
+			usage = s.StorageBefore
+		}
+		storageValue := fmt.Sprintf("%s of %s", formatBytes(usage.Used), formatBytes(usage.Total))
+		if s.StorageBefore != nil && s.StorageAfter != nil {
+			storageValue = formatBytes(s.StorageBefore.Used) + " → " + storageValue
+		}
+		printDataRow("☁️ ", "Storage used", storageValue, contentWidth, "")
+		if freed, ok := s.reclaimed(); ok && freed > 0 {
+			printDataRow("", "  reclaimed", formatBytes(freed), contentWidth, theme.OK)
+		}
+	}
+
 	// Skipped dates (if any)
 	if s.SkippedDates > 0 {
 		skippedValue := fmt.Sprintf("%d (out of date range)", s.SkippedDates)
-		printDataRow("⏭️ ", "Skipped", skippedValue, contentWidth, colorYellow)
+		printDataRow("⏭️ ", "Skipped", skippedValue, contentWidth, theme.Warn)
+	}
+
+	// Stuck dates (if any)
+	if s.StuckDates > 0 {
+		printDataRow("⏳", "Stuck", fmt.Sprintf("%d (timed out)", s.StuckDates), contentWidth, theme.Error)
+	}
+
+	// Page refreshes (if any)
+	if s.PageRefreshes > 0 {
+		printDataRow("🔄", "Page refreshes", fmt.Sprintf("%d", s.PageRefreshes), contentWidth, theme.Warn)
+	}
+
+	// Throttling signals (if any)
+	if s.ThrottleEvents > 0 {
+		printDataRow("🐢", "Throttled", fmt.Sprintf("%d times", s.ThrottleEvents), contentWidth, theme.Warn)
+	}
+
+	// Network outages (if any)
+	if s.NetworkOutages > 0 {
+		printDataRow("📡", "Network outages", fmt.Sprintf("%d", s.NetworkOutages), contentWidth, theme.Warn)
 	}
 	
+	// Blacklisted dates (if any), right after the counters so they aren't missed
+	if len(s.Blacklisted) > 0 {
+		printBoxSeparator(contentWidth)
+		printDataRow("⛔", fmt.Sprintf("Blacklisted dates (%d):", len(s.Blacklisted)), "", contentWidth, theme.Error)
+		for _, b := range s.Blacklisted {
+			line := fmt.Sprintf("- %s: failed in %d runs", b.Date, b.Failures)
+			if b.New {
+				line += ", blacklisted now"
+			}
+			printErrorLine(line, contentWidth)
+			if b.Error != "" {
+				printErrorLine("  "+b.Error, contentWidth)
+			}
+		}
+		printErrorLine("Skipped by later runs; use -retry-blacklisted", contentWidth)
+	}
+
+	// Step timings (if any)
+	if steps := s.Timings.Steps(); len(steps) > 0 {
+		printBoxSeparator(contentWidth)
+		printDataRow("⏲️ ", "Step timings:", "", contentWidth, "")
+		for _, step := range steps {
+			printDataRow("", "  "+step, s.Timings.Summary(step), contentWidth, "")
+		}
+	}
+
+	// Per-date durations (if any)
+	if s.DateTimings.Count() > 0 {
+		printBoxSeparator(contentWidth)
+		printDataRow("⌛", fmt.Sprintf("Per date (%d):", s.DateTimings.Count()), "", contentWidth, "")
+		printDataRow("", "  min / median", fmt.Sprintf("%s / %s",
+			formatStepDuration(s.DateTimings.Percentile(0)), formatStepDuration(s.DateTimings.Percentile(50))), contentWidth, "")
+		printDataRow("", "  p95 / max", fmt.Sprintf("%s / %s",
+			formatStepDuration(s.DateTimings.Percentile(95)), formatStepDuration(s.DateTimings.Percentile(100))), contentWidth, "")
+		maxCount := 0
+		for _, b := range s.DateTimings.Histogram() {
+			maxCount = max(maxCount, b.Count)
+		}
+		for _, b := range s.DateTimings.Histogram() {
+			if b.Count == 0 {
+				continue
+			}
+			bar := strings.Repeat("#", max(1, b.Count*20/maxCount))
+			printDataRow("", "  "+b.Label, fmt.Sprintf("%s %d", bar, b.Count), contentWidth, "")
+		}
+	}
+
+	// Exported dates by year and by month (if any)
+	if len(s.Years) > 0 {
+		printBoxSeparator(contentWidth)
+		printDataRow("🗓️ ", "Exported by year:", "", contentWidth, "")
+		for _, y := range s.Years {
+			printDataRow("", "  "+y.Period, formatPeriod(y), contentWidth, "")
+		}
+		printDataRow("", "By month:", "", contentWidth, "")
+		maxMonths := 12
+		for i, m := range s.Months {
+			if i >= maxMonths {
+				printErrorLine(fmt.Sprintf("... and %d more months (see -json)", len(s.Months)-maxMonths), contentWidth)
+				break
+			}
+			printDataRow("", "  "+m.Period, formatPeriod(m), contentWidth, "")
+		}
+	}
+
+	// Archive-to-date mapping (if any), unfinished archives first
+	if len(s.Archives) > 0 {
+		printBoxSeparator(contentWidth)
+		printDataRow("🗂️ ", fmt.Sprintf("Archives (%d):", len(s.Archives)), "", contentWidth, "")
+		archives := slices.Clone(s.Archives)
+		slices.SortStableFunc(archives, func(a, b ArchiveEntry) int {
+			return cmp.Compare(archiveOrder(a.Status), archiveOrder(b.Status))
+		})
+		maxArchives := 5
+		for i, a := range archives {
+			if i >= maxArchives {
+				printErrorLine(fmt.Sprintf("... and %d more (find any with -which)", len(archives)-maxArchives), contentWidth)
+				break
+			}
+			switch a.Status {
+			case ArchiveSaved:
+				printErrorLine(fmt.Sprintf("- %s → %s", a.File, a.Date), contentWidth)
+			case ArchiveFailed:
+				printErrorLine(fmt.Sprintf("- %s → %s (failed)", a.Name, a.Date), contentWidth)
+			default:
+				printErrorLine(fmt.Sprintf("- %s → %s (not finished)", a.Name, a.Date), contentWidth)
+			}
+		}
+	}
+
+	// Archives whose file count does not match the selection (if any)
+	if len(s.CountMismatches) > 0 {
+		printBoxSeparator(contentWidth)
+		printDataRow("⚠️", fmt.Sprintf("Count mismatches (%d):", len(s.CountMismatches)), "", contentWidth, theme.Warn)
+		maxMismatches := 5
+		for i, m := range s.CountMismatches {
+			if i >= maxMismatches {
+				printErrorLine(fmt.Sprintf("... and %d more", len(s.CountMismatches)-maxMismatches), contentWidth)
+				break
+			}
+			printErrorLine(fmt.Sprintf("- %s: %d of %d items (%s)", m.File, m.Actual, m.Expected, m.Date), contentWidth)
+		}
+	}
+
 	// Errors section
 	printBoxSeparator(contentWidth)
 	if len(s.Errors) > 0 {
 		errTitle := fmt.Sprintf("Errors (%d):", len(s.Errors))
-		printDataRow("❌", errTitle, "", contentWidth, colorRed)
+		printDataRow("❌", errTitle, "", contentWidth, theme.Error)
 		
 		// Show up to 5 errors
 		maxErrors := 5
@@ -211,38 +735,47 @@ func (s *Stats) Print() {
 			printErrorLine(errText, contentWidth)
 		}
 	} else {
-		printDataRow("✅", "No errors occurred", "", contentWidth, colorGreen)
+		printDataRow("✅", "No errors occurred", "", contentWidth, theme.OK)
+	}
+
+	// Debug bundles (if any)
+	if len(s.DebugBundles) > 0 {
+		printDataRow("🧾", "Debug bundles:", "", contentWidth, theme.Warn)
+		for _, dir := range s.DebugBundles {
+			printErrorLine("- "+dir, contentWidth)
+		}
 	}
 	
 	printBoxBottom(contentWidth)
-	fmt.Println()
+	fmt.Fprintln(out)
 }
 
 // printBoxTop prints the top border.
 func printBoxTop(width int) {
-	fmt.Printf("%s%s%s\n", colorCyan, strings.Repeat("=", width), colorReset)
+	fmt.Fprintf(out, "%s%s%s\n", theme.Border, strings.Repeat("=", width), theme.Reset)
 }
 
 // printBoxBottom prints the bottom border.
 func printBoxBottom(width int) {
-	fmt.Printf("%s%s%s\n", colorCyan, strings.Repeat("=", width), colorReset)
+	fmt.Fprintf(out, "%s%s%s\n", theme.Border, strings.Repeat("=", width), theme.Reset)
 }
 
 // printBoxSeparator prints a horizontal separator line.
 func printBoxSeparator(width int) {
-	fmt.Printf("%s%s%s\n", colorCyan, strings.Repeat("-", width), colorReset)
+	fmt.Fprintf(out, "%s%s%s\n", theme.Border, strings.Repeat("-", width), theme.Reset)
 }
 
 // printBoxTitle prints a centered title.
 func printBoxTitle(title string, width int) {
+	title = symbol(title)
 	visLen := measureString(title)
 	padding := (width - visLen) / 2
 	if padding < 0 { padding = 0 }
 	
-	fmt.Printf("%s%s%s%s%s\n", 
+	fmt.Fprintf(out, "%s%s%s%s%s\n", 
 		strings.Repeat(" ", padding),
-		colorBold, title, colorReset,
-		colorCyan) // Restore color for next lines if needed, though mostly reset
+		theme.Title, title, theme.Reset,
+		theme.Border) // Restore color for next lines if needed, though mostly reset
 }
 
 // printDataRow prints a data row with emoji, label, and value.
@@ -254,10 +787,13 @@ func printDataRow(emoji, label, value string, width int, valueColor string) {
 	colGap := "   " // Space between label and value
 	
 	labelFixedVisWidth := 22
+	if plain {
+		labelFixedVisWidth = 28 // ASCII markers are longer than emoji
+	}
 	
 	// Prepare Label
 	fullLabel := label
-	if emoji != "" {
+	if emoji = symbol(emoji); emoji != "" {
 		fullLabel = emoji + "  " + label // Extra space after emoji for aesthetics
 	}
 	
@@ -269,16 +805,16 @@ func printDataRow(emoji, label, value string, width int, valueColor string) {
 
 	valueField := value
 	if valueColor != "" {
-		valueField = valueColor + value + colorReset
+		valueField = valueColor + value + theme.Reset
 	}
 
-	fmt.Printf("%s%s%s%s%s%s\n", 
-		colorCyan, // Base color (though mostly reset inside)
+	fmt.Fprintf(out, "%s%s%s%s%s%s\n", 
+		theme.Border, // Base color (though mostly reset inside)
 		indent,
-		colorReset + labelField,
+		theme.Reset + labelField,
 		colGap,
 		valueField,
-		colorReset)
+		theme.Reset)
 }
 
 // printErrorLine prints an error detail line.
@@ -286,11 +822,11 @@ func printErrorLine(text string, width int) {
 	// Layout: "      [text]"
 	indent := "      " // Indent to align with text start of data rows
 	
-	fmt.Printf("%s%s%s%s\n",
-		colorCyan, 
+	fmt.Fprintf(out, "%s%s%s%s\n",
+		theme.Border, 
 		indent,
-		colorRed + text + colorReset,
-		colorReset)
+		theme.Error + text + theme.Reset,
+		theme.Reset)
 }
 
 // measureString returns visual length of string without ANSI codes
@@ -298,26 +834,48 @@ func measureString(s string) int {
 	return visualLength(stripAnsiCodes(s))
 }
 
-// visualLength calculates visual width of string handling emojis
+// visualLength returns the number of terminal columns s takes up: East
+// Asian wide and fullwidth characters and emoji count 2, combining marks and
+// other zero-width characters 0, and an emoji ZWJ sequence or an emoji with
+// skin tone counts as the single glyph terminals draw for it.
 func visualLength(s string) int {
-	width := 0
+	cols := 0
+	last := 0 // Width of the previous glyph
+	joined := false
 	for _, r := range s {
-		// Variation Selector-16 (VS16) to force emoji style: \uFE0F
-		// Some chars are zero width
-		if r == '\ufe0f' {
-			continue
-		}
-		
-		// Simple heuristic for East Asian Width / Emojis
-		// Most emojis and CJK characters are 2 width
-		// ASCII and simple unicode are 1
-		if r > 256 {
-			width += 2
-		} else {
-			width += 1
+		switch {
+		case joined:
+			// Part of an emoji ZWJ sequence, drawn as one glyph
+			joined = false
+		case r == '\u200d':
+			joined = true
+		case r == '\ufe0f':
+			// Variation Selector-16 asks for the wide emoji presentation
+			if last == 1 {
+				cols++
+				last = 2
+			}
+		case r >= 0x1f3fb && r <= 0x1f3ff:
+			// Skin tone modifiers merge into the preceding emoji
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf, unicode.Cc):
+			// Combining marks and format characters take no column
+		default:
+			last = runeWidth(r)
+			cols += last
 		}
 	}
-	return width
+	return cols
+}
+
+// runeWidth returns the columns a single non-combining rune takes up.
+// Ambiguous characters, such as Cyrillic letters, are narrow outside East
+// Asian locales.
+func runeWidth(r rune) int {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
 }
 
 // stripAnsiCodes removes ANSI escape codes from a string.
@@ -340,8 +898,24 @@ func stripAnsiCodes(s string) string {
 	return result
 }
 
+// WriteJSON writes the stats as a single JSON document, for -json.
+func (s *Stats) WriteJSON(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finish()
+	doc := struct {
+		*Stats
+		DurationSeconds float64 `json:"duration_seconds"`
+	}{s, s.duration().Seconds()}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
 // Summary returns a brief one-line summary of the stats.
 func (s *Stats) Summary() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return fmt.Sprintf(
 		"%d dates processed, %d downloads (%d failed), %d skipped, %d errors in %s",
 		s.DatesProcessed,
@@ -349,6 +923,6 @@ func (s *Stats) Summary() string {
 		s.DownloadsFailed,
 		s.SkippedDates,
 		len(s.Errors),
-		formatDuration(s.Duration()),
+		formatDuration(s.duration()),
 	)
 }
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+// TestStatsConcurrent records from several goroutines at once, as the
+// download tracker, the extract workers and the export loop do. Run it with
+// -race to catch unguarded fields.
+func TestStatsConcurrent(t *testing.T) {
+	s := New()
+	const n = 50
+	var wg sync.WaitGroup
+	for i := range n {
+		date := fmt.Sprintf("%d January 2024", i%28+1)
+		name := fmt.Sprintf("archive-%d.zip", i)
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			s.BeginArchive(name, date)
+			s.AddArchive(name, name, date, 100)
+		}()
+		go func() {
+			defer wg.Done()
+			s.AddSelectedItems(date, 2)
+			s.IncrementDownloadsStarted()
+		}()
+		go func() {
+			defer wg.Done()
+			s.AddMismatch(name, date, 2, 1)
+			if err := s.WriteJSON(io.Discard); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.Finish()
+	if len(s.Archives) != n {
+		t.Errorf("%d archives, want %d", len(s.Archives), n)
+	}
+	for _, a := range s.Archives {
+		if a.Status != ArchiveSaved {
+			t.Errorf("archive %s is %s, want %s", a.Name, a.Status, ArchiveSaved)
+		}
+	}
+	if s.DownloadsStarted != n || s.ItemsSelected != 2*n || len(s.CountMismatches) != n {
+		t.Errorf("downloads %d, items %d, mismatches %d; want %d, %d, %d",
+			s.DownloadsStarted, s.ItemsSelected, len(s.CountMismatches), n, 2*n, n)
+	}
+	var bytes int64
+	for _, y := range s.Years {
+		bytes += y.Bytes
+	}
+	if bytes != 100*n {
+		t.Errorf("%d bytes by year, want %d", bytes, 100*n)
+	}
+}
@@ -0,0 +1,136 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Theme holds the ANSI sequences Print uses for each part of the report.
+type Theme struct {
+	Border string // Box lines
+	Title  string // Report title
+	OK     string // Values that are fine
+	Warn   string // Values worth a look
+	Error  string // Errors and their details
+	Reset  string // Ends any of the above
+}
+
+// paletteColor is a named color with a fallback for 8-color terminals.
+type paletteColor struct {
+	full  string // 256-color (or attribute) sequence
+	basic string // Closest of the 8 standard colors; "" when none is readable
+}
+
+// palette lists the colors a -report-theme may name.
+var palette = map[string]paletteColor{
+	"none":       {"", ""},
+	"bold":       {"\033[1m", "\033[1m"},
+	"dim":        {"\033[2m", ""}, // Unreadable next to cyan on many 8-color terminals
+	"black":      {"\033[30m", "\033[30m"},
+	"red":        {"\033[31m", "\033[31m"},
+	"green":      {"\033[32m", "\033[32m"},
+	"yellow":     {"\033[33m", "\033[33m"},
+	"blue":       {"\033[34m", "\033[34m"},
+	"magenta":    {"\033[35m", "\033[35m"},
+	"cyan":       {"\033[36m", "\033[36m"},
+	"white":      {"\033[37m", "\033[37m"},
+	"gray":       {"\033[38;5;245m", ""},
+	"orange":     {"\033[38;5;208m", "\033[33m"},
+	"dark-red":   {"\033[38;5;124m", "\033[31m"},
+	"dark-green": {"\033[38;5;28m", "\033[32m"},
+	"dark-blue":  {"\033[38;5;25m", "\033[34m"},
+	"brown":      {"\033[38;5;130m", "\033[35m"},
+}
+
+// themePresets are the named -report-theme presets, as part=color lists.
+var themePresets = map[string]string{
+	"dark":  "border=cyan,title=bold,ok=green,warn=yellow,error=red",
+	"light": "border=dark-blue,title=bold,ok=dark-green,warn=brown,error=dark-red",
+	"none":  "border=none,title=none,ok=none,warn=none,error=none",
+}
+
+// theme is the theme used by Print.
+var theme = mustTheme("dark")
+
+// SetTheme sets the report colors from a -report-theme value: a preset
+// (dark, light or none), optionally followed by part=color overrides, e.g.
+// "light,warn=magenta" or "border=blue". Parts are border, title, ok, warn
+// and error. On terminals with fewer than 256 colors, and with NO_COLOR
+// set, colors fall back to the 8 standard ones or to none.
+func SetTheme(spec string) error {
+	t, err := parseTheme(spec, colorDepth())
+	if err != nil {
+		return err
+	}
+	theme = t
+	return nil
+}
+
+// mustTheme returns a preset for the default theme.
+func mustTheme(preset string) Theme {
+	t, err := parseTheme(preset, colorDepth())
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// parseTheme builds a Theme from a -report-theme value for a terminal
+// supporting depth colors (0, 8 or 256).
+func parseTheme(spec string, depth int) (Theme, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(spec)), ",")
+	if preset, ok := themePresets[parts[0]]; ok {
+		parts = append(strings.Split(preset, ","), parts[1:]...)
+	} else if !strings.Contains(parts[0], "=") {
+		return Theme{}, fmt.Errorf("unknown theme %q (use dark, light or none)", parts[0])
+	} else {
+		parts = append(strings.Split(themePresets["dark"], ","), parts...)
+	}
+
+	var t Theme
+	fields := map[string]*string{
+		"border": &t.Border, "title": &t.Title, "ok": &t.OK, "warn": &t.Warn, "error": &t.Error,
+	}
+	for _, part := range parts {
+		key, name, ok := strings.Cut(strings.TrimSpace(part), "=")
+		field, known := fields[key]
+		if !ok || !known {
+			return Theme{}, fmt.Errorf("invalid theme part %q (use part=color, where part is border, title, ok, warn or error)", part)
+		}
+		c, known := palette[name]
+		if !known {
+			return Theme{}, fmt.Errorf("unknown color %q in %q", name, part)
+		}
+		switch depth {
+		case 0:
+		case 8:
+			*field = c.basic
+		default:
+			*field = c.full
+		}
+	}
+	if t != (Theme{}) {
+		t.Reset = "\033[0m"
+	}
+	return t, nil
+}
+
+// colorDepth guesses how many colors the terminal supports: 0 with NO_COLOR
+// set, 256 for terminals announcing 256 or true colors, 8 otherwise.
+func colorDepth() int {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return 0
+	}
+	term := os.Getenv("TERM")
+	switch {
+	case os.Getenv("COLORTERM") != "",
+		strings.Contains(term, "256color"),
+		os.Getenv("WT_SESSION") != "": // Windows Terminal
+		return 256
+	case term == "dumb":
+		return 0
+	default:
+		return 8
+	}
+}
@@ -0,0 +1,187 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// StepTimings collects the durations of each step of the per-date cycle.
+type StepTimings struct {
+	order     []string                   // Step names in first-seen order
+	durations map[string][]time.Duration // Recorded durations by step name
+}
+
+// Record adds a duration for the named step.
+func (t *StepTimings) Record(step string, d time.Duration) {
+	if t.durations == nil {
+		t.durations = make(map[string][]time.Duration)
+	}
+	if _, ok := t.durations[step]; !ok {
+		t.order = append(t.order, step)
+	}
+	t.durations[step] = append(t.durations[step], d)
+}
+
+// merge adds all durations recorded in other.
+func (t *StepTimings) merge(other StepTimings) {
+	for _, step := range other.order {
+		for _, d := range other.durations[step] {
+			t.Record(step, d)
+		}
+	}
+}
+
+// Steps returns the names of all recorded steps in first-seen order.
+func (t *StepTimings) Steps() []string {
+	return t.order
+}
+
+// Percentile returns the p-th percentile (0-100) duration of the named step
+// using the nearest-rank method, or 0 if nothing was recorded.
+func (t *StepTimings) Percentile(step string, p float64) time.Duration {
+	return percentile(t.durations[step], p)
+}
+
+// percentile returns the p-th percentile (0-100) of durations using the
+// nearest-rank method, or 0 if durations is empty.
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// Summary returns the sample count and p50/p90/max of the named step.
+func (t *StepTimings) Summary(step string) string {
+	return fmt.Sprintf("n=%d p50=%s p90=%s max=%s",
+		len(t.durations[step]),
+		formatStepDuration(t.Percentile(step, 50)),
+		formatStepDuration(t.Percentile(step, 90)),
+		formatStepDuration(t.Percentile(step, 100)))
+}
+
+// formatStepDuration renders a step duration compactly (e.g. "1.2s", "350ms").
+func formatStepDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+// stepJSON is the JSON form of one step's timings.
+type stepJSON struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50_seconds"`
+	P90   float64 `json:"p90_seconds"`
+	Max   float64 `json:"max_seconds"`
+}
+
+// MarshalJSON renders the timings as the sample count and p50/p90/max of
+// each step, keyed by step name.
+func (t StepTimings) MarshalJSON() ([]byte, error) {
+	steps := make(map[string]stepJSON, len(t.order))
+	for _, step := range t.order {
+		steps[step] = stepJSON{
+			Count: len(t.durations[step]),
+			P50:   t.Percentile(step, 50).Seconds(),
+			P90:   t.Percentile(step, 90).Seconds(),
+			Max:   t.Percentile(step, 100).Seconds(),
+		}
+	}
+	return json.Marshal(steps)
+}
+
+// DateTimings collects how long each downloaded date took, from finding it
+// on the page to moving on to the next one.
+type DateTimings struct {
+	durations []time.Duration
+}
+
+// dateBuckets are the upper bounds of the per-date histogram buckets; the
+// last bucket is open-ended.
+var dateBuckets = []time.Duration{
+	10 * time.Second, 30 * time.Second, time.Minute, 2 * time.Minute, 5 * time.Minute,
+}
+
+// Bucket is one bar of the per-date histogram.
+type Bucket struct {
+	Label string `json:"label"` // Range of the bucket, e.g. "10s-30s"
+	Count int    `json:"count"`
+}
+
+// Record adds the duration of one date.
+func (t *DateTimings) Record(d time.Duration) {
+	t.durations = append(t.durations, d)
+}
+
+// Count returns the number of recorded dates.
+func (t *DateTimings) Count() int {
+	return len(t.durations)
+}
+
+// Percentile returns the p-th percentile (0-100) date duration, or 0 if
+// nothing was recorded. Percentile 0 is the shortest date.
+func (t *DateTimings) Percentile(p float64) time.Duration {
+	return percentile(t.durations, p)
+}
+
+// Histogram returns how many dates fall in each bucket of dateBuckets.
+func (t *DateTimings) Histogram() []Bucket {
+	buckets := make([]Bucket, len(dateBuckets)+1)
+	lower := "0s"
+	for i, upper := range dateBuckets {
+		buckets[i].Label = lower + "-" + bucketBound(upper)
+		lower = bucketBound(upper)
+	}
+	buckets[len(dateBuckets)].Label = lower + "+"
+	for _, d := range t.durations {
+		i := sort.Search(len(dateBuckets), func(i int) bool { return d < dateBuckets[i] })
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// bucketBound formats a histogram bound as whole minutes or seconds.
+func bucketBound(d time.Duration) string {
+	if d%time.Minute == 0 {
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
+
+// merge adds all durations recorded in other.
+func (t *DateTimings) merge(other DateTimings) {
+	t.durations = append(t.durations, other.durations...)
+}
+
+// MarshalJSON renders the date durations as their count, min/median/p95/max
+// and histogram.
+func (t DateTimings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Count     int      `json:"count"`
+		Min       float64  `json:"min_seconds"`
+		P50       float64  `json:"p50_seconds"`
+		P95       float64  `json:"p95_seconds"`
+		Max       float64  `json:"max_seconds"`
+		Histogram []Bucket `json:"histogram"`
+	}{
+		Count:     t.Count(),
+		Min:       t.Percentile(0).Seconds(),
+		P50:       t.Percentile(50).Seconds(),
+		P95:       t.Percentile(95).Seconds(),
+		Max:       t.Percentile(100).Seconds(),
+		Histogram: t.Histogram(),
+	})
+}
@@ -0,0 +1,108 @@
+// Package retry provides a shared retry helper with exponential backoff and jitter.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+)
+
+// Policy controls how an operation is retried.
+type Policy struct {
+	MaxAttempts  int           // Total attempts including the first one
+	InitialDelay time.Duration // Delay before the second attempt
+	MaxDelay     time.Duration // Upper bound for a single delay
+	Multiplier   float64       // Growth factor applied after each failed attempt
+	Jitter       float64       // Random spread applied to each delay (0.2 = ±20%)
+}
+
+// DefaultPolicy returns the policy used for most browser interactions.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:  3,
+		InitialDelay: 1 * time.Second,
+		MaxDelay:     10 * time.Second,
+		Multiplier:   2,
+		Jitter:       0.2,
+	}
+}
+
+// permanentError marks an error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that Do returns it without further attempts.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsRetriable reports whether an error is worth retrying.
+// Errors caused by a closed browser or canceled context, and errors marked
+// with Permanent, are never retried.
+func IsRetriable(err error) bool {
+	var perm *permanentError
+	return err != nil && !browser.IsBrowserClosed(err) && !errors.As(err, &perm)
+}
+
+// Do runs fn until it succeeds, returns a non-retriable error, or the policy's
+// attempts are exhausted. The last error is returned on failure.
+func Do(ctx context.Context, p Policy, name string, fn func(attempt int) error) error {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= p.MaxAttempts; attempt++ {
+		if err = fn(attempt); err == nil {
+			return nil
+		}
+		if !IsRetriable(err) || attempt == p.MaxAttempts {
+			break
+		}
+
+		delay := p.Delay(attempt)
+		log.Printf("%s failed (attempt %d/%d): %v - retrying in %v", name, attempt, p.MaxAttempts, err, delay.Round(time.Millisecond))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	if p.MaxAttempts > 1 && IsRetriable(err) {
+		return fmt.Errorf("%s failed after %d attempts: %w", name, p.MaxAttempts, err)
+	}
+	return err
+}
+
+// Delay returns the backoff delay after the given failed attempt (1-based).
+func (p Policy) Delay(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.InitialDelay)
+	for i := 1; i < attempt; i++ {
+		delay *= multiplier
+	}
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
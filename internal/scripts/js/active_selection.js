@@ -0,0 +1,21 @@
+// Reports whether the page shows an active selection.
+function activeSelection() {
+	// Check if selection bar is visible (file counter)
+	const selectionBar = document.querySelector('[class*="selection"], [class*="toolbar"]');
+	if (selectionBar) {
+		const text = selectionBar.textContent || '';
+		if (/\d+\s*(file|файл|item)/i.test(text)) {
+			return true;
+		}
+	}
+
+	// Check if there are checked checkboxes
+	const checkedInputs = document.querySelectorAll('input[type="checkbox"]:checked');
+	if (checkedInputs.length > 0) return true;
+
+	// Check elements with 'checked' class
+	const checkedElements = document.querySelectorAll('[class*="checkbox"][class*="checked"]');
+	if (checkedElements.length > 0) return true;
+
+	return false;
+}
@@ -0,0 +1,15 @@
+// Clicks the album with the given name in the visible "add to album" picker.
+// Returns whether it was found.
+function albumOption(name) {
+	const popups = document.querySelectorAll('[role="dialog"], [role="menu"], [role="listbox"], [class*="popup"], [class*="Popup"], [class*="modal"], [class*="Modal"]');
+	for (const popup of popups) {
+		if (popup.offsetParent === null) continue;
+		for (const el of popup.querySelectorAll('[role="menuitem"], [role="option"], button, li, [class*="item"]')) {
+			if ((el.textContent || '').trim() === name) {
+				el.click();
+				return true;
+			}
+		}
+	}
+	return false;
+}
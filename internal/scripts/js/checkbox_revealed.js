@@ -0,0 +1,11 @@
+// Reports whether a checkbox is shown next to the date label at targetY.
+function checkboxRevealed(targetY) {
+	const checkboxes = document.querySelectorAll('input[type="checkbox"], [class*="checkbox"], [class*="Checkbox"]');
+	for (const cb of checkboxes) {
+		const rect = cb.getBoundingClientRect();
+		if (rect.width > 0 && Math.abs(rect.top + rect.height/2 - targetY) < 40) {
+			return true;
+		}
+	}
+	return false;
+}
@@ -0,0 +1,28 @@
+// Clicks the unchecked checkbox next to the date label at targetY, falling
+// back to any checkbox-like element under the point (x, y).
+function clickCheckbox(targetY, x, y) {
+	const checkboxes = document.querySelectorAll('input[type="checkbox"], [class*="checkbox"], [class*="Checkbox"]');
+
+	for (const cb of checkboxes) {
+		const rect = cb.getBoundingClientRect();
+		if (Math.abs(rect.top + rect.height/2 - targetY) < 40) {
+			if (!cb.checked && !cb.classList.contains('checked')) {
+				cb.click();
+				return true;
+			}
+		}
+	}
+
+	const elements = document.elementsFromPoint(x, y);
+	for (const el of elements) {
+		if (el.tagName === 'INPUT' ||
+			el.className?.includes('checkbox') ||
+			el.className?.includes('Checkbox') ||
+			el.role === 'checkbox') {
+			el.click();
+			return true;
+		}
+	}
+
+	return false;
+}
@@ -0,0 +1,14 @@
+// Clicks the item of the open filter menu whose text contains one of words
+// (lower case).
+function clickFilterOption(words) {
+	// Find all menu items
+	const menuItems = document.querySelectorAll('.Menu-Item[role="option"]');
+	for (const item of menuItems) {
+		const text = item.textContent.toLowerCase();
+		if (words.some(word => text.includes(word))) {
+			item.click();
+			return true;
+		}
+	}
+	return false;
+}
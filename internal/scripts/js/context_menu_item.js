@@ -0,0 +1,19 @@
+// Clicks the Download entry, named after one of labels, of the visible
+// context menu. Entries marked as a download action by their attributes
+// match too, for interface languages without known labels. Returns whether
+// an entry was clicked.
+function contextMenuItem(labels) {
+	const menus = document.querySelectorAll('[role="menu"], [class*="context-menu"], [class*="ContextMenu"], [class*="popup"], [class*="Popup"]');
+	for (const menu of menus) {
+		if (menu.offsetParent === null) continue;
+		for (const el of menu.querySelectorAll('[role="menuitem"], button, li, [class*="item"]')) {
+			const text = (el.textContent || '').trim();
+			const attrs = [el.getAttribute('data-action'), el.getAttribute('data-id'), el.className].join(' ').toLowerCase();
+			if (labels.some(label => text === label) || /(^|[^a-z])download([^a-z]|$)/.test(attrs)) {
+				el.click();
+				return true;
+			}
+		}
+	}
+	return false;
+}
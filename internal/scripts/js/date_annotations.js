@@ -0,0 +1,63 @@
+// Returns the titles and descriptions Yandex shows for the photos below the
+// date label at targetY, up to the next date label, as
+// [{name, title, description}]. The file name comes from the preview's alt
+// text; the title and description from the item's tooltip (title
+// attribute), accessible name and description, and caption elements.
+function dateAnnotations(targetY, months) {
+	const datePattern = new RegExp('^\\d{1,2}\\s+(' + months.join('|') + ')(\\s+\\d{4})?$', 'i');
+	let nextY = Infinity;
+	document.querySelectorAll('*').forEach(el => {
+		if (el.children.length === 0 && datePattern.test(el.textContent?.trim() || '')) {
+			const top = el.getBoundingClientRect().top;
+			if (top > targetY + 20 && top < nextY) {
+				nextY = top;
+			}
+		}
+	});
+
+	const clean = text => (text || '').replace(/\s+/g, ' ').trim();
+	const seen = new Set();
+	const items = [];
+	for (const img of document.querySelectorAll('img')) {
+		const rect = img.getBoundingClientRect();
+		if (rect.top < targetY || rect.top >= nextY || rect.width < 40) {
+			continue;
+		}
+		const name = clean(img.alt);
+		if (!name || seen.has(name)) {
+			continue;
+		}
+		seen.add(name);
+
+		// The grid item is the closest ancestor still about the size of the preview
+		let item = img;
+		while (item.parentElement && item.parentElement.getBoundingClientRect().width <= rect.width * 1.5) {
+			item = item.parentElement;
+		}
+
+		const texts = [];
+		const add = text => {
+			text = clean(text);
+			if (text && text !== name && !texts.includes(text)) {
+				texts.push(text);
+			}
+		};
+		for (const el of [item, ...item.querySelectorAll('[title], [aria-label]')]) {
+			add(el.getAttribute('title'));
+			add(el.getAttribute('aria-label'));
+		}
+		let description = clean(item.getAttribute('aria-description'));
+		for (const id of (item.getAttribute('aria-describedby') || '').split(/\s+/)) {
+			description = description || clean(id && document.getElementById(id)?.textContent);
+		}
+		for (const el of item.querySelectorAll('[class*="caption"], [class*="Caption"], [class*="description"], [class*="Description"]')) {
+			description = description || clean(el.textContent);
+		}
+
+		const title = texts.find(t => t !== description) || '';
+		if (title || description) {
+			items.push({name, title, description});
+		}
+	}
+	return items;
+}
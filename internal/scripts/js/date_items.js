@@ -0,0 +1,71 @@
+// Returns the photos and videos shown below the date label at targetY, up
+// to the next date label, as {items: [{name, video, size, unlimited}],
+// nextY}. The file name comes from the preview's alt text; size is the text
+// of a file size found in the item's tooltip (title attribute) or
+// accessible name, or '' when Yandex doesn't show one. unlimited is set
+// when the item carries an unlimited storage badge: a class mentioning it,
+// or a tooltip or accessible name containing one of unlimitedWords (lower
+// case). nextY is the position of the next date label, or null while it is
+// below the screen. targetY is negative once the label was scrolled off the
+// top while going through a large date.
+function dateItems(targetY, months, unlimitedWords) {
+	const datePattern = new RegExp('^\\d{1,2}\\s+(' + months.join('|') + ')(\\s+\\d{4})?$', 'i');
+	// The sticky header repeats the date scrolled under it; it is not the next one
+	const inStickyHeader = el => {
+		for (let node = el; node && node !== document.body; node = node.parentElement) {
+			const style = getComputedStyle(node);
+			if (style.position === 'fixed' || style.position === 'sticky') {
+				return true;
+			}
+		}
+		return false;
+	};
+	let nextY = Infinity;
+	document.querySelectorAll('*').forEach(el => {
+		if (el.children.length === 0 && datePattern.test(el.textContent?.trim() || '') && !inStickyHeader(el)) {
+			const top = el.getBoundingClientRect().top;
+			if (top > targetY + 20 && top < nextY) {
+				nextY = top;
+			}
+		}
+	});
+
+	const sizePattern = /(\d+(?:[.,]\d+)?)\s*(TB|GB|MB|KB|B|ТБ|ГБ|МБ|КБ|Б)(?![a-zа-я])/i;
+	const durationPattern = /^\d{1,2}:\d{2}(:\d{2})?$/;
+	const seen = new Set();
+	const items = [];
+	for (const img of document.querySelectorAll('img')) {
+		const rect = img.getBoundingClientRect();
+		if (rect.top < targetY || rect.top >= nextY || rect.width < 40) {
+			continue;
+		}
+		const name = (img.alt || '').trim();
+		if (!name || seen.has(name)) {
+			continue;
+		}
+		seen.add(name);
+
+		// The grid item is the closest ancestor still about the size of the preview
+		let item = img;
+		while (item.parentElement && item.parentElement.getBoundingClientRect().width <= rect.width * 1.5) {
+			item = item.parentElement;
+		}
+
+		let size = '';
+		for (const el of [item, ...item.querySelectorAll('[title], [aria-label]')]) {
+			const match = (el.getAttribute('title') || '').match(sizePattern) || (el.getAttribute('aria-label') || '').match(sizePattern);
+			if (match) {
+				size = match[0];
+				break;
+			}
+		}
+		// Videos carry a duration badge
+		const video = [...item.querySelectorAll('*')].some(el => el.children.length === 0 && durationPattern.test(el.textContent?.trim() || ''));
+		const unlimited = [item, ...item.querySelectorAll('*')].some(el => {
+			const label = ((el.getAttribute('title') || '') + ' ' + (el.getAttribute('aria-label') || '')).toLowerCase();
+			return /unlim/i.test(el.getAttribute('class') || '') || (unlimitedWords || []).some(word => label.includes(word));
+		});
+		items.push({name, video, size, unlimited});
+	}
+	return {items, nextY: nextY < window.innerHeight ? nextY : null};
+}
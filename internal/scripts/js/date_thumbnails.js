@@ -0,0 +1,30 @@
+// Returns the preview image URLs of the photos below the date label at
+// targetY, up to the next date label (a day followed by one of months), as
+// [{src, name}].
+function dateThumbnails(targetY, months) {
+	const datePattern = new RegExp('^\\d{1,2}\\s+(' + months.join('|') + ')(\\s+\\d{4})?$', 'i');
+	let nextY = Infinity;
+	document.querySelectorAll('*').forEach(el => {
+		if (el.children.length === 0 && datePattern.test(el.textContent?.trim() || '')) {
+			const top = el.getBoundingClientRect().top;
+			if (top > targetY + 20 && top < nextY) {
+				nextY = top;
+			}
+		}
+	});
+
+	const seen = new Set();
+	const thumbs = [];
+	for (const img of document.querySelectorAll('img')) {
+		const rect = img.getBoundingClientRect();
+		if (rect.top < targetY || rect.top >= nextY || rect.width < 40 || !img.src.startsWith('http')) {
+			continue;
+		}
+		if (seen.has(img.src)) {
+			continue;
+		}
+		seen.add(img.src);
+		thumbs.push({src: img.src, name: img.alt || ''});
+	}
+	return thumbs;
+}
@@ -0,0 +1,24 @@
+// Reports whether logged-in Yandex Disk elements have rendered.
+function diskElements() {
+	// Check for Yandex Disk logged-in elements
+	const diskIndicators = [
+		// Photo section elements
+		!!document.querySelector('[class*="photo"]'),
+		!!document.querySelector('[class*="Photo"]'),
+		!!document.querySelector('[class*="listing"]'),
+		!!document.querySelector('[class*="Listing"]'),
+
+		// User avatar or account elements
+		!!document.querySelector('[class*="user"]'),
+		!!document.querySelector('[class*="User"]'),
+		!!document.querySelector('[class*="avatar"]'),
+		!!document.querySelector('[class*="Avatar"]'),
+
+		// Disk navigation elements
+		!!document.querySelector('[class*="sidebar"]'),
+		!!document.querySelector('[class*="Sidebar"]'),
+		!!document.querySelector('[href*="/client/"]'),
+	];
+
+	return diskIndicators.filter(i => i === true).length >= 2;
+}
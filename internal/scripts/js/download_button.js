@@ -0,0 +1,19 @@
+// Finds the Download button of the selection toolbar, named after one of
+// labels. With action "click" the button is also clicked. Returns whether the
+// button was found.
+function downloadButton(action, labels) {
+	const buttons = document.querySelectorAll('button, [role="button"]');
+	for (const btn of buttons) {
+		const text = btn.textContent?.trim() || '';
+		const ariaLabel = btn.getAttribute('aria-label') || '';
+		const title = btn.getAttribute('title') || '';
+
+		if (labels.some(label => text === label || ariaLabel.includes(label) || title.includes(label))) {
+			if (action === 'click') {
+				btn.click();
+			}
+			return true;
+		}
+	}
+	return false;
+}
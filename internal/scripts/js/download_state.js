@@ -0,0 +1,30 @@
+// Returns a summary of the download feedback on the page: the visible
+// notifications and progress bars, and the state of the Download button
+// named after one of labels. The summary changes when a click on Download
+// took effect.
+function downloadState(labels) {
+	const parts = [];
+	const toasts = document.querySelectorAll('[role="alert"], [role="status"], [role="progressbar"], [class*="notification"], [class*="Notification"], [class*="toast"], [class*="Toast"], [class*="snackbar"]');
+	for (const el of toasts) {
+		if (el.offsetParent === null) continue;
+		parts.push('toast:' + (el.textContent || '').trim().substring(0, 100));
+	}
+
+	let button = 'gone';
+	for (const btn of document.querySelectorAll('button, [role="button"]')) {
+		const text = btn.textContent?.trim() || '';
+		const ariaLabel = btn.getAttribute('aria-label') || '';
+		const title = btn.getAttribute('title') || '';
+		if (!labels.some(label => text === label || ariaLabel.includes(label) || title.includes(label))) {
+			continue;
+		}
+		const busy = btn.disabled ||
+			btn.getAttribute('aria-disabled') === 'true' ||
+			btn.getAttribute('aria-busy') === 'true' ||
+			btn.querySelector('[role="progressbar"], [class*="spin"], [class*="Spin"]') !== null;
+		button = busy ? 'busy' : 'ready';
+		break;
+	}
+	parts.push('button:' + button);
+	return parts.join('\n');
+}
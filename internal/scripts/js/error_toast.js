@@ -0,0 +1,20 @@
+// Returns {kind, text} for a visible Yandex download error notification, or
+// null if there is none.
+function errorToast() {
+	const patterns = [
+		['quota', /download limit|limit (is |has been )?(exceeded|reached)|quota|лимит скачивани|превышен лимит|квот/i],
+		['rate-limit', /too many requests|try again later|слишком много запросов|попробуйте позже/i],
+		['failed', /download failed|failed to download|couldn't download|something went wrong|не удалось скачать|ошибка скачивания|что-то пошло не так/i],
+	];
+	const toasts = document.querySelectorAll('[role="alert"], [role="status"], [class*="notification"], [class*="Notification"], [class*="toast"], [class*="Toast"], [class*="snackbar"]');
+	for (const el of toasts) {
+		if (el.offsetParent === null) continue;
+		const text = (el.textContent || '').trim();
+		for (const [kind, pattern] of patterns) {
+			if (pattern.test(text)) {
+				return {kind: kind, text: text.substring(0, 200)};
+			}
+		}
+	}
+	return null;
+}
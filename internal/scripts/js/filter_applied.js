@@ -0,0 +1,7 @@
+// Reports whether the filter button shows an option containing one of words
+// (lower case).
+function filterApplied(words) {
+	const btn = document.querySelector('button.Select2-Button[aria-label^="Show:"], button[role="listbox"].Select2-Button');
+	const label = ((btn && (btn.getAttribute('aria-label') || btn.textContent)) || '').toLowerCase();
+	return words.some(word => label.includes(word));
+}
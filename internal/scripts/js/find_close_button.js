@@ -0,0 +1,57 @@
+// Finds the X (close/deselect) button of the selection toolbar, unnamed or
+// named with one of labels (lower case), and returns its center as
+// {x, y, found, info}.
+function findCloseButton(labels) {
+	// Look for X or Deselect button in top bar
+	const selectors = [
+		'button[aria-label*="close" i]',
+		'button[aria-label*="Close" i]',
+		'button[aria-label*="deselect" i]',
+		'[class*="close"]',
+		'[class*="Close"]',
+		'svg[class*="close"]',
+		'button:has(svg)',
+	];
+
+	for (const selector of selectors) {
+		const elements = document.querySelectorAll(selector);
+		for (const el of elements) {
+			const rect = el.getBoundingClientRect();
+			// X button should be at the top of the screen (toolbar)
+			if (rect.top < 150 && rect.width > 0 && rect.height > 0) {
+				const text = el.textContent?.trim() || '';
+				const ariaLabel = el.getAttribute('aria-label') || '';
+				// Check if it looks like a close/deselect button
+				if (text === '×' || text === 'X' || text === '' ||
+					labels.some(label => ariaLabel.toLowerCase().includes(label))) {
+					return {
+						x: rect.left + rect.width/2,
+						y: rect.top + rect.height/2,
+						found: true,
+						info: ariaLabel || text || 'button'
+					};
+				}
+			}
+		}
+	}
+
+	// Look for any button in top bar that could be the X
+	const allButtons = document.querySelectorAll('button, [role="button"]');
+	for (const btn of allButtons) {
+		const rect = btn.getBoundingClientRect();
+		// Button in top right corner (selection area)
+		if (rect.top < 100 && rect.right > window.innerWidth - 200) {
+			const text = btn.textContent?.trim() || '';
+			if (text === '×' || text === 'X' || text.length <= 2) {
+				return {
+					x: rect.left + rect.width/2,
+					y: rect.top + rect.height/2,
+					found: true,
+					info: 'corner-button'
+				};
+			}
+		}
+	}
+
+	return { found: false };
+}
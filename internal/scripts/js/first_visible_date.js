@@ -0,0 +1,53 @@
+// Returns the topmost date label visible on screen as {text, x, y}, or null.
+// Date labels are a day followed by one of months. The sticky header that
+// repeats the month or date of the group scrolled under it, and labels it
+// covers, are ignored: hovering them would hit the wrong group.
+function firstVisibleDate(months) {
+	const datePattern = new RegExp('^\\d{1,2}\\s+(' + months.join('|') + ')$', 'i');
+
+	// A fixed element, or a sticky one currently stuck at its offset
+	const inStickyHeader = el => {
+		for (let node = el; node && node !== document.body; node = node.parentElement) {
+			const style = getComputedStyle(node);
+			if (style.position === 'fixed') {
+				return true;
+			}
+			if (style.position === 'sticky') {
+				const offset = parseFloat(style.top);
+				if (!isNaN(offset) && Math.abs(node.getBoundingClientRect().top - offset) < 2) {
+					return true;
+				}
+			}
+		}
+		return false;
+	};
+	// Another element (the header) is drawn over the middle of the label
+	const isCovered = (el, rect) => {
+		const hit = document.elementFromPoint(rect.left + rect.width / 2, rect.top + rect.height / 2);
+		return hit !== null && !el.contains(hit) && !hit.contains(el);
+	};
+
+	const allElements = document.querySelectorAll('*');
+	const dates = [];
+
+	allElements.forEach(el => {
+		const text = el.textContent?.trim() || '';
+		// Detect date pattern
+		if (datePattern.test(text)) {
+			const rect = el.getBoundingClientRect();
+			// Only include if visible on screen
+			if (rect.top >= 80 && rect.top < window.innerHeight - 50 && rect.width > 0 &&
+				!inStickyHeader(el) && !isCovered(el, rect)) {
+				dates.push({
+					text: text,
+					x: rect.left,
+					y: rect.top + (rect.height / 2)
+				});
+			}
+		}
+	});
+
+	// Sort by Y and return the first one
+	dates.sort((a, b) => a.y - b.y);
+	return dates.length > 0 ? dates[0] : null;
+}
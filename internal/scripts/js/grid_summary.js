@@ -0,0 +1,27 @@
+// Summarizes the timeline on screen as {first, dates, photos}: the text of
+// the topmost date label (a day followed by one of months, with an
+// optional year), the number of date labels and the number of photo
+// previews visible. Comparing two summaries tells whether the grid changed.
+function gridSummary(months) {
+	const datePattern = new RegExp('^\\d{1,2}\\s+(' + months.join('|') + ')(\\s+\\d{4})?$', 'i');
+	const onScreen = rect => rect.bottom > 0 && rect.top < window.innerHeight && rect.width > 0;
+
+	let first = '', firstTop = Infinity, dates = 0;
+	for (const el of document.querySelectorAll('*')) {
+		if (el.children.length !== 0 || !datePattern.test(el.textContent?.trim() || '')) continue;
+		const rect = el.getBoundingClientRect();
+		if (!onScreen(rect)) continue;
+		dates++;
+		if (rect.top < firstTop) {
+			first = el.textContent.trim();
+			firstTop = rect.top;
+		}
+	}
+
+	let photos = 0;
+	for (const img of document.querySelectorAll('img')) {
+		const rect = img.getBoundingClientRect();
+		if (onScreen(rect) && rect.width >= 40 && img.src.startsWith('http')) photos++;
+	}
+	return {first, dates, photos};
+}
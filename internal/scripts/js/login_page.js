@@ -0,0 +1,34 @@
+// Reports whether the current page is the Yandex ID login page.
+function loginPage() {
+	// Check for Yandex ID login page elements
+	const pageText = document.body?.innerText || '';
+
+	// Login page indicators
+	const loginIndicators = [
+		// Text content checks
+		pageText.includes('Log in with Yandex ID'),
+		pageText.includes('Войти с Яндекс ID'),
+		pageText.includes('Yandex ID'),
+		pageText.includes('Username or email'),
+		pageText.includes('Логин или email'),
+		pageText.includes('Create ID'),
+		pageText.includes('Создать ID'),
+		pageText.includes('Face or fingerprint login'),
+
+		// Element checks
+		!!document.querySelector('input[name="login"]'),
+		!!document.querySelector('input[placeholder*="Username"]'),
+		!!document.querySelector('input[placeholder*="email"]'),
+		!!document.querySelector('button[data-t="button:pseudo"]'),
+		!!document.querySelector('[class*="AuthLoginInputToggle"]'),
+		!!document.querySelector('[class*="Passport"]'),
+		!!document.querySelector('[data-t="login"]'),
+
+		// Login form check
+		!!document.querySelector('form[action*="passport"]'),
+		!!document.querySelector('form[action*="auth"]'),
+	];
+
+	// If any login indicator is found, user is on login page
+	return loginIndicators.some(indicator => indicator === true);
+}
@@ -0,0 +1,5 @@
+// Reports whether any item still looks selected.
+function remainingSelection() {
+	const checked = document.querySelectorAll('[class*="checkbox"][class*="checked"], [class*="selected"]');
+	return checked.length > 0;
+}
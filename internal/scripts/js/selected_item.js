@@ -0,0 +1,17 @@
+// Returns the viewport coordinates of the center of a visible selected
+// photo of the grid, or null if none is on screen. The date's own checkbox
+// is skipped, since only photos open the item context menu.
+function selectedItem() {
+	const marks = document.querySelectorAll('input[type="checkbox"]:checked, [role="checkbox"][aria-checked="true"], [aria-selected="true"], [class*="checked"], [class*="selected"]');
+	for (const mark of marks) {
+		let el = mark;
+		for (let i = 0; i < 6 && el; i++, el = el.parentElement) {
+			const img = el.querySelector('img');
+			if (!img) continue;
+			const r = img.getBoundingClientRect();
+			if (r.width < 20 || r.height < 20 || r.bottom <= 0 || r.top >= window.innerHeight) break;
+			return {x: r.left + r.width / 2, y: r.top + r.height / 2};
+		}
+	}
+	return null;
+}
@@ -0,0 +1,55 @@
+// Compares the selected photos on screen with the date label at targetY, as
+// {inside, outside, total, complete}: selected photos of the date, selected
+// photos of other dates, photos of the date on screen, and whether the
+// whole date is on screen (its label and the next date label are visible).
+// A checkbox counts for the photo it belongs to; a date's own checkbox,
+// whose container holds several photos, is ignored.
+function selectionBleed(targetY, months) {
+	const datePattern = new RegExp('^\\d{1,2}\\s+(' + months.join('|') + ')(\\s+\\d{4})?$', 'i');
+	// The sticky header repeats the date scrolled under it; it is not the next one
+	const inStickyHeader = el => {
+		for (let node = el; node && node !== document.body; node = node.parentElement) {
+			const style = getComputedStyle(node);
+			if (style.position === 'fixed' || style.position === 'sticky') {
+				return true;
+			}
+		}
+		return false;
+	};
+	let nextY = Infinity;
+	document.querySelectorAll('*').forEach(el => {
+		if (el.children.length === 0 && datePattern.test(el.textContent?.trim() || '') && !inStickyHeader(el)) {
+			const top = el.getBoundingClientRect().top;
+			if (top > targetY + 20 && top < nextY) {
+				nextY = top;
+			}
+		}
+	});
+
+	// Whole class names only: "unchecked" or "selectable" mark nothing
+	const selected = new Set();
+	const marks = document.querySelectorAll('input[type="checkbox"]:checked, [role="checkbox"][aria-checked="true"], [aria-selected="true"], .checked, .selected');
+	for (const mark of marks) {
+		let el = mark;
+		for (let i = 0; i < 6 && el; i++, el = el.parentElement) {
+			const imgs = el.querySelectorAll('img');
+			if (imgs.length === 1) selected.add(imgs[0]);
+			if (imgs.length > 0) break;
+		}
+	}
+
+	let inside = 0, outside = 0, total = 0;
+	for (const img of document.querySelectorAll('img')) {
+		const rect = img.getBoundingClientRect();
+		if (rect.width < 40 || rect.bottom <= 0 || rect.top >= window.innerHeight || inStickyHeader(img)) {
+			continue;
+		}
+		const inDate = rect.top >= targetY && rect.top < nextY;
+		if (inDate) total++;
+		if (selected.has(img)) {
+			if (inDate) inside++;
+			else outside++;
+		}
+	}
+	return {inside, outside, total, complete: targetY >= 0 && nextY < window.innerHeight};
+}
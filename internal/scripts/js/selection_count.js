@@ -0,0 +1,13 @@
+// Returns the number of items the selection toolbar reports as selected, or
+// 0 if the counter is not shown.
+function selectionCount() {
+	const bars = document.querySelectorAll('[class*="selection"], [class*="toolbar"]');
+	for (const bar of bars) {
+		const text = bar.textContent || '';
+		const match = text.match(/(\d[\d\s ]*)\s*(files?|items?|photos?|файл|фото|объект)/i);
+		if (match) {
+			return parseInt(match[1].replace(/[\s ]/g, ''), 10);
+		}
+	}
+	return 0;
+}
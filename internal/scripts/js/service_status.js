@@ -0,0 +1,21 @@
+// Returns a short description if the current page is a Yandex maintenance or
+// server error page, or an empty string otherwise. Text checks only apply to
+// short pages so photo titles can't trigger them.
+function serviceStatus() {
+	const nav = performance.getEntriesByType('navigation')[0];
+	const status = (nav && nav.responseStatus) || 0;
+	if (status >= 500) {
+		return 'HTTP ' + status;
+	}
+	const text = (document.body && document.body.innerText) || '';
+	if (text.length > 2000) {
+		return '';
+	}
+	if (/technical work|under maintenance|maintenance|технические работы|ведутся работы/i.test(text)) {
+		return 'maintenance page';
+	}
+	if (/service (is )?(temporarily )?unavailable|сервис (временно )?недоступен|internal server error|bad gateway|gateway time-?out/i.test(text)) {
+		return 'server error page';
+	}
+	return '';
+}
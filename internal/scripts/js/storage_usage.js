@@ -0,0 +1,17 @@
+// Returns the text of the storage indicator in the sidebar, such as
+// "7.9 GB of 10 GB" or "Свободно 2,1 ГБ из 10 ГБ": the shortest visible
+// element whose text holds two sizes. Returns '' if there is none.
+function storageUsage() {
+	const size = /\d[\d\s.,]*\s*(?:[KMGT]?B|[КМГТ]?Б)(?![\p{L}])/gu;
+	let best = '';
+	const candidates = 'aside, aside *, nav, nav *, [class*="space"], [class*="Space"], [class*="quota"], [class*="Quota"], [class*="storage"], [class*="Storage"]';
+	for (const el of document.querySelectorAll(candidates)) {
+		if (el.offsetParent === null || (el.textContent || '').length > 200) continue;
+		const text = (el.innerText || '').replace(/\s+/g, ' ').trim();
+		if (text.length > 100 || (best && text.length >= best.length)) continue;
+		if ((text.match(size) || []).length === 2) {
+			best = text;
+		}
+	}
+	return best;
+}
@@ -0,0 +1,18 @@
+// Clicks the confirm button of a visible "delete" dialog: the first whose
+// text starts with one of labels, ignoring case. Returns whether a button was
+// clicked.
+function trashConfirm(labels) {
+	const prefixes = labels.map(l => l.toLowerCase());
+	const dialogs = document.querySelectorAll('[role="dialog"], [role="alertdialog"], [class*="modal"], [class*="Modal"], [class*="dialog"], [class*="Dialog"]');
+	for (const dialog of dialogs) {
+		if (dialog.offsetParent === null) continue;
+		for (const btn of dialog.querySelectorAll('button, [role="button"]')) {
+			const text = (btn.textContent || '').trim().toLowerCase();
+			if (prefixes.some(p => text.startsWith(p))) {
+				btn.click();
+				return true;
+			}
+		}
+	}
+	return false;
+}
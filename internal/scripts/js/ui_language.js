@@ -0,0 +1,16 @@
+// Returns the language of the Yandex Disk interface: the primary subtag of
+// the page's lang attribute, or the first of codes whose markers (by code)
+// appear in the page text. Returns '' when neither tells.
+function uiLanguage(codes, markers) {
+	const lang = (document.documentElement.getAttribute('lang') || '').toLowerCase().split('-')[0];
+	if (lang) {
+		return lang;
+	}
+	const text = document.body ? document.body.innerText : '';
+	for (const code of codes) {
+		if ((markers[code] || []).some(marker => text.includes(marker))) {
+			return code;
+		}
+	}
+	return '';
+}
@@ -0,0 +1,95 @@
+// Package scripts provides the JavaScript snippets evaluated in the Yandex Disk
+// page. Each script is a function expression in js/<name>.js, embedded into the
+// binary and invoked with JSON-encoded arguments. Scripts can be overridden
+// without rebuilding by placing a file with the same name in an override
+// directory, e.g. to adapt to a new Yandex UI version.
+package scripts
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+//go:embed js/*.js
+var embedded embed.FS
+
+var (
+	mu          sync.Mutex
+	overrideDir string            // Directory whose scripts take precedence (empty disables)
+	cache       map[string]string // Loaded script sources by name
+)
+
+// SetOverrideDir makes scripts in dir take precedence over the embedded ones.
+func SetOverrideDir(dir string) {
+	mu.Lock()
+	defer mu.Unlock()
+	overrideDir = dir
+	cache = nil
+}
+
+// Source returns the source of the named script (without the .js extension).
+// It panics if the script is not embedded, which is a programming error.
+func Source(name string) string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if src, ok := cache[name]; ok {
+		return src
+	}
+	if cache == nil {
+		cache = make(map[string]string)
+	}
+
+	src := load(name)
+	cache[name] = src
+	return src
+}
+
+// Call returns a JavaScript expression that invokes the named script with the
+// given arguments, encoded as JSON.
+func Call(name string, args ...any) string {
+	encoded := make([]string, len(args))
+	for i, arg := range args {
+		b, err := json.Marshal(arg)
+		if err != nil {
+			panic(fmt.Sprintf("scripts: cannot encode argument %d for %s: %v", i, name, err))
+		}
+		encoded[i] = string(b)
+	}
+	return fmt.Sprintf("(%s)(%s)", Source(name), strings.Join(encoded, ", "))
+}
+
+// Names returns the names of all embedded scripts.
+func Names() []string {
+	entries, _ := embedded.ReadDir("js")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".js"))
+	}
+	return names
+}
+
+// load reads a script from the override directory or the embedded files.
+func load(name string) string {
+	if overrideDir != "" {
+		path := filepath.Join(overrideDir, name+".js")
+		if b, err := os.ReadFile(path); err == nil {
+			log.Printf("Using script override: %s", path)
+			return strings.TrimSpace(string(b))
+		} else if !os.IsNotExist(err) {
+			log.Printf("Warning: could not read script override %s: %v", path, err)
+		}
+	}
+
+	b, err := embedded.ReadFile("js/" + name + ".js")
+	if err != nil {
+		panic(fmt.Sprintf("scripts: unknown script %q", name))
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,107 @@
+package selection
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/locale"
+)
+
+const (
+	// maxCheckboxScan bounds how many checkboxes are inspected when looking
+	// for the one next to a date, since every photo also has a checkbox.
+	maxCheckboxScan = 60
+	// checkboxDistance is the maximum vertical distance between a date label
+	// and its checkbox, in pixels.
+	checkboxDistance = 40
+	// toolbarBottom is the lowest position of the selection toolbar, in pixels.
+	toolbarBottom = 150
+)
+
+// elementStateFn reports an element's vertical center, width and checked state.
+const elementStateFn = `function() {
+	const rect = this.getBoundingClientRect();
+	return {
+		top: rect.top,
+		y: rect.top + rect.height / 2,
+		width: rect.width,
+		checked: !!this.checked || this.getAttribute('aria-checked') === 'true'
+	};
+}`
+
+// isCloseName matches the accessible name of the selection toolbar's close button.
+func isCloseName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, label := range locale.Current().Close {
+		if strings.Contains(lower, label) {
+			return true
+		}
+	}
+	return false
+}
+
+// elementState is the result of elementStateFn.
+type elementState struct {
+	Top     float64 `json:"top"`
+	Y       float64 `json:"y"`
+	Width   float64 `json:"width"`
+	Checked bool    `json:"checked"`
+}
+
+// clickDateCheckbox clicks the unchecked checkbox next to the date label at y,
+// located through the accessibility tree. Checkboxes named after the date are
+// tried first. It reports whether a checkbox was clicked.
+func clickDateCheckbox(ctx context.Context, dateText string, y float64) (bool, error) {
+	named, err := browser.FindByRole(ctx, "checkbox", func(name string) bool {
+		return strings.Contains(name, dateText)
+	})
+	if err != nil {
+		return false, err
+	}
+	all, err := browser.FindByRole(ctx, "checkbox", nil)
+	if err != nil {
+		return false, err
+	}
+	if len(all) > maxCheckboxScan {
+		all = all[:maxCheckboxScan]
+	}
+
+	for _, el := range append(named, all...) {
+		var st elementState
+		if err := browser.CallOn(ctx, el, elementStateFn, &st); err != nil {
+			if browser.IsBrowserClosed(err) {
+				return false, err
+			}
+			continue
+		}
+		if st.Width > 0 && !st.Checked && math.Abs(st.Y-y) < checkboxDistance {
+			return true, browser.ClickElement(ctx, el)
+		}
+	}
+	return false, nil
+}
+
+// clickCloseButton clicks the selection toolbar's close button, located
+// through the accessibility tree. It returns the button's accessible name and
+// whether a button was clicked.
+func clickCloseButton(ctx context.Context) (string, bool, error) {
+	buttons, err := browser.FindByRole(ctx, "button", isCloseName)
+	if err != nil {
+		return "", false, err
+	}
+	for _, el := range buttons {
+		var st elementState
+		if err := browser.CallOn(ctx, el, elementStateFn, &st); err != nil {
+			if browser.IsBrowserClosed(err) {
+				return "", false, err
+			}
+			continue
+		}
+		if st.Width > 0 && st.Top < toolbarBottom {
+			return el.Name, true, browser.ClickElement(ctx, el)
+		}
+	}
+	return "", false, nil
+}
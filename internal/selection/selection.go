@@ -3,52 +3,74 @@ package selection
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/locale"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
 	"github.com/chromedp/chromedp"
 )
 
+// ErrSelectionActive is returned when a selection is still present after deselecting.
+var ErrSelectionActive = errors.New("selection still active")
+
+const (
+	// checkboxTimeout is how long to wait for the date checkbox to appear after hovering.
+	checkboxTimeout = 3 * time.Second
+	// selectionTimeout is how long to wait for a selection or deselection to register.
+	selectionTimeout = 2 * time.Second
+)
+
 // DateInfo contains information about a selected date.
 type DateInfo struct {
 	Text      string
+	XPosition float64
 	YPosition float64
+	Items     int // Photos and videos in the date, read once it is selected (0 if unknown)
 }
 
+// ErrNotSelected is returned when clicking a date did not select it.
+var ErrNotSelected = errors.New("date could not be selected")
+
+// ErrSelectionBleed is returned when selecting a date also selected photos
+// of other dates.
+var ErrSelectionBleed = errors.New("selection spilled into other dates")
+
 // SelectFirstVisibleDate selects the FIRST visible date on screen.
 // Returns the date info if selected, nil if no date found.
 func SelectFirstVisibleDate(ctx context.Context) (*DateInfo, error) {
-	// Get the first visible date
+	dateInfo, err := FindFirstVisibleDate(ctx)
+	if err != nil || dateInfo == nil {
+		return nil, err
+	}
+	if err := SelectDate(ctx, dateInfo); err != nil {
+		if errors.Is(err, ErrNotSelected) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return dateInfo, nil
+}
+
+// FindFirstVisibleDate returns the FIRST visible date on screen without
+// selecting it, or nil if no date is visible. The sticky header at the top
+// of the grid, and date labels hidden under it, do not count as visible.
+func FindFirstVisibleDate(ctx context.Context) (*DateInfo, error) {
+	dateInfo, err := PeekFirstVisibleDate(ctx)
+	if dateInfo != nil {
+		log.Printf("Processing FIRST visible date: %s (y=%.0f)", dateInfo.Text, dateInfo.YPosition)
+	}
+	return dateInfo, err
+}
+
+// PeekFirstVisibleDate is FindFirstVisibleDate without the log line, for
+// sampling the timeline while fast-forwarding through it.
+func PeekFirstVisibleDate(ctx context.Context) (*DateInfo, error) {
 	var dateInfo map[string]interface{}
-	err := chromedp.Run(ctx,
-		chromedp.Evaluate(`
-			(function() {
-				const allElements = document.querySelectorAll('*');
-				const dates = [];
-				
-				allElements.forEach(el => {
-					const text = el.textContent?.trim() || '';
-					// Detect date pattern
-					if (/^\d{1,2}\s+(January|February|March|April|May|June|July|August|September|October|November|December)$/i.test(text)) {
-						const rect = el.getBoundingClientRect();
-						// Only include if visible on screen
-						if (rect.top >= 80 && rect.top < window.innerHeight - 50 && rect.width > 0) {
-							dates.push({
-								text: text,
-								x: rect.left,
-								y: rect.top + (rect.height / 2)
-							});
-						}
-					}
-				});
-				
-				// Sort by Y and return the first one
-				dates.sort((a, b) => a.y - b.y);
-				return dates.length > 0 ? dates[0] : null;
-			})()
-		`, &dateInfo),
-	)
+	err := browser.Evaluate(ctx, scripts.Call("first_visible_date", locale.Current().Months), &dateInfo)
 
 	if err != nil {
 		return nil, fmt.Errorf("error fetching dates: %w", err)
@@ -62,176 +84,148 @@ func SelectFirstVisibleDate(ctx context.Context) (*DateInfo, error) {
 	y, _ := dateInfo["y"].(float64)
 	text, _ := dateInfo["text"].(string)
 
-	log.Printf("Processing FIRST visible date: %s (y=%.0f)", text, y)
+	return &DateInfo{Text: text, XPosition: x, YPosition: y}, nil
+}
+
+// SelectDate hovers next to the date label to reveal its checkbox and clicks it.
+// In keyboard mode (see browser.WithKeyboard), the checkbox is first toggled
+// from the keyboard without hovering, if the page lets it take the focus.
+func SelectDate(ctx context.Context, date *DateInfo) error {
+	text, y := date.Text, date.YPosition
+
+	if browser.Keyboard(ctx) {
+		clicked, err := clickDateCheckbox(ctx, text, y)
+		if err != nil && browser.IsBrowserClosed(err) {
+			return err
+		}
+		if clicked && err == nil {
+			log.Printf("✓ Date '%s' selected (keyboard)", text)
+			browser.WaitReact(ctx, browser.ReactSelection, scripts.Call("active_selection"), selectionTimeout)
+			return nil
+		}
+		log.Printf("Checkbox of '%s' not reachable from the keyboard, hovering...", text)
+	}
 
 	// Hover on left side to reveal checkbox
-	hoverX := x - 30
+	hoverX := date.XPosition - 30
 	if hoverX < 10 {
 		hoverX = 10
 	}
 
-	err = chromedp.Run(ctx,
-		chromedp.MouseClickXY(hoverX, y, chromedp.ButtonNone),
-	)
+	err := browser.MouseClickXY(ctx, hoverX, y, chromedp.ButtonNone)
 	if err != nil {
-		return nil, fmt.Errorf("error moving mouse: %w", err)
+		return fmt.Errorf("error moving mouse: %w", err)
 	}
 
-	time.Sleep(2 * time.Second)
-
-	// Click on checkbox
-	var clicked bool
-	err = chromedp.Run(ctx,
-		chromedp.Evaluate(fmt.Sprintf(`
-			(function() {
-				const targetY = %f;
-				const checkboxes = document.querySelectorAll('input[type="checkbox"], [class*="checkbox"], [class*="Checkbox"]');
-				
-				for (const cb of checkboxes) {
-					const rect = cb.getBoundingClientRect();
-					if (Math.abs(rect.top + rect.height/2 - targetY) < 40) {
-						if (!cb.checked && !cb.classList.contains('checked')) {
-							cb.click();
-							return true;
-						}
-					}
-				}
-				
-				const elements = document.elementsFromPoint(%f, %f);
-				for (const el of elements) {
-					if (el.tagName === 'INPUT' || 
-						el.className?.includes('checkbox') || 
-						el.className?.includes('Checkbox') ||
-						el.role === 'checkbox') {
-						el.click();
-						return true;
-					}
-				}
-				
-				return false;
-			})()
-		`, y, hoverX, y), &clicked),
-	)
+	// Wait for the checkbox next to the date to be revealed
+	if err := browser.WaitReact(ctx, browser.ReactSelection, scripts.Call("checkbox_revealed", y), checkboxTimeout); err != nil {
+		if browser.IsBrowserClosed(err) {
+			return err
+		}
+		log.Printf("Checkbox not revealed yet (%v), trying anyway...", err)
+	}
 
-	if err != nil {
-		return nil, fmt.Errorf("error clicking checkbox: %w", err)
+	// Click on checkbox: by role first, then by class names and position
+	clicked, err := clickDateCheckbox(ctx, text, y)
+	if err != nil && browser.IsBrowserClosed(err) {
+		return err
+	}
+	if !clicked {
+		err = browser.Evaluate(ctx, scripts.Call("click_checkbox", y, hoverX, y), &clicked)
+
+		if err != nil {
+			return fmt.Errorf("error clicking checkbox: %w", err)
+		}
 	}
 
 	if clicked {
 		log.Printf("✓ Date '%s' selected", text)
-		time.Sleep(500 * time.Millisecond)
-		return &DateInfo{Text: text, YPosition: y}, nil
+		browser.WaitReact(ctx, browser.ReactSelection, scripts.Call("active_selection"), selectionTimeout)
+		return nil
 	}
 
 	// Fallback: click directly
-	err = chromedp.Run(ctx,
-		chromedp.MouseClickXY(hoverX, y, chromedp.ButtonLeft),
-	)
+	err = browser.MouseClickXY(ctx, hoverX, y, chromedp.ButtonLeft)
 	if err == nil {
 		log.Printf("✓ Date '%s' selected (direct click)", text)
-		time.Sleep(500 * time.Millisecond)
-		return &DateInfo{Text: text, YPosition: y}, nil
+		browser.WaitReact(ctx, browser.ReactSelection, scripts.Call("active_selection"), selectionTimeout)
+		return nil
 	}
 
-	return nil, nil
+	return ErrNotSelected
 }
 
 // HasActiveSelection checks if there is any active selection on the page.
 func HasActiveSelection(ctx context.Context) bool {
 	var hasSelection bool
-	if err := chromedp.Run(ctx,
-		chromedp.Evaluate(`
-			(function() {
-				// Check if selection bar is visible (file counter)
-				const selectionBar = document.querySelector('[class*="selection"], [class*="toolbar"]');
-				if (selectionBar) {
-					const text = selectionBar.textContent || '';
-					if (/\d+\s*(file|файл|item)/i.test(text)) {
-						return true;
-					}
-				}
-				
-				// Check if there are checked checkboxes
-				const checkedInputs = document.querySelectorAll('input[type="checkbox"]:checked');
-				if (checkedInputs.length > 0) return true;
-				
-				// Check elements with 'checked' class
-				const checkedElements = document.querySelectorAll('[class*="checkbox"][class*="checked"]');
-				if (checkedElements.length > 0) return true;
-				
-				return false;
-			})()
-		`, &hasSelection),
-	); err != nil {
+	if err := browser.Evaluate(ctx, scripts.Call("active_selection"), &hasSelection); err != nil {
 		log.Printf("Warning: could not check selection state: %v", err)
 		return false
 	}
 	return hasSelection
 }
 
+// Count returns the number of photos the selection toolbar reports as
+// selected, or 0 if it cannot be read.
+func Count(ctx context.Context) int {
+	var count int
+	if err := browser.Evaluate(ctx, scripts.Call("selection_count"), &count); err != nil {
+		return 0
+	}
+	return count
+}
+
+// bleed is the result of the selection_bleed script.
+type bleed struct {
+	Inside   int  `json:"inside"`   // Selected photos of the date
+	Outside  int  `json:"outside"`  // Selected photos of other dates
+	Total    int  `json:"total"`    // Photos of the date on screen
+	Complete bool `json:"complete"` // The whole date is on screen
+}
+
+// CheckSelection makes sure that selecting date selected its photos and
+// nothing else, given count, the number of items in the toolbar (0 if
+// unknown). When the grid re-renders under the click, Yandex sometimes
+// toggles photos of the neighbouring dates too, and the archive then mixes
+// dates. It returns ErrSelectionBleed when photos of other dates are
+// selected on screen, or when the whole date is on screen and the toolbar
+// counts a different number of items than it has.
+func CheckSelection(ctx context.Context, date *DateInfo, count int) error {
+	var b bleed
+	if err := browser.Evaluate(ctx, scripts.Call("selection_bleed", date.YPosition, locale.Current().Months), &b); err != nil {
+		return fmt.Errorf("could not check the selection: %w", err)
+	}
+	switch {
+	case b.Outside > 0:
+		return fmt.Errorf("%w: %d photos of other dates are selected", ErrSelectionBleed, b.Outside)
+	case b.Complete && count > 0 && b.Inside == b.Total && count != b.Total:
+		return fmt.Errorf("%w: the toolbar counts %d items, but '%s' has %d", ErrSelectionBleed, count, date.Text, b.Total)
+	}
+	return nil
+}
+
 // Deselect clears the current selection by clicking the X button or pressing ESC.
 func Deselect(ctx context.Context) error {
+	// Find the X button by role and accessible name first
+	if name, clicked, err := clickCloseButton(ctx); err != nil && browser.IsBrowserClosed(err) {
+		return err
+	} else if clicked && err == nil {
+		log.Printf("Clicking X button - %s", name)
+		return waitDeselected(ctx)
+	}
+
+	// Escape needs no coordinates, so keyboard mode prefers it to a click
+	if browser.Keyboard(ctx) {
+		log.Println("Pressing ESC to deselect...")
+		if err := browser.KeyEvent(ctx, "\x1b"); err != nil {
+			log.Printf("Warning: ESC key press failed: %v", err)
+		}
+		return waitDeselected(ctx)
+	}
+
 	// Find the X button (close/deselect) in the selection bar
 	var buttonInfo map[string]interface{}
-	err := chromedp.Run(ctx,
-		chromedp.Evaluate(`
-			(function() {
-				// Look for X or Deselect button in top bar
-				const selectors = [
-					'button[aria-label*="close" i]',
-					'button[aria-label*="Close" i]',
-					'button[aria-label*="deselect" i]',
-					'[class*="close"]',
-					'[class*="Close"]',
-					'svg[class*="close"]',
-					'button:has(svg)',
-				];
-				
-				for (const selector of selectors) {
-					const elements = document.querySelectorAll(selector);
-					for (const el of elements) {
-						const rect = el.getBoundingClientRect();
-						// X button should be at the top of the screen (toolbar)
-						if (rect.top < 150 && rect.width > 0 && rect.height > 0) {
-							const text = el.textContent?.trim() || '';
-							const ariaLabel = el.getAttribute('aria-label') || '';
-							// Check if it looks like a close/deselect button
-							if (text === '×' || text === 'X' || text === '' || 
-								ariaLabel.toLowerCase().includes('close') ||
-								ariaLabel.toLowerCase().includes('deselect')) {
-								return {
-									x: rect.left + rect.width/2,
-									y: rect.top + rect.height/2,
-									found: true,
-									info: ariaLabel || text || 'button'
-								};
-							}
-						}
-					}
-				}
-				
-				// Look for any button in top bar that could be the X
-				const allButtons = document.querySelectorAll('button, [role="button"]');
-				for (const btn of allButtons) {
-					const rect = btn.getBoundingClientRect();
-					// Button in top right corner (selection area)
-					if (rect.top < 100 && rect.right > window.innerWidth - 200) {
-						const text = btn.textContent?.trim() || '';
-						if (text === '×' || text === 'X' || text.length <= 2) {
-							return {
-								x: rect.left + rect.width/2,
-								y: rect.top + rect.height/2,
-								found: true,
-								info: 'corner-button'
-							};
-						}
-					}
-				}
-				
-				return { found: false };
-			})()
-		`, &buttonInfo),
-	)
+	err := browser.Evaluate(ctx, scripts.Call("find_close_button", locale.Current().Close), &buttonInfo)
 
 	if err != nil {
 		return err
@@ -244,43 +238,40 @@ func Deselect(ctx context.Context) error {
 		info, _ := buttonInfo["info"].(string)
 		log.Printf("Clicking X button at (%.0f, %.0f) - %s", x, y, info)
 
-		err = chromedp.Run(ctx,
-			chromedp.MouseClickXY(x, y, chromedp.ButtonLeft),
-		)
+		err = browser.MouseClickXY(ctx, x, y, chromedp.ButtonLeft)
 		if err != nil {
 			log.Printf("Error clicking X: %v", err)
 		}
 	} else {
 		log.Println("X button not found, trying ESC...")
 		// Fallback: press ESC
-		if err := chromedp.Run(ctx, chromedp.KeyEvent("\x1b")); err != nil {
+		if err := browser.KeyEvent(ctx, "\x1b"); err != nil {
 			log.Printf("Warning: ESC key press failed: %v", err)
 		}
 	}
 
+	return waitDeselected(ctx)
+}
+
+// waitDeselected waits for the selection to clear, clicking on an empty area
+// of the page if it is still active.
+func waitDeselected(ctx context.Context) error {
 	// Wait for UI to update
-	time.Sleep(1 * time.Second)
+	browser.WaitReact(ctx, browser.ReactSelection, "!"+scripts.Call("remaining_selection"), selectionTimeout)
 
 	// Check if selection is still active and click on empty area
 	var hasSelection bool
-	if err := chromedp.Run(ctx,
-		chromedp.Evaluate(`
-			(function() {
-				const checked = document.querySelectorAll('[class*="checkbox"][class*="checked"], [class*="selected"]');
-				return checked.length > 0;
-			})()
-		`, &hasSelection),
-	); err != nil {
+	if err := browser.Evaluate(ctx, scripts.Call("remaining_selection"), &hasSelection); err != nil {
 		log.Printf("Warning: could not check remaining selection: %v", err)
 	}
 
 	if hasSelection {
 		log.Println("Selection still active, clicking on empty area...")
 		// Click on an empty area of the page
-		if err := chromedp.Run(ctx, chromedp.MouseClickXY(800, 400, chromedp.ButtonLeft)); err != nil {
+		if err := browser.MouseClickXY(ctx, 800, 400, chromedp.ButtonLeft); err != nil {
 			log.Printf("Warning: click on empty area failed: %v", err)
 		}
-		time.Sleep(500 * time.Millisecond)
+		browser.WaitReact(ctx, browser.ReactSelection, "!"+scripts.Call("remaining_selection"), selectionTimeout)
 	}
 
 	return nil
@@ -293,6 +284,5 @@ func ClearPendingSelection(ctx context.Context) {
 		if err := Deselect(ctx); err != nil {
 			log.Printf("Warning: could not clear pending selection: %v", err)
 		}
-		time.Sleep(1 * time.Second)
 	}
 }
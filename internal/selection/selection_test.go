@@ -0,0 +1,118 @@
+package selection
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser/browsertest"
+)
+
+func TestPeekFirstVisibleDate(t *testing.T) {
+	d := browsertest.New().On("first_visible_date", map[string]any{"text": "14 March", "x": 120, "y": 340})
+	date, err := PeekFirstVisibleDate(d.Context(context.Background()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DateInfo{Text: "14 March", XPosition: 120, YPosition: 340}
+	if date == nil || *date != want {
+		t.Errorf("got %+v, want %+v", date, want)
+	}
+
+	d.On("first_visible_date", nil)
+	if date, err := PeekFirstVisibleDate(d.Context(context.Background())); date != nil || err != nil {
+		t.Errorf("no date on screen: got %+v, %v", date, err)
+	}
+}
+
+func TestSelectDate(t *testing.T) {
+	d := browsertest.New().
+		On("checkbox_revealed", true).
+		On("click_checkbox", true).
+		On("active_selection", true)
+	date := &DateInfo{Text: "14 March", XPosition: 100, YPosition: 340}
+	if err := SelectDate(d.Context(context.Background()), date); err != nil {
+		t.Fatal(err)
+	}
+	actions := d.Actions()
+	// Hover left of the label, then the checkbox fallback once the
+	// accessibility tree can't be read
+	if i := slices.Index(actions, "MouseClickXY 70,340 none"); i != 0 {
+		t.Errorf("first action: got %v, want a hover next to the label", actions)
+	}
+	if d.Count("Evaluate click_checkbox") != 1 {
+		t.Errorf("click_checkbox not evaluated once: %v", actions)
+	}
+	if slices.Contains(actions, "MouseClickXY 70,340 left") {
+		t.Errorf("clicked directly although the checkbox was clicked: %v", actions)
+	}
+}
+
+func TestSelectDateDirectClick(t *testing.T) {
+	d := browsertest.New().
+		On("checkbox_revealed", true).
+		On("click_checkbox", false).
+		On("active_selection", true)
+	date := &DateInfo{Text: "14 March", XPosition: 20, YPosition: 340}
+	if err := SelectDate(d.Context(context.Background()), date); err != nil {
+		t.Fatal(err)
+	}
+	// The hover position never goes off the left edge
+	if !slices.Contains(d.Actions(), "MouseClickXY 10,340 left") {
+		t.Errorf("no direct click: %v", d.Actions())
+	}
+}
+
+func TestCheckSelection(t *testing.T) {
+	date := &DateInfo{Text: "14 March", YPosition: 340}
+	for _, tc := range []struct {
+		name  string
+		bleed bleed
+		count int
+		bled  bool
+	}{
+		{"clean", bleed{Inside: 3, Total: 3, Complete: true}, 3, false},
+		{"other dates", bleed{Inside: 3, Outside: 2, Total: 3, Complete: true}, 5, true},
+		{"toolbar count", bleed{Inside: 3, Total: 3, Complete: true}, 7, true},
+		{"date not whole", bleed{Inside: 3, Total: 3}, 7, false},
+		{"count unknown", bleed{Inside: 3, Total: 3, Complete: true}, 0, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := browsertest.New().On("selection_bleed", tc.bleed)
+			err := CheckSelection(d.Context(context.Background()), date, tc.count)
+			if got := errors.Is(err, ErrSelectionBleed); got != tc.bled {
+				t.Errorf("got %v, want bleed %v", err, tc.bled)
+			}
+		})
+	}
+
+	d := browsertest.New().On("selection_bleed", errors.New("page gone"))
+	if err := CheckSelection(d.Context(context.Background()), date, 3); err == nil || errors.Is(err, ErrSelectionBleed) {
+		t.Errorf("script failure: got %v", err)
+	}
+}
+
+func TestDeselect(t *testing.T) {
+	d := browsertest.New().
+		On("find_close_button", map[string]any{"found": true, "x": 40, "y": 60, "info": "close"}).
+		On("!remaining_selection", true).
+		On("remaining_selection", false)
+	if err := Deselect(d.Context(context.Background())); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(d.Actions(), "MouseClickXY 40,60 left") {
+		t.Errorf("close button not clicked: %v", d.Actions())
+	}
+
+	d = browsertest.New().
+		On("find_close_button", map[string]any{"found": false}).
+		On("!remaining_selection", true).
+		On("remaining_selection", false)
+	if err := Deselect(d.Context(context.Background())); err != nil {
+		t.Fatal(err)
+	}
+	if d.Count("KeyEvent \x1b") != 1 {
+		t.Errorf("ESC not pressed without a close button: %v", d.Actions())
+	}
+}
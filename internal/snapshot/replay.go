@@ -0,0 +1,95 @@
+// Package snapshot records page snapshots of key UI states and replays the
+// selection logic against them, so selector changes can be developed offline.
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/selection"
+)
+
+// Result is the outcome of replaying the selection logic against one snapshot.
+type Result struct {
+	File   string
+	State  string
+	Passed bool
+	Detail string
+}
+
+// Replay loads every snapshot in dir and checks that the selection logic
+// still recognizes the recorded state. Static snapshots don't run Yandex's
+// JavaScript, so only detection (dates, checkboxes, selection bar) is verified.
+func Replay(ctx context.Context, dir string) ([]Result, error) {
+	files, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(files))
+	for _, file := range files {
+		if browser.IsContextCanceled(ctx) {
+			return results, ctx.Err()
+		}
+
+		res := Result{File: filepath.Base(file), State: StateOf(file)}
+		u, err := fileURL(file)
+		if err == nil {
+			err = browser.Navigate(ctx, u)
+		}
+		if err != nil {
+			res.Detail = fmt.Sprintf("could not load snapshot: %v", err)
+			results = append(results, res)
+			continue
+		}
+
+		switch res.State {
+		case StateTimeline:
+			dateInfo, err := selection.SelectFirstVisibleDate(ctx)
+			switch {
+			case err != nil:
+				res.Detail = fmt.Sprintf("selection failed: %v", err)
+			case dateInfo == nil:
+				res.Detail = "no date found"
+			default:
+				res.Passed = true
+				res.Detail = fmt.Sprintf("found date '%s' (y=%.0f)", dateInfo.Text, dateInfo.YPosition)
+			}
+		case StateSelectionActive:
+			if !selection.HasActiveSelection(ctx) {
+				res.Detail = "active selection not detected"
+				break
+			}
+			if err := selection.Deselect(ctx); err != nil {
+				res.Detail = fmt.Sprintf("deselect failed: %v", err)
+				break
+			}
+			res.Passed = true
+			res.Detail = "selection detected and deselect ran"
+		default:
+			res.Passed = true
+			res.Detail = "loaded (no checks for this state)"
+		}
+
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// PrintResults logs replay results and returns the number of failures.
+func PrintResults(results []Result) int {
+	failed := 0
+	for _, r := range results {
+		mark := "✓"
+		if !r.Passed {
+			mark = "❌"
+			failed++
+		}
+		log.Printf("%s %s [%s]: %s", mark, r.File, r.State, r.Detail)
+	}
+	log.Printf("Replay finished: %d passed, %d failed", len(results)-failed, failed)
+	return failed
+}
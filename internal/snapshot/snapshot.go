@@ -0,0 +1,139 @@
+// Package snapshot records page snapshots of key UI states and replays the
+// selection logic against them, so selector changes can be developed offline.
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// Key UI states captured during a recording run.
+const (
+	StateTimeline        = "timeline"
+	StateFilterMenuOpen  = "filter-menu-open"
+	StateSelectionActive = "selection-active"
+)
+
+// Recorder saves MHTML and HTML snapshots of the page into a directory.
+type Recorder struct {
+	dir  string
+	mu   sync.Mutex
+	seen map[string]int // Captures per state, to limit disk usage
+}
+
+// MaxPerState is the maximum number of snapshots kept for each state.
+const MaxPerState = 3
+
+// NewRecorder creates a Recorder writing into dir, creating it if needed.
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create snapshot directory: %w", err)
+	}
+	return &Recorder{dir: dir, seen: make(map[string]int)}, nil
+}
+
+// recorderKey is the context key under which a Recorder is stored.
+type recorderKey struct{}
+
+// WithRecorder returns a copy of ctx that records snapshots through r.
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// Capture saves a snapshot of the current page for the given state if a
+// Recorder was injected into ctx. Failures are logged and never fatal.
+func Capture(ctx context.Context, state string) {
+	r, ok := ctx.Value(recorderKey{}).(*Recorder)
+	if !ok || r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	if r.seen[state] >= MaxPerState {
+		r.mu.Unlock()
+		return
+	}
+	r.seen[state]++
+	r.mu.Unlock()
+
+	if err := r.capture(ctx, state); err != nil {
+		log.Printf("Warning: could not record %s snapshot: %v", state, err)
+	}
+}
+
+// capture writes the MHTML archive and serialized DOM of the current page.
+func (r *Recorder) capture(ctx context.Context, state string) error {
+	var mhtml string
+	if err := browser.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		mhtml, err = page.CaptureSnapshot().WithFormat(page.CaptureSnapshotFormatMhtml).Do(ctx)
+		return err
+	})); err != nil {
+		return err
+	}
+
+	var html string
+	if err := browser.Evaluate(ctx, `document.documentElement.outerHTML`, &html); err != nil {
+		return err
+	}
+
+	base := filepath.Join(r.dir, fmt.Sprintf("%s_%s", time.Now().Format("20060102-150405.000"), state))
+	if err := os.WriteFile(base+".mhtml", []byte(mhtml), 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(base+".html", []byte(html), 0644); err != nil {
+		return err
+	}
+
+	log.Printf("📸 Snapshot saved: %s.mhtml", base)
+	return nil
+}
+
+// statePattern extracts the state name from a snapshot file name.
+var statePattern = regexp.MustCompile(`_([a-z-]+)\.mhtml$`)
+
+// StateOf returns the UI state recorded in a snapshot file name.
+func StateOf(path string) string {
+	m := statePattern.FindStringSubmatch(filepath.Base(path))
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// List returns all MHTML snapshots in dir, sorted by name (capture time).
+func List(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.mhtml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .mhtml snapshots found in %s", dir)
+	}
+	return files, nil
+}
+
+// fileURL converts a local path into a file:// URL.
+func fileURL(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	p := filepath.ToSlash(abs)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // Windows drive paths
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String(), nil
+}
@@ -0,0 +1,153 @@
+// Package throttle detects Yandex rate limiting and adapts the pace of the run.
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// DelayStep is how much the inter-date delay grows after each throttling signal.
+	DelayStep = 5 * time.Second
+	// MaxDelay is the upper bound for the inter-date delay.
+	MaxDelay = 60 * time.Second
+	// Cooldown is how long to pause when throttling signals keep arriving.
+	Cooldown = 5 * time.Minute
+	// cooldownThreshold is the number of consecutive signals that trigger a cooldown.
+	cooldownThreshold = 3
+)
+
+// Pacer tracks throttling signals and controls the delay between dates.
+type Pacer struct {
+	mu          sync.Mutex
+	delay       time.Duration // Current extra delay between dates
+	consecutive int           // Consecutive dates with a throttling signal
+	pending429  int           // HTTP 429 responses seen since the last Check
+}
+
+// New creates a Pacer with no extra delay.
+func New() *Pacer {
+	return &Pacer{}
+}
+
+// Watch starts recording HTTP 429 responses received by the page.
+func (p *Pacer) Watch(ctx context.Context) error {
+	chromedp.ListenTarget(ctx, func(ev any) {
+		if e, ok := ev.(*network.EventResponseReceived); ok && e.Response != nil &&
+			e.Response.Status == http.StatusTooManyRequests {
+			p.mu.Lock()
+			p.pending429++
+			p.mu.Unlock()
+		}
+	})
+	return browser.Run(ctx, network.Enable())
+}
+
+// Check looks for throttling signals since the last call and returns a short
+// description of the signal found, or an empty string if there was none.
+// downloadFailed reports whether the last download refused to start.
+func (p *Pacer) Check(ctx context.Context, downloadFailed bool) string {
+	p.mu.Lock()
+	count := p.pending429
+	p.pending429 = 0
+	p.mu.Unlock()
+
+	switch {
+	case count > 0:
+		return fmt.Sprintf("%d HTTP 429 response(s)", count)
+	case hasThrottleToast(ctx):
+		return "'too many requests' notification"
+	case downloadFailed:
+		return "download refused to start"
+	}
+	return ""
+}
+
+// Throttled increases the inter-date delay and, if signals keep arriving,
+// pauses for a cooldown period before resuming.
+func (p *Pacer) Throttled(ctx context.Context) {
+	p.mu.Lock()
+	p.consecutive++
+	p.delay += DelayStep
+	if p.delay > MaxDelay {
+		p.delay = MaxDelay
+	}
+	consecutive, delay := p.consecutive, p.delay
+	p.mu.Unlock()
+
+	log.Printf("🐢 Slowing down: waiting %v between dates", delay)
+
+	if consecutive >= cooldownThreshold {
+		log.Printf("🐢 Throttled %d times in a row. Cooling down for %v...", consecutive, Cooldown)
+		sleep(ctx, Cooldown)
+		log.Println("✓ Cooldown finished, resuming")
+
+		p.mu.Lock()
+		p.consecutive = 0
+		p.mu.Unlock()
+	}
+}
+
+// Succeeded relaxes the pace after a date was processed without throttling.
+func (p *Pacer) Succeeded() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.consecutive = 0
+	if p.delay > 0 {
+		p.delay -= DelayStep / 2
+		if p.delay < 0 {
+			p.delay = 0
+		}
+	}
+}
+
+// Delay returns the current extra delay between dates.
+func (p *Pacer) Delay() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.delay
+}
+
+// Wait sleeps for the current extra delay between dates.
+func (p *Pacer) Wait(ctx context.Context) {
+	if delay := p.Delay(); delay > 0 {
+		sleep(ctx, delay)
+	}
+}
+
+// hasThrottleToast checks the page for a rate-limit notification.
+func hasThrottleToast(ctx context.Context) bool {
+	var found bool
+	if err := browser.Evaluate(ctx, `
+		(function() {
+			const pattern = /too many requests|try again later|слишком много запросов|попробуйте позже/i;
+			const toasts = document.querySelectorAll('[role="alert"], [role="status"], [class*="notification"], [class*="Notification"], [class*="toast"], [class*="Toast"], [class*="snackbar"]');
+			for (const el of toasts) {
+				if (pattern.test(el.textContent || '')) {
+					return true;
+				}
+			}
+			return false;
+		})()
+	`, &found); err != nil {
+		return false
+	}
+	return found
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
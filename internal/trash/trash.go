@@ -0,0 +1,61 @@
+// Package trash moves exported photos to the Yandex Disk Trash once their
+// download has been verified, to free the account's storage.
+package trash
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/locale"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/selection"
+)
+
+const (
+	// ConfirmTimeout is how long to wait for Yandex's confirmation dialog.
+	ConfirmTimeout = 5 * time.Second
+	// removeTimeout is how long to wait for the photos to leave the timeline.
+	removeTimeout = 30 * time.Second
+)
+
+// ErrButtonNotFound is returned when the selection toolbar has no Delete button.
+var ErrButtonNotFound = errors.New("delete button not found")
+
+// isDeleteName matches the accessible name of the toolbar's Delete button
+// in the interface language.
+func isDeleteName(name string) bool {
+	return slices.Contains(locale.Current().Delete, name)
+}
+
+// MoveSelectionToTrash clicks Delete in the selection toolbar, confirms
+// Yandex's dialog if one is shown and waits until the selection is gone.
+// Deleted photos stay in the Yandex Trash until it is emptied.
+func MoveSelectionToTrash(ctx context.Context) error {
+	if _, err := browser.ClickByRole(ctx, "button", isDeleteName); err != nil {
+		if errors.Is(err, browser.ErrElementNotFound) {
+			return ErrButtonNotFound
+		}
+		return err
+	}
+
+	// Some versions ask for confirmation, others move to Trash right away
+	if err := browser.WaitFor(ctx, scripts.Call("trash_confirm", locale.Current().Delete), ConfirmTimeout); err != nil && browser.IsBrowserClosed(err) {
+		return err
+	}
+
+	deadline := time.Now().Add(removeTimeout)
+	for selection.HasActiveSelection(ctx) {
+		if time.Now().After(deadline) {
+			return errors.New("photos are still selected after moving them to Trash")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+// Package webui serves a small embedded dashboard showing live progress,
+// per-date status and errors, with buttons to pause, resume or stop the run.
+// It is meant for exports running on a headless machine such as a NAS. It
+// listens on localhost unless given another address, and its API requires a
+// token generated for each run, so only those who were shown the URL can
+// control the run.
+package webui
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/control"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/events"
+)
+
+const (
+	// maxDates is the number of most recent dates listed on the dashboard.
+	maxDates = 200
+	// maxErrors is the number of most recent errors listed on the dashboard.
+	maxErrors = 50
+)
+
+// TokenHeader is the request header that carries the API token; the token
+// query parameter is accepted too.
+const TokenHeader = "X-Dashboard-Token"
+
+//go:embed index.html
+var indexHTML []byte
+
+// DateStatus is the latest known status of a date.
+type DateStatus struct {
+	Date    string      `json:"date"`
+	Status  events.Type `json:"status"`
+	Message string      `json:"message,omitempty"`
+	Updated time.Time   `json:"updated"`
+}
+
+// Counters summarize the run so far.
+type Counters struct {
+	DatesFound        int   `json:"datesFound"`
+	DatesSkipped      int   `json:"datesSkipped"`
+	DownloadsStarted  int   `json:"downloadsStarted"`
+	DownloadsComplete int   `json:"downloadsCompleted"`
+	DownloadsFailed   int   `json:"downloadsFailed"`
+	DatesTrashed      int   `json:"datesTrashed"`
+	DatesMarked       int   `json:"datesMarked"`
+	BytesDownloaded   int64 `json:"bytesDownloaded"`
+	Errors            int   `json:"errors"`
+}
+
+// Status is the dashboard state returned by /api/status.
+type Status struct {
+	State    control.State  `json:"state"`
+	Started  time.Time      `json:"started"`
+	Finished bool           `json:"finished"`
+	Counters Counters       `json:"counters"`
+	Dates    []DateStatus   `json:"dates"`  // Most recent first
+	Errors   []events.Event `json:"errors"` // Most recent first
+}
+
+// Server collects events and serves the dashboard.
+type Server struct {
+	ctl   *control.Controller
+	token string // Required by the API, set by Start
+
+	mu       sync.Mutex
+	started  time.Time
+	finished bool
+	counters Counters
+	dates    []DateStatus // Oldest first
+	errors   []events.Event
+}
+
+// New creates a Server fed by the emitter's events and controlling ctl.
+func New(emitter *events.Emitter, ctl *control.Controller) *Server {
+	s := &Server{ctl: ctl, started: time.Now()}
+	emitter.Subscribe(s.record)
+	return s
+}
+
+// Start listens on addr (e.g. ":8080", which means 127.0.0.1:8080, or
+// "0.0.0.0:8080" to be reached from the network) and serves the dashboard
+// in the background. It returns the dashboard's URL, whose fragment holds
+// the token the page sends with its API requests.
+func (s *Server) Start(addr string) (string, error) {
+	addr, local, err := listenAddr(addr)
+	if err != nil {
+		return "", err
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("could not generate the web UI token: %w", err)
+	}
+	s.token = hex.EncodeToString(token)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("could not start web UI on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(indexHTML)
+	})
+	mux.HandleFunc("GET /api/status", s.authorized(s.handleStatus))
+	mux.HandleFunc("POST /api/pause", s.authorized(s.handleControl(s.ctl.Pause)))
+	mux.HandleFunc("POST /api/resume", s.authorized(s.handleControl(s.ctl.Resume)))
+	mux.HandleFunc("POST /api/stop", s.authorized(s.handleControl(s.ctl.Stop)))
+
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			log.Printf("⚠️ Web UI stopped: %v", err)
+		}
+	}()
+	if !local {
+		log.Printf("⚠️ The web UI can be reached from the network on %s. Anyone who gets its URL can control the run, and plain HTTP does not hide it from others on the network: only use it on a network you trust", ln.Addr())
+	}
+	return "http://" + ln.Addr().String() + "/#token=" + s.token, nil
+}
+
+// listenAddr returns addr with 127.0.0.1 as its host if it has none, and
+// whether it only accepts connections from this machine.
+func listenAddr(addr string) (string, bool, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid web UI address %q: %w", addr, err)
+	}
+	if host == "" || host == "localhost" {
+		return net.JoinHostPort("127.0.0.1", port), true, nil
+	}
+	ip := net.ParseIP(host)
+	return addr, ip != nil && ip.IsLoopback(), nil
+}
+
+// authorized rejects requests without the run's token, in the TokenHeader
+// header or the token query parameter. Another website open in the browser
+// can send requests to localhost, but cannot know the token.
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(TokenHeader)
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			http.Error(w, "missing or wrong dashboard token", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Status returns a snapshot of the dashboard state.
+func (s *Server) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := Status{
+		State:    s.ctl.State(),
+		Started:  s.started,
+		Finished: s.finished,
+		Counters: s.counters,
+		Dates:    make([]DateStatus, 0, len(s.dates)),
+		Errors:   make([]events.Event, 0, len(s.errors)),
+	}
+	for i := len(s.dates) - 1; i >= 0; i-- {
+		st.Dates = append(st.Dates, s.dates[i])
+	}
+	for i := len(s.errors) - 1; i >= 0; i-- {
+		st.Errors = append(st.Errors, s.errors[i])
+	}
+	return st
+}
+
+// handleStatus replies with the dashboard state as JSON.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(s.Status())
+}
+
+// handleControl applies a control request and replies with the new state.
+func (s *Server) handleControl(action func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		action()
+		log.Printf("🌐 Web UI request: %s", r.URL.Path)
+		s.handleStatus(w, r)
+	}
+}
+
+// record updates the dashboard state from an event.
+func (s *Server) record(ev events.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch ev.Type {
+	case events.RunStarted:
+		s.started = ev.Time
+	case events.RunFinished:
+		s.finished = true
+	case events.DateFound:
+		s.counters.DatesFound++
+	case events.DateSkipped:
+		s.counters.DatesSkipped++
+	case events.DownloadStarted:
+		s.counters.DownloadsStarted++
+	case events.DownloadCompleted:
+		s.counters.DownloadsComplete++
+		s.counters.BytesDownloaded += ev.Bytes
+	case events.DownloadFailed:
+		s.counters.DownloadsFailed++
+	case events.DateTrashed:
+		s.counters.DatesTrashed++
+	case events.DateMarked:
+		s.counters.DatesMarked++
+	}
+	if ev.Type == events.Error || ev.Type == events.DownloadFailed {
+		s.counters.Errors++
+		if len(s.errors) >= maxErrors {
+			s.errors = s.errors[1:]
+		}
+		s.errors = append(s.errors, ev)
+	}
+
+	if ev.Date != "" {
+		s.setDate(DateStatus{Date: ev.Date, Status: ev.Type, Message: ev.Message, Updated: ev.Time})
+	}
+}
+
+// setDate updates the status of a date, adding it if it is new.
+func (s *Server) setDate(d DateStatus) {
+	for i := len(s.dates) - 1; i >= 0; i-- {
+		if s.dates[i].Date == d.Date {
+			s.dates[i] = d
+			return
+		}
+	}
+	if len(s.dates) >= maxDates {
+		s.dates = s.dates[1:]
+	}
+	s.dates = append(s.dates, d)
+}
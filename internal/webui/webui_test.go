@@ -0,0 +1,84 @@
+package webui
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/control"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/events"
+)
+
+func TestListenAddr(t *testing.T) {
+	for _, tc := range []struct {
+		addr, want string
+		local      bool
+	}{
+		{":8080", "127.0.0.1:8080", true},
+		{"localhost:8080", "127.0.0.1:8080", true},
+		{"127.0.0.1:8080", "127.0.0.1:8080", true},
+		{"[::1]:8080", "[::1]:8080", true},
+		{"0.0.0.0:8080", "0.0.0.0:8080", false},
+		{"192.168.1.10:8080", "192.168.1.10:8080", false},
+		{"nas.local:8080", "nas.local:8080", false},
+	} {
+		got, local, err := listenAddr(tc.addr)
+		if err != nil || got != tc.want || local != tc.local {
+			t.Errorf("listenAddr(%q) = %q, %t, %v; want %q, %t", tc.addr, got, local, err, tc.want, tc.local)
+		}
+	}
+	if _, _, err := listenAddr("8080"); err == nil {
+		t.Error("listenAddr(\"8080\") accepted an address without a port")
+	}
+}
+
+func TestAPIToken(t *testing.T) {
+	ctl := control.New()
+	url, err := New(events.New(nil), ctl).Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, token, ok := strings.Cut(url, "#token=")
+	if !ok || len(token) != 32 {
+		t.Fatalf("no token in %q", url)
+	}
+
+	request := func(method, path, header string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, base+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header != "" {
+			req.Header.Set(TokenHeader, header)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	if code := request("GET", "", ""); code != http.StatusOK {
+		t.Errorf("page: got %d, want 200", code)
+	}
+	if code := request("GET", "api/status", ""); code != http.StatusForbidden {
+		t.Errorf("status without token: got %d, want 403", code)
+	}
+	if code := request("POST", "api/pause", "wrong"); code != http.StatusForbidden {
+		t.Errorf("pause with a wrong token: got %d, want 403", code)
+	}
+	if ctl.State() != control.Running {
+		t.Fatalf("unauthorized pause changed the state to %s", ctl.State())
+	}
+	if code := request("GET", "api/status", token); code != http.StatusOK {
+		t.Errorf("status with token: got %d, want 200", code)
+	}
+	if code := request("POST", "api/pause?token="+token, ""); code != http.StatusOK {
+		t.Errorf("pause with the token in the query: got %d, want 200", code)
+	}
+	if ctl.State() != control.Paused {
+		t.Errorf("state after pause: got %s, want %s", ctl.State(), control.Paused)
+	}
+}
@@ -1,21 +1,58 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"image"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/annotations"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/appdirs"
 	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/auth"
 	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/catalog"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/control"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/crash"
 	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/datefilter"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/datelog"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/debugserver"
 	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/download"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/events"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/exif"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/exporter"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/extract"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/fixture"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/forensics"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/geo"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/locale"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/logging"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/mirror"
 	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/navigation"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/netcheck"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/notify"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/overlay"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/pipeline"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/preflight"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/progress"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/quota"
 	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/report"
-	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/selection"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/retry"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/scripts"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/snapshot"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/throttle"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/tracing"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/webui"
 )
 
 // appVersion is set at build time via -ldflags="-X main.appVersion=x.x.x"
@@ -23,9 +60,13 @@ var appVersion = "dev"
 
 const (
 	yandexPhotosURL = "https://disk.yandex.com/client/photo"
+	// englishLocale is the language requested when -force-english is set
+	englishLocale = "en-US"
 )
 
 func main() {
+	defer crash.Recover()
+
 	// Version flag
 	showVersion := flag.Bool("version", false, "Show version and exit")
 
@@ -35,13 +76,101 @@ func main() {
 	defaultDownload := "./YandexDiskPhotosExporter"
 
 	profile := flag.String("profile", defaultProfile, "Path to browser profile")
+	token := flag.String("token", "", "Yandex OAuth token to open the browser's Yandex session with instead of logging in (default: $"+auth.TokenEnv+")")
 	batchSize := flag.Int("batch", 10, "Number of dates per batch")
 	execPath := flag.String("exec", "", "Browser executable (auto-detect if empty)")
 	downloadDir := flag.String("download", defaultDownload, "Directory to save downloads")
+	mode := flag.String("mode", "unlimited", "Photos to export: unlimited (from unlimited storage), services (saved from Telegram, VK, mail...) or all, saved in unlimited/ and disk/ folders by storage")
+	forceEnglish := flag.Bool("force-english", false, "Force the Yandex Disk interface into English (for accounts that default to Russian)")
+	extractArchives := flag.Bool("extract", false, "Extract each downloaded archive into a folder next to it (archives are kept)")
+	fileTypes := flag.String("types", "", "With -extract, only keep these file types, e.g. jpg,heic,mp4 (default: all)")
+	minSize := flag.String("min-size", "", "With -extract, skip files smaller than this, e.g. 100KB")
+	maxSize := flag.String("max-size", "", "With -extract, skip files larger than this, e.g. 1GB")
+	extractStructure := flag.String("extract-structure", "preserve", "With -extract, keep the archive's folders (preserve) or put all files in one folder per archive (flat)")
+	nameTemplate := flag.String("name-template", "", "With -extract, rename extracted files, e.g. {date}_{index}_{orig} (tokens: {date}, {index}, {orig}, {type})")
+	normalize := flag.String("normalize", "", "With -extract, convert extracted file names to Unicode nfc or nfd")
+	dedup := flag.String("dedup", "", "With -extract, store files identical to an earlier one once: link (hard link) or skip")
+	provenance := flag.Bool("provenance", false, "With -extract, tag extracted files with their date, run and archive in extended attributes")
+	reorganize := flag.Bool("reorganize", false, "With -extract, move extracted photos into folders named after their EXIF capture date")
+	geoFormat := flag.String("geo", "", "With -extract, write the locations of geotagged photos to a geojson, gpx or kml file per run")
+	saveAnnotations := flag.Bool("annotations", false, "Save the titles and descriptions of photos to "+annotations.FileName+" in the download directory, keyed by file name")
+	skipScreenshots := flag.Bool("skip-screenshots", false, "With -extract, leave screenshots out (or move them to -screenshots-dir)")
+	screenshotsDir := flag.String("screenshots-dir", "", "With -skip-screenshots, extract screenshots into this folder (relative to -download) instead of leaving them out")
+	pipelineFile := flag.String("pipeline", "", "JSON file of post-processing steps (extract, convert, rename, set-mtime, hook, sink) run in order on each finished download, instead of -extract")
+	subdirs := flag.Bool("subdirs", false, "Save each date's archive (and its extracted files) in a folder named after the date")
+	markAlbum := flag.String("mark-album", "", "After each date's download is verified, add its photos to this existing Yandex Disk album")
+	previewOnly := flag.Bool("preview", false, "Only save the thumbnails of each date into a previews folder, to review what a full export would contain")
+	inventoryOnly := flag.Bool("inventory", false, "Only record the name, type and size of every file of each date in the catalog, without downloading")
+	localMirror := flag.String("local-mirror", "", "Skip dates whose files are all in this Yandex.Disk desktop client folder (\"auto\" to find it)")
+	redownload := flag.Bool("redownload", false, "Download dates again whose archives from an earlier run are still in the download directory, instead of skipping them (they are never skipped with -extract or -pipeline)")
+	deleteAfterVerify := flag.Bool("delete-after-verify", false, "After each date's download is verified, move its photos to the Yandex Disk Trash (asks for confirmation)")
 	cleanDir := flag.Bool("clean", false, "Clean download directory before starting")
 	fromDate := flag.String("from", "", "Start date for filtering (format: YYYY-MM-DD)")
 	toDate := flag.String("to", "", "End date for filtering (format: YYYY-MM-DD)")
+	dateTimeout := flag.Duration("date-timeout", 3*time.Minute, "Maximum time for one date's select/download/deselect cycle before it is marked as stuck")
+	progressEvery := flag.Duration("progress-every", 5*time.Second, "Update "+progress.FileName+" in the data directory this often with the run's status and ETA (0 disables)")
+	webhook := flag.String("webhook", "", "POST the run's progress as JSON to this URL when it ends (see -webhook-every and -webhook-every-dates for interim updates)")
+	webhookEvery := flag.Duration("webhook-every", 0, "With -webhook, also POST the progress at this interval while the run goes on (e.g. 15m; 0 disables)")
+	webhookEveryDates := flag.Int("webhook-every-dates", 0, "With -webhook, also POST the progress every N finished dates (0 disables)")
+	webAddr := flag.String("web", "", "Serve a dashboard with live progress and pause/resume/stop buttons on this address (e.g. :8080 for localhost only, 0.0.0.0:8080 for the network); open the URL printed at startup, which holds its access token")
+	output := flag.String("output", "text", "Machine-readable output on stdout: text (none) or jsonl (one JSON event per line); the log and the report are always on stderr")
+	quiet := flag.Bool("quiet", false, "Suppress the log, from startup on; errors that stop the run are still printed")
+	jsonSummary := flag.Bool("json", false, "Print the final statistics as a single JSON document on stdout instead of the report, then exit")
+	logTime := flag.String("log-time", "local", "Timestamp of log lines: local, rfc3339, elapsed (seconds since start) or none")
+	reportTheme := flag.String("report-theme", "dark", "Colors of the final report: dark, light or none, optionally with part=color overrides (e.g. light,warn=magenta)")
+	plain := flag.Bool("plain", false, "Use plain ASCII markers instead of emoji, box-drawing and colors in the log and report")
+	debug := flag.Bool("debug", false, "Enable debug logging (page JavaScript errors, failed requests, step timings)")
+	debugAddr := flag.String("debug-addr", "", "Serve net/http/pprof on this address (e.g. :6060) for live profiling")
+	debugDir := flag.String("debug-dir", "", "Directory for debug bundles written on unrecoverable errors (default: debug in the data directory)")
+	portable := flag.Bool("portable", false, "Keep the browser profile, config, catalogs, logs and debug bundles in "+appdirs.PortableName+" next to the executable, e.g. on a USB drive (on whenever that folder exists)")
+	dataDir := flag.String("data-dir", "", "Directory for the exporter's own files: config, catalogs, progress file, logs and debug bundles (default: the OS's standard locations)")
+	recordDir := flag.String("record-snapshots", "", "Save MHTML/DOM snapshots of key UI states into this directory (development)")
+	labelsFile := flag.String("labels", "", "JSON file with extra interface labels (Download, Close, filter options, months) by language code, e.g. for Turkish or Ukrainian")
+	scriptsDir := flag.String("scripts-dir", "", "Directory with JavaScript overrides for the embedded page scripts (development)")
+	which := flag.String("which", "", "Print the Yandex date an archive or extracted file in the download directory belongs to and exit")
+	diff := flag.Bool("diff", false, "Compare the catalog of dates seen on Yandex with the download directory and exit")
+	verifyDir := flag.String("verify-downloads", "", "Test the zip archives in this directory (e.g. an earlier manual export), match them with the catalog's dates, list the dates to download again and exit")
+	targetURL := flag.String("target-url", "", "Open this page instead of Yandex Disk Photos; \"fixture\" serves a built-in fake timeline (development and tests)")
+	replayDir := flag.String("replay", "", "Replay selection logic against snapshots in this directory and exit (development)")
+	shards := flag.Int("shards", 1, "Split the date range into N shards exported concurrently in separate browser windows")
+	minFreeGB := flag.Float64("min-free-gb", 1, "Minimum free disk space (GB) required in the download directory")
+	skipPreflight := flag.Bool("skip-preflight", false, "Skip network, download directory and disk space checks before starting")
+	otlpEndpoint := flag.String("otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OpenTelemetry collector URL for trace export over OTLP/HTTP, e.g. http://localhost:4318 (empty disables)")
+	maxInFlight := flag.Int("max-inflight", 3, "Archives Yandex may be preparing or Chrome downloading at once while the next dates are selected (0 for no limit)")
+	errorLogPath := flag.String("error-log", logging.ErrorLogName, "File that receives every warning and error, with timestamps and the date being processed, whatever -quiet hides (relative to the log directory; empty disables)")
+	dateLogs := flag.Bool("date-logs", false, "Write a JSON-lines log of each processed date (steps, timings, errors) to the log directory")
+	dateRetries := flag.Int("date-retries", 2, "Times a date is tried again after a selection failure, failed download or stuck cycle before it is recorded as failed and skipped")
+	maxErrors := flag.Int("max-errors", exporter.DefaultErrorThreshold, "Failed attempts in a row, of the same or different dates, that trigger the next -on-errors action")
+	onErrors := flag.String("on-errors", "refresh", "Recovery actions taken each time -max-errors is reached before a download starts, the last one repeating: wait[:duration], refresh, restart (the browser) or abort, e.g. wait:2m,refresh,restart,abort")
+	blacklistAfter := flag.Int("blacklist-after", 3, "Blacklist a date that failed in this many runs; later runs skip it (0 disables)")
+	retryBlacklisted := flag.Bool("retry-blacklisted", false, "Process blacklisted dates again instead of skipping them")
+	humanize := flag.Bool("humanize", false, "Move the mouse along curved paths, scroll in wheel-sized steps and vary pauses, so very long sessions look less automated")
+	humanizeDelay := flag.Duration("humanize-delay", 400*time.Millisecond, "With -humanize, longest random pause before each click, key press and scroll")
+	humanizeJitter := flag.Float64("humanize-jitter", 0.3, "With -humanize, fraction by which fixed waits vary, e.g. 0.3 for ±30%")
+	adaptiveDelays := flag.Bool("adaptive-delays", true, "Measure how fast the page reacts and shorten or lengthen waits and pauses to match (false keeps the fixed ones)")
+	windowPos := flag.String("window-pos", "", "Open the browser window with its top-left corner at x,y, e.g. -3000,0 to keep it off-screen")
+	windowState := flag.String("window-state", browser.WindowNormal, "Browser window state: normal, minimized or maximized; the window is shown when a login is needed")
+	keyboard := flag.Bool("keyboard", false, "Select dates, press Download and deselect with the keyboard (focus and shortcut keys) where the page allows, instead of clicks at screen positions")
+	keepAlive := flag.Duration("keep-alive", time.Minute, "While waiting for Yandex to prepare archives, move the pointer, focus and scroll the page by a pixel this often so the session isn't expired as idle (0 disables)")
+	heartbeat := flag.Duration("heartbeat", 15*time.Second, "Check this often that the page still responds, and reload it if it hangs (0 disables)")
+	reloadEvery := flag.Int("reload-every", 100, "Reload the page every N processed dates to release browser memory (0 to disable)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [export|login] [flags]\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintln(flag.CommandLine.Output(), "  export  Export the photos (the default)")
+		fmt.Fprintf(flag.CommandLine.Output(), "  login   Only open the browser to log in to Yandex, save the session in -profile and exit\n\n")
+		flag.PrintDefaults()
+	}
+
+	// The command comes before the flags; export when there is none
+	command := "export"
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		command = os.Args[1]
+		os.Args = append(os.Args[:1:1], os.Args[2:]...)
+	}
 	flag.Parse()
+	if command != "export" && command != "login" {
+		log.Fatalf("Error: unknown command %q (use export or login)", command)
+	}
 
 	// Handle version flag
 	if *showVersion {
@@ -49,6 +178,157 @@ func main() {
 		os.Exit(0)
 	}
 
+	logging.SetDebug(*debug)
+	if err := logging.SetTimeFormat(*logTime); err != nil {
+		log.Fatalf("Error: -log-time: %v", err)
+	}
+
+	if err := report.SetTheme(*reportTheme); err != nil {
+		log.Fatalf("Error: -report-theme: %v", err)
+	}
+
+	// ASCII-only output for terminals and log collectors without emoji
+	var stderr io.Writer = os.Stderr
+	if *plain {
+		stderr = logging.PlainWriter(os.Stderr)
+		report.SetPlain(true)
+	}
+	// With -quiet, the log only reaches the terminal for errors that stop
+	// the run; the debug bundle and the error log still get all of it
+	console := stderr
+	if *quiet {
+		console = logging.FatalOnly(stderr)
+	}
+	log.SetOutput(logging.Timestamps(console))
+
+	// Machine-readable event stream on stdout; the log and the report are
+	// on stderr, so stdout carries nothing but the JSON lines
+	var emitter *events.Emitter
+	switch *output {
+	case "text":
+	case "jsonl":
+		emitter = events.New(os.Stdout)
+	default:
+		log.Fatalf("Error: unknown -output %q (use text or jsonl)", *output)
+	}
+	if *jsonSummary && *output == "jsonl" {
+		log.Fatal("Error: -json cannot be combined with -output jsonl")
+	}
+
+	// Pause/resume/stop requests between dates
+	ctl := control.New()
+	ctl.HandleSignals()
+	if *webAddr != "" {
+		if emitter == nil {
+			emitter = events.New(nil)
+		}
+		url, err := webui.New(emitter, ctl).Start(*webAddr)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		log.Printf("🌐 Dashboard: %s", url)
+		if *quiet {
+			// The URL holds the token the dashboard needs
+			fmt.Fprintf(stderr, "🌐 Dashboard: %s\n", url)
+		}
+	}
+	// The exporter's own files, apart from the downloads
+	var dirs appdirs.Dirs
+	if *portable && *dataDir != "" {
+		log.Fatal("Error: -portable and -data-dir cannot be combined")
+	}
+	switch {
+	case *portable || (*dataDir == "" && appdirs.FindPortable() != ""):
+		// Everything next to the executable, e.g. on a USB drive
+		root, err := appdirs.PortableRoot()
+		if err != nil {
+			log.Fatalf("Error: -portable: %v", err)
+		}
+		if err := os.MkdirAll(root, 0755); err != nil {
+			log.Fatalf("Error: -portable: %v", err)
+		}
+		dirs = appdirs.In(root)
+		profileSet := false
+		flag.Visit(func(f *flag.Flag) { profileSet = profileSet || f.Name == "profile" })
+		if !profileSet {
+			*profile = filepath.Join(root, "profile")
+		}
+		log.Printf("✓ Portable mode: %s", root)
+	case *dataDir != "":
+		dirs = appdirs.In(*dataDir)
+	default:
+		var err error
+		if dirs, err = appdirs.Default(); err != nil {
+			log.Fatalf("Error: could not find the data directory, set one with -data-dir: %v", err)
+		}
+	}
+	if *debugDir == "" {
+		*debugDir = dirs.Debug
+	}
+
+	scripts.SetOverrideDir(*scriptsDir)
+	if *labelsFile != "" {
+		if err := locale.Load(dirs.ConfigFile(*labelsFile)); err != nil {
+			log.Fatalf("Error: -labels: %v", err)
+		}
+	}
+
+	// Keep recent log lines for debug bundles
+	collector := forensics.New(*debugDir)
+	log.SetOutput(logging.Timestamps(io.MultiWriter(console, collector)))
+
+	// Live profiling for long runs
+	if *debugAddr != "" {
+		addr, err := debugserver.Start(*debugAddr)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		log.Printf("🔬 Profiling: http://%s/debug/pprof/", addr)
+	}
+
+	// Expand ~ in download path
+	downloadPath := *downloadDir
+	if strings.HasPrefix(downloadPath, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			log.Fatalf("Error: could not find the home directory: %v", err)
+		}
+		downloadPath = filepath.Join(homeDir, downloadPath[2:])
+	}
+	// A templated download directory is resolved per date below its fixed part
+	var downloadTemplate string
+	if strings.Contains(downloadPath, "{") {
+		if err := datefilter.ValidateDirTemplate(downloadPath); err != nil {
+			log.Fatalf("Error: -download: %v", err)
+		}
+		downloadTemplate = downloadPath
+		downloadPath = datefilter.TemplateBase(downloadPath)
+	}
+
+	// Which mode: look a file up in the catalog, no browser needed
+	if *which != "" {
+		if err := runWhich(dirs, downloadPath, *which); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
+
+	// Diff mode: compare the catalog with the download directory, no browser needed
+	if *diff {
+		if err := runDiff(dirs, downloadPath, stderr); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
+
+	// Verify mode: check archives already on disk against the catalog, no browser needed
+	if *verifyDir != "" {
+		if err := runVerify(dirs, downloadPath, *verifyDir, stderr); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
+
 	// Auto-detect browser if not specified
 	browserExec := *execPath
 	if browserExec == "" {
@@ -58,26 +338,50 @@ func main() {
 		}
 		log.Printf("✓ Auto-detected browser: %s", browserExec)
 	}
+	// A token opens the Yandex session without the login page
+	if *token == "" {
+		*token = os.Getenv(auth.TokenEnv)
+	}
+	if *token != "" {
+		account, err := auth.ValidateToken(context.Background(), *token)
+		switch {
+		case errors.Is(err, auth.ErrInvalidToken):
+			log.Fatalf("Error: %v", err)
+		case err != nil:
+			log.Printf("⚠️ Could not validate the OAuth token: %v", err)
+		default:
+			log.Printf("✓ OAuth token of %s accepted by Yandex Disk", account.Login)
+		}
+	}
+	if browser.IsHeadlessShell(browserExec) && *token == "" {
+		log.Println("⚠️ chrome-headless-shell opens no window: the profile must already be logged in to Yandex")
+	}
 
-	// Expand ~ in download path
-	downloadPath := *downloadDir
-	if strings.HasPrefix(downloadPath, "~/") {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			log.Fatalf("Error getting home directory: %v", err)
+	// Login command: capture the session for later runs without a display
+	if command == "login" {
+		if err := runLogin(*profile, browserExec, *token); err != nil {
+			log.Fatalf("Error: %v", err)
 		}
-		downloadPath = filepath.Join(homeDir, downloadPath[2:])
+		return
+	}
+
+	// Replay mode: check selectors against saved snapshots, no Yandex access needed
+	if *replayDir != "" {
+		if err := runReplay(*profile, browserExec, *replayDir); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
 	}
 
 	// Create download directory if it doesn't exist
 	if err := os.MkdirAll(downloadPath, 0755); err != nil {
-		log.Fatalf("Error creating download directory: %v", err)
+		log.Fatalf("Error: could not create the download directory: %v", err)
 	}
 
 	// Clean download directory if requested
 	if *cleanDir {
 		if err := cleanDownloadDirectory(downloadPath); err != nil {
-			log.Fatalf("Error cleaning download directory: %v", err)
+			log.Fatalf("Error: could not clean the download directory: %v", err)
 		}
 		log.Printf("✓ Download directory cleaned: %s", downloadPath)
 	} else {
@@ -88,22 +392,366 @@ func main() {
 		}
 	}
 
+	// Warnings and errors also go to a file of their own, for triage after the run
+	var errorLog *logging.ErrorLog
+	if *errorLogPath != "" {
+		path := *errorLogPath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dirs.Logs, path)
+		}
+		var err error
+		if errorLog, err = logging.OpenErrorLog(path); err != nil {
+			log.Fatalf("Error: -error-log: %v", err)
+		}
+		log.SetOutput(io.MultiWriter(logging.Timestamps(io.MultiWriter(console, collector)), errorLog))
+	}
+
 	// Parse date range filter
 	dateRange, err := datefilter.NewDateRange(*fromDate, *toDate)
 	if err != nil {
-		log.Fatalf("Error parsing date range: %v", err)
+		log.Fatalf("Error: invalid date range: %v", err)
+	}
+	if *shards > 1 && !dateRange.Enabled {
+		log.Fatal("Error: -shards requires a date range (use -from and/or -to)")
+	}
+	var humanizer *browser.Humanizer
+	if *humanize {
+		if *humanizeDelay < 0 || *humanizeJitter < 0 || *humanizeJitter >= 1 {
+			log.Fatal("Error: -humanize-delay can't be negative and -humanize-jitter must be between 0 and 1")
+		}
+		humanizer = &browser.Humanizer{MaxDelay: *humanizeDelay, Jitter: *humanizeJitter}
+	}
+	if *dateRetries < 0 {
+		log.Fatal("Error: -date-retries can't be negative")
+	}
+	if *maxErrors < 1 {
+		log.Fatal("Error: -max-errors must be at least 1")
+	}
+	errorActions, err := exporter.ParseErrorActions(*onErrors)
+	if err != nil {
+		log.Fatalf("Error: -on-errors: %v", err)
+	}
+	var windowAt *image.Point
+	if *windowPos != "" {
+		windowAt = &image.Point{}
+		if n, err := fmt.Sscanf(*windowPos, "%d,%d", &windowAt.X, &windowAt.Y); n != 2 || err != nil {
+			log.Fatalf("Error: -window-pos: %q is not x,y", *windowPos)
+		}
+	}
+	if !slices.Contains(browser.WindowStates, *windowState) {
+		log.Fatalf("Error: -window-state: unknown state %q (use %s)", *windowState, strings.Join(browser.WindowStates, ", "))
+	}
+	if *shards > 1 && *dateLogs {
+		log.Fatal("Error: -date-logs can't be combined with -shards, whose log lines interleave")
+	}
+
+	// A page other than Yandex's, e.g. the fixture timeline for end-to-end tests
+	photosURL := yandexPhotosURL
+	switch *targetURL {
+	case "":
+	case "fixture":
+		u, err := fixture.Start("127.0.0.1:0")
+		if err != nil {
+			log.Fatalf("Error: -target-url: %v", err)
+		}
+		photosURL = u
+	default:
+		photosURL = *targetURL
+	}
+	if photosURL != yandexPhotosURL {
+		if err := netcheck.SetTarget(photosURL); err != nil {
+			log.Fatalf("Error: -target-url: %v", err)
+		}
+		log.Printf("🧪 Target: %s", photosURL)
+	}
+
+	// Fail early on network or disk problems instead of mid-run
+	if !*skipPreflight {
+		if err := preflight.Run(context.Background(), preflight.Config{
+			DownloadDir:  downloadPath,
+			MinFreeBytes: uint64(*minFreeGB * (1 << 30)),
+		}); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+
+	// A snap or flatpak browser can only write to some folders, and the
+	// downloads it can't write vanish without an error
+	var stagingDir string
+	if sb := browser.DetectSandbox(browserExec); sb != nil {
+		if ok, reason := sb.CanWrite(downloadPath); !ok {
+			stagingDir = sb.StagingDir()
+			if err := os.MkdirAll(stagingDir, 0755); err != nil {
+				log.Fatalf("Error: the %s browser can't write to %s (%s) and its staging folder %s could not be created: %v", sb.Kind, downloadPath, reason, stagingDir, err)
+			}
+			log.Printf("⚠️ The %s browser %s can't write to %s: %s", sb.Kind, sb.App, downloadPath, reason)
+			log.Printf("   Downloads are saved to %s and moved to %s when they finish", stagingDir, downloadPath)
+		}
+	}
+
+	filter, ok := navigation.Filters[strings.ToLower(*mode)]
+	if !ok {
+		log.Fatalf("Error: unknown -mode %q (use unlimited, services or all)", *mode)
+	}
+
+	if *deleteAfterVerify && *markAlbum != "" {
+		log.Fatal("Error: -mark-album and -delete-after-verify cannot be combined")
+	}
+	if *previewOnly && (*deleteAfterVerify || *markAlbum != "" || *extractArchives) {
+		log.Fatal("Error: -preview downloads nothing, so it cannot be combined with -delete-after-verify, -mark-album or -extract")
+	}
+	if *inventoryOnly && (*previewOnly || *deleteAfterVerify || *markAlbum != "" || *extractArchives) {
+		log.Fatal("Error: -inventory downloads nothing, so it cannot be combined with -preview, -delete-after-verify, -mark-album or -extract")
+	}
+	if *localMirror != "" && (*previewOnly || *inventoryOnly) {
+		log.Fatal("Error: -local-mirror skips downloads, so it cannot be combined with -preview or -inventory")
+	}
+	// Both confirmations read the same input, which may hold both answers
+	var confirmTrash exporter.TrashConfirm
+	if *deleteAfterVerify {
+		stdin := bufio.NewReader(os.Stdin)
+		if err := confirmDeleteAfterVerify(stdin); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		confirmTrash = confirmFirstTrash(stdin)
+	}
+
+	// Identifies this run in provenance tags and per-run files
+	runID := time.Now().Format("20060102-150405")
+	var extractOpts *extract.Options
+	var geoPoints *geo.Collector
+	if *extractArchives {
+		extractOpts = &extract.Options{}
+		if *fileTypes != "" {
+			if extractOpts.Types, err = extract.ParseTypes(*fileTypes); err != nil {
+				log.Fatalf("Error: -types: %v", err)
+			}
+		}
+		if *minSize != "" {
+			if extractOpts.MinSize, err = extract.ParseSize(*minSize); err != nil {
+				log.Fatalf("Error: -min-size: %v", err)
+			}
+		}
+		if *maxSize != "" {
+			if extractOpts.MaxSize, err = extract.ParseSize(*maxSize); err != nil {
+				log.Fatalf("Error: -max-size: %v", err)
+			}
+		}
+		if extractOpts.MaxSize > 0 && extractOpts.MinSize > extractOpts.MaxSize {
+			log.Fatal("Error: -min-size is larger than -max-size")
+		}
+		switch strings.ToLower(*extractStructure) {
+		case "preserve":
+		case "flat":
+			extractOpts.Flatten = true
+		default:
+			log.Fatalf("Error: unknown -extract-structure %q (use flat or preserve)", *extractStructure)
+		}
+		if *nameTemplate != "" {
+			if err := extract.ValidateNameTemplate(*nameTemplate); err != nil {
+				log.Fatalf("Error: -name-template: %v", err)
+			}
+			extractOpts.NameTemplate = *nameTemplate
+		}
+		if *normalize != "" {
+			if extractOpts.Normalize, err = extract.ParseNormalize(*normalize); err != nil {
+				log.Fatalf("Error: -normalize: %v", err)
+			}
+		}
+		if *dedup != "" {
+			if extractOpts.Dedup, err = extract.ParseDedup(*dedup); err != nil {
+				log.Fatalf("Error: -dedup: %v", err)
+			}
+		}
+		if *provenance {
+			extractOpts.Provenance = true
+			extractOpts.RunID = runID
+		}
+		if *reorganize {
+			extractOpts.ReorganizeDir = downloadPath
+		}
+		if *screenshotsDir != "" && !*skipScreenshots {
+			log.Fatal("Error: -screenshots-dir requires -skip-screenshots")
+		}
+		extractOpts.SkipScreenshots = *skipScreenshots
+		extractOpts.ScreenshotsDir = *screenshotsDir
+		if extractOpts.ScreenshotsDir != "" && !filepath.IsAbs(extractOpts.ScreenshotsDir) {
+			extractOpts.ScreenshotsDir = filepath.Join(downloadPath, extractOpts.ScreenshotsDir)
+		}
+		if *geoFormat != "" {
+			if geoPoints, err = geo.New(*geoFormat, downloadPath, runID); err != nil {
+				log.Fatalf("Error: -geo: %v", err)
+			}
+		}
+	} else if *fileTypes != "" || *minSize != "" || *maxSize != "" || *nameTemplate != "" || *normalize != "" || *dedup != "" || *provenance || *reorganize || *geoFormat != "" || *skipScreenshots {
+		log.Fatal("Error: -types, -min-size, -max-size, -name-template, -normalize, -dedup, -provenance, -reorganize, -geo and -skip-screenshots require -extract")
+	}
+
+	var pipelineCfg *pipeline.Config
+	if *pipelineFile != "" {
+		if *extractArchives {
+			log.Fatal("Error: -pipeline replaces -extract; add an extract step to the pipeline instead")
+		}
+		if pipelineCfg, err = pipeline.Load(dirs.ConfigFile(*pipelineFile)); err != nil {
+			log.Fatalf("Error: -pipeline: %v", err)
+		}
+	}
+
+	cat, err := openCatalog(dirs, downloadPath)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if extractOpts != nil && extractOpts.Dedup != "" {
+		extractOpts.Hashes = cat // Finds duplicates across dates and runs
+	}
+	var annotationsFile *annotations.File
+	if *saveAnnotations {
+		if annotationsFile, err = annotations.Open(filepath.Join(downloadPath, annotations.FileName)); err != nil {
+			log.Fatalf("Error: -annotations: %v", err)
+		}
+	}
+	if *blacklistAfter < 0 {
+		log.Fatal("Error: -blacklist-after can't be negative")
+	}
+	cat.SetBlacklistAfter(*blacklistAfter)
+
+	// Status file for monitoring without the web dashboard
+	var progressFile *progress.File
+	if *progressEvery > 0 {
+		if emitter == nil {
+			emitter = events.New(nil)
+		}
+		progressFile = progress.New(filepath.Join(dirs.State, progress.FileName), emitter, expectedDates(cat, dateRange))
+		progressFile.Start(*progressEvery)
+	}
+
+	var notifier *notify.Notifier
+	if *webhook != "" {
+		if emitter == nil {
+			emitter = events.New(nil)
+		}
+		notifier = notify.New(*webhook, emitter, expectedDates(cat, dateRange), *webhookEveryDates, *webhookEvery)
+	} else if *webhookEvery != 0 || *webhookEveryDates != 0 {
+		log.Fatal("Error: -webhook-every and -webhook-every-dates require -webhook")
+	}
+
+	// Per-date logs receive the same lines as the terminal
+	var dateLogger *datelog.Logger
+	if *dateLogs {
+		dateLogger = datelog.New(dirs.Logs)
+		log.SetOutput(io.MultiWriter(logging.Timestamps(io.MultiWriter(console, collector)), errorLog, dateLogger))
 	}
 
 	log.Println("=== Yandex Photo Downloader ===")
 	log.Printf("Executable: %s", browserExec)
 	log.Printf("Profile: %s", *profile)
 	log.Printf("Download: %s", downloadPath)
+	log.Printf("Catalog: %s", cat.Path())
 	log.Printf("Batch: %d dates at a time", *batchSize)
 	if dateRange.Enabled {
 		log.Printf("Date range: %s", dateRange)
 	}
+	if *reloadEvery > 0 {
+		log.Printf("Reload: every %d dates", *reloadEvery)
+	}
+
+	var mirrorIndex *mirror.Index
+	if *localMirror != "" {
+		dir := *localMirror
+		if dir == "auto" {
+			if dir = mirror.Detect(); dir == "" {
+				log.Fatal("Error: -local-mirror: no Yandex.Disk folder found; give its path instead of auto")
+			}
+		}
+		var err error
+		if mirrorIndex, err = mirror.Load(dir); err != nil {
+			log.Fatalf("Error: -local-mirror: %v", err)
+		}
+		log.Printf("Local mirror: %s (%d files)", dir, mirrorIndex.Files())
+	}
+
+	var tracer *tracing.Tracer
+	if *otlpEndpoint != "" {
+		tracer = tracing.New(*otlpEndpoint, appVersion)
+		log.Printf("Tracing: %s", *otlpEndpoint)
+	}
+
+	opts := options{
+		profile:          *profile,
+		batchSize:        *batchSize,
+		execPath:         browserExec,
+		downloadDir:      downloadPath,
+		stagingDir:       stagingDir,
+		dateRange:        dateRange,
+		reloadEvery:      *reloadEvery,
+		maxInFlight:      *maxInFlight,
+		token:            *token,
+		dateRetries:      *dateRetries,
+		errorThreshold:   *maxErrors,
+		errorActions:     errorActions,
+		heartbeat:        *heartbeat,
+		keepAlive:        *keepAlive,
+		humanize:         humanizer,
+		keyboard:         *keyboard,
+		adaptiveDelays:   *adaptiveDelays,
+		windowPos:        windowAt,
+		windowState:      *windowState,
+		retryBlacklisted: *retryBlacklisted,
+		dateTimeout:      *dateTimeout,
+		recordDir:        *recordDir,
+		photosURL:        photosURL,
+		exitWhenDone:     *targetURL == "fixture",
+		forensics:        collector,
+		forceEnglish:     *forceEnglish,
+		filter:           filter,
+		tracer:           tracer,
+		events:           emitter,
+		control:          ctl,
+		preview:          *previewOnly,
+		inventory:        *inventoryOnly,
+		mirror:           mirrorIndex,
+		trash:            *deleteAfterVerify,
+		confirmTrash:     confirmTrash,
+		markAlbum:        *markAlbum,
+		catalog:          cat,
+		extract:          extractOpts,
+		pipeline:         pipelineCfg,
+		subdirs:          *subdirs,
+		downloadTemplate: downloadTemplate,
+		geo:              geoPoints,
+		annotations:      annotationsFile,
+		jsonSummary:      *jsonSummary,
+		progress:         progressFile,
+		notifier:         notifier,
+		dateLogs:         dateLogger,
+		errorLog:         errorLog,
+	}
+	// Without post-processing, an archive already on disk is all a date needs
+	opts.skipArchived = !*redownload && extractOpts == nil && pipelineCfg == nil &&
+		!*previewOnly && !*inventoryOnly && !*deleteAfterVerify && *markAlbum == ""
+	// With all photos shown, each storage gets its own folder
+	if filter == navigation.All && !*previewOnly && !*inventoryOnly {
+		opts.sources = &dateSources{sources: make(map[string]string)}
+	}
+	// A crash still saves the catalog and finishes the progress file, the
+	// event stream and the webhook, like an error would
+	crash.OnCrash(func(reason error) {
+		if *quiet {
+			fmt.Fprintf(stderr, "Error: %v\n", reason)
+		}
+		opts.events.Emit(events.Event{Type: events.Error, Message: reason.Error()})
+		opts.catalog.Flush()
+		opts.progress.Close()
+		opts.notifier.Finish("", reason)
+	})
 
-	if err := run(*profile, *batchSize, browserExec, downloadPath, dateRange); err != nil {
+	if *shards > 1 {
+		err = runSharded(opts, *shards)
+	} else {
+		err = run(opts)
+	}
+	if err != nil {
+		log.SetOutput(io.MultiWriter(logging.Timestamps(stderr), errorLog))
 		log.Fatalf("Error: %v", err)
 	}
 }
@@ -125,16 +773,406 @@ func cleanDownloadDirectory(dir string) error {
 	return nil
 }
 
-func run(profile string, batchSize int, execPath string, downloadDir string, dateRange *datefilter.DateRange) error {
-	// Initialize stats for final report
-	stats := report.New()
-	stats.SetDownloadDir(downloadDir)
+// trashConfirmation must be typed to enable -delete-after-verify.
+const trashConfirmation = "MOVE TO TRASH"
 
-	// Initialize browser
+// confirmDeleteAfterVerify explains what -delete-after-verify does and asks
+// the user to type trashConfirmation.
+func confirmDeleteAfterVerify(in *bufio.Reader) error {
+	fmt.Fprintln(os.Stderr, "\n⚠️  -delete-after-verify is enabled.")
+	fmt.Fprintln(os.Stderr, "After each date's download has finished and been verified (file count and")
+	fmt.Fprintln(os.Stderr, "checksums of the archive), its photos will be MOVED TO THE YANDEX DISK TRASH.")
+	fmt.Fprintln(os.Stderr, "Dates that fail verification are kept. Keep the downloaded archives safe and")
+	fmt.Fprintln(os.Stderr, "do not empty the Trash until you have checked your backup.")
+	fmt.Fprintln(os.Stderr, "Before the first date is moved, you will be asked again with its photo count.")
+	fmt.Fprintf(os.Stderr, "\nType %q to continue: ", trashConfirmation)
+
+	answer, err := in.ReadString('\n')
+	if err != nil && answer == "" {
+		return fmt.Errorf("-delete-after-verify not confirmed: %w", err)
+	}
+	if strings.TrimSpace(answer) != trashConfirmation {
+		return errors.New("-delete-after-verify not confirmed")
+	}
+	return nil
+}
+
+// confirmFirstTrash returns the second -delete-after-verify confirmation:
+// before the first verified date of the run is moved to the Trash, it shows
+// its photo count and asks the user to type it. Later dates, including
+// those of other shards and of a restarted browser, are moved without
+// asking.
+func confirmFirstTrash(in *bufio.Reader) exporter.TrashConfirm {
+	var (
+		mu        sync.Mutex
+		confirmed bool
+	)
+	return func(date string, count int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if confirmed {
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "\n⚠️  '%s' has been downloaded and verified: %d photos.\n", date, count)
+		fmt.Fprintln(os.Stderr, "They will be MOVED TO THE YANDEX DISK TRASH now, and so will the photos of")
+		fmt.Fprintln(os.Stderr, "every other date this run verifies, without asking again.")
+		fmt.Fprintf(os.Stderr, "\nType the number of photos (%d) to continue: ", count)
+
+		answer, err := in.ReadString('\n')
+		if err != nil && answer == "" {
+			return fmt.Errorf("no answer: %w", err)
+		}
+		if strings.TrimSpace(answer) != strconv.Itoa(count) {
+			return fmt.Errorf("%q is not %d", strings.TrimSpace(answer), count)
+		}
+		confirmed = true
+		return nil
+	}
+}
+
+// options holds the settings for a single export run.
+type options struct {
+	profile          string
+	batchSize        int
+	execPath         string
+	downloadDir      string
+	stagingDir       string // Where a sandboxed browser saves downloads before they are moved to downloadDir (empty: downloadDir)
+	dateRange        *datefilter.DateRange
+	reloadEvery      int                    // Reload the page every N processed dates (0 disables)
+	maxInFlight      int                    // Archives outstanding before the loop waits (0 is unbounded)
+	dateRetries      int                    // Retries of a failed date before it is skipped
+	errorThreshold   int                    // Failed attempts in a row that trigger the next of errorActions
+	errorActions     []exporter.ErrorAction // Recovery actions for consecutive errors, escalated in order
+	resumeScrollY    float64                // Scroll offset a restarted browser carries on from (0 starts at the top)
+	retryBlacklisted bool                   // Process blacklisted dates instead of skipping them
+	heartbeat        time.Duration          // Interval of the page responsiveness check (0 disables)
+	keepAlive        time.Duration          // Interval of the activity that keeps the session alive while waiting for archives (0 disables)
+	humanize         *browser.Humanizer     // Humanized input timing and mouse paths (nil disables)
+	keyboard         bool                   // Activate elements with key presses instead of clicks where possible
+	adaptiveDelays   bool                   // Adapt waits and pauses to how fast the page reacts
+	windowPos        *image.Point           // Top-left corner of the browser window (nil leaves it to the window manager)
+	windowState      string                 // Browser window state: normal, minimized or maximized
+	recordDir        string                 // Directory for development snapshots (empty disables)
+	photosURL        string                 // Timeline page: Yandex Disk Photos, or the -target-url page
+	exitWhenDone     bool                   // Close the browser and exit after the report instead of waiting for Ctrl+C
+	token            string                 // Yandex OAuth token that opens the session instead of the login page (empty disables)
+	forensics        *forensics.Collector   // Collects evidence for debug bundles
+	dateTimeout      time.Duration          // Watchdog deadline for each date's select→download→deselect cycle
+	forceEnglish     bool                   // Request the English Yandex interface
+	filter           navigation.Filter      // Timeline section to export (see -mode)
+	tracer           *tracing.Tracer        // Exports per-date spans (nil disables)
+	events           *events.Emitter        // JSON-lines event stream (nil disables)
+	control          *control.Controller    // Pause/resume/stop requests
+	trash            bool                   // Move verified dates to the Yandex Trash
+	confirmTrash     exporter.TrashConfirm  // Asked before the first verified date is moved to the Trash
+	markAlbum        string                 // Add verified dates to this Yandex album (empty disables)
+	preview          bool                   // Save thumbnails instead of downloading
+	inventory        bool                   // List the files of each date in the catalog instead of downloading
+	mirror           *mirror.Index          // Local Yandex.Disk folder whose dates are skipped (nil disables)
+	skipArchived     bool                   // Skip dates whose archives from an earlier run are still on disk
+	catalog          *catalog.Catalog       // Persistent record of seen and exported dates
+	extract          *extract.Options       // Extract downloaded archives (nil disables)
+	pipeline         *pipeline.Config       // Post-processing steps for finished downloads, instead of extract (nil disables)
+	subdirs          bool                   // One folder per date in the download directory
+	downloadTemplate string                 // -download with {year}/{month}/{day}/{date} tokens; downloadDir is its fixed part
+	geo              *geo.Collector         // Locations of geotagged photos (nil disables)
+	annotations      *annotations.File      // Titles and descriptions of photos (nil disables)
+	jsonSummary      bool                   // Print the stats as JSON and exit instead of the report
+	progress         *progress.File         // Periodically written status file (nil disables)
+	notifier         *notify.Notifier       // Progress webhook (nil disables)
+	dateLogs         *datelog.Logger        // Per-date log files (nil disables)
+	errorLog         *logging.ErrorLog      // Warnings and errors file, told the date being processed (nil disables)
+	sources          *dateSources           // Storage of each date, which picks its folder with -mode all (nil disables)
+}
+
+// dateSources records the storage each date's photos are in, unlimited or
+// disk, so -mode all can save them in separate folders.
+type dateSources struct {
+	mu      sync.Mutex
+	sources map[string]string
+}
+
+// set records the storage of date.
+func (s *dateSources) set(date, source string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sources[date] = source
+}
+
+// get returns the storage of date, or "" if unknown.
+func (s *dateSources) get(date string) string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sources[date]
+}
+
+// postProcessor handles finished downloads in the background: an
+// extract.Worker for -extract or a pipeline.Pipeline for -pipeline.
+type postProcessor interface {
+	Add(f download.File)
+	Close() (files, skipped int)
+	Duplicates() (files int, bytes int64)
+}
+
+func run(opts options) error {
+	opts.events.Emit(events.Event{Type: events.RunStarted})
+	stats, browserCtx, err := exportRestarting(opts)
+	// Send the remaining spans and save the catalog before the process
+	// blocks or exits
+	opts.tracer.Shutdown()
+	opts.catalog.Flush()
+	writeGeo(opts.geo)
+	if err != nil {
+		opts.events.Emit(events.Event{Type: events.Error, Message: err.Error()})
+		opts.progress.Close()
+		opts.notifier.Finish("", err)
+		return err
+	}
+	addBlacklisted(stats, opts)
+	opts.events.Emit(events.Event{Type: events.RunFinished, Message: stats.Summary()})
+	opts.progress.Close()
+	opts.notifier.Finish(stats.Summary(), nil)
+	defer browserCtx.Close()
+
+	// Print final report
+	if opts.jsonSummary {
+		return stats.WriteJSON(os.Stdout)
+	}
+	stats.Print()
+	if opts.exitWhenDone {
+		return nil
+	}
+
+	log.Println("Browser remains open. Press Ctrl+C to exit.")
+
+	select {}
+}
+
+// addBlacklisted lists in stats the blacklisted dates of the range that the
+// run came across, skipped or retried, including those it blacklisted.
+func addBlacklisted(stats *report.Stats, opts options) {
+	for _, e := range opts.catalog.Blacklist() {
+		if e.LastSeen.Before(stats.StartTime) {
+			continue
+		}
+		if in, err := opts.dateRange.IsInRange(e.Date); err == nil && !in {
+			continue
+		}
+		stats.AddBlacklisted(e.Date, e.Error, e.Failures, !e.Blacklisted.Before(stats.StartTime))
+	}
+}
+
+// runSharded splits the date range into shards and exports them concurrently,
+// each in its own browser window and profile, then prints the merged report.
+func runSharded(opts options, shards int) error {
+	ranges := opts.dateRange.Split(shards)
+	opts.events.Emit(events.Event{Type: events.RunStarted})
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		parts    []*report.Stats
+		browsers []*browser.Context
+	)
+
+	for i, dr := range ranges {
+		shardOpts := opts
+		shardOpts.dateRange = dr
+		// Concurrent shards process different dates; their lines still reach
+		// the error log, without a date
+		shardOpts.errorLog = nil
+		// The first shard reuses the main profile; the others need their own
+		// because Chrome locks a profile directory to a single instance.
+		if i > 0 {
+			shardOpts.profile = fmt.Sprintf("%s-shard-%d", opts.profile, i+1)
+		}
+		log.Printf("Shard %d/%d: %s (profile: %s)", i+1, len(ranges), dr, shardOpts.profile)
+
+		wg.Add(1)
+		go func(shard int, shardOpts options) {
+			defer crash.Recover()
+			defer wg.Done()
+			stats, browserCtx, err := exportRestarting(shardOpts)
+			if err != nil {
+				log.Printf("⚠️ Shard %d failed: %v", shard, err)
+				opts.events.Emit(events.Event{Type: events.Error, Message: fmt.Sprintf("shard %d: %v", shard, err)})
+				return
+			}
+			mu.Lock()
+			parts = append(parts, stats)
+			browsers = append(browsers, browserCtx)
+			mu.Unlock()
+		}(i+1, shardOpts)
+	}
+	wg.Wait()
+	opts.tracer.Shutdown()
+	opts.catalog.Flush()
+	writeGeo(opts.geo)
+
+	for _, b := range browsers {
+		defer b.Close()
+	}
+	if len(parts) == 0 {
+		err := fmt.Errorf("all %d shards failed", len(ranges))
+		opts.progress.Close()
+		opts.notifier.Finish("", err)
+		return err
+	}
+
+	// Print merged report
+	merged := report.Merge(parts...)
+	addBlacklisted(merged, opts)
+	opts.events.Emit(events.Event{Type: events.RunFinished, Message: merged.Summary()})
+	opts.progress.Close()
+	opts.notifier.Finish(merged.Summary(), nil)
+	if opts.jsonSummary {
+		return merged.WriteJSON(os.Stdout)
+	}
+	merged.Print()
+	if opts.exitWhenDone {
+		return nil
+	}
+
+	log.Println("Browsers remain open. Press Ctrl+C to exit.")
+
+	select {}
+}
+
+// dateDir returns the folder a date's archive is moved to: the download
+// template resolved for the date, plus a folder named after the date with
+// -subdirs, all under a folder named after the date's storage with -mode
+// all. Dates the template cannot be resolved for go to downloadDir.
+func (o options) dateDir(date string) string {
+	base := o.downloadDir
+	if source := o.sources.get(date); source != "" {
+		base = filepath.Join(base, source)
+	}
+	dir := base
+	if o.downloadTemplate != "" {
+		resolved, err := datefilter.ExpandDirTemplate(o.downloadTemplate, date)
+		if err != nil {
+			log.Printf("⚠️ Could not resolve the download directory for '%s': %v", date, err)
+			return o.downloadDir
+		}
+		// The template's fixed part is downloadDir
+		if rel, err := filepath.Rel(o.downloadDir, resolved); err == nil {
+			dir = filepath.Join(base, rel)
+		} else {
+			dir = resolved
+		}
+	}
+	if o.subdirs {
+		dir = filepath.Join(dir, datefilter.DirName(date))
+	}
+	return dir
+}
+
+// expectedDates returns how many dates in the range the catalog knows from
+// earlier runs, as the progress file's estimate of the run's length.
+func expectedDates(cat *catalog.Catalog, dr *datefilter.DateRange) int {
+	n := 0
+	for _, e := range cat.Entries() {
+		if ok, err := dr.IsInRange(e.Date); err == nil && ok {
+			n++
+		}
+	}
+	return n
+}
+
+// writeGeo writes the locations collected during the run, if any.
+func writeGeo(c *geo.Collector) {
+	path, n, err := c.Write()
+	switch {
+	case err != nil:
+		log.Printf("⚠️ Could not write photo locations: %v", err)
+	case n > 0:
+		log.Printf("🗺️ Wrote %d photo locations to %s", n, path)
+	}
+}
+
+// openCatalog opens the catalog of downloadDir in the state directory.
+func openCatalog(dirs appdirs.Dirs, downloadDir string) (*catalog.Catalog, error) {
+	path, moved, err := dirs.Catalog(downloadDir)
+	if err != nil {
+		return nil, err
+	}
+	if moved {
+		log.Printf("✓ Moved the catalog from %s to %s", downloadDir, path)
+	}
+	return catalog.Open(path, downloadDir)
+}
+
+// runWhich prints the dates the catalog records for file.
+func runWhich(dirs appdirs.Dirs, downloadDir, file string) error {
+	cat, err := openCatalog(dirs, downloadDir)
+	if err != nil {
+		return err
+	}
+	entries := cat.Lookup(file)
+	if len(entries) == 0 {
+		return fmt.Errorf("%s is not in the catalog of %s", file, downloadDir)
+	}
+	for _, e := range entries {
+		details := string(e.Status)
+		if e.Items > 0 {
+			details = fmt.Sprintf("%d items, %s", e.Items, details)
+		}
+		fmt.Printf("%s: %s (%s)\n", file, e.Date, details)
+	}
+	return nil
+}
+
+// runDiff compares the catalog with the files in the download directory and
+// writes the result to out.
+func runDiff(dirs appdirs.Dirs, downloadDir string, out io.Writer) error {
+	cat, err := openCatalog(dirs, downloadDir)
+	if err != nil {
+		return err
+	}
+	if len(cat.Entries()) == 0 {
+		return fmt.Errorf("no catalog in %s yet; run an export first", downloadDir)
+	}
+	d, err := cat.Compare()
+	if err != nil {
+		return err
+	}
+	d.Print(out)
+	if !d.Complete() {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// runVerify tests the archives in dir and reports the catalog dates that
+// need downloading again to out.
+func runVerify(dirs appdirs.Dirs, downloadDir, dir string, out io.Writer) error {
+	cat, err := openCatalog(dirs, downloadDir)
+	if err != nil {
+		return err
+	}
+	if len(cat.Entries()) == 0 {
+		return fmt.Errorf("no catalog in %s yet; run an export first", downloadDir)
+	}
+	log.Printf("Testing the archives in %s...", dir)
+	v, err := cat.VerifyArchives(dir)
+	if err != nil {
+		return err
+	}
+	v.Print(out)
+	if !v.Complete() {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// runReplay opens a browser and replays the selection logic against saved snapshots.
+func runReplay(profile, execPath, dir string) error {
 	cfg := browser.DefaultConfig()
 	cfg.ExecPath = execPath
 	cfg.ProfilePath = profile
-	cfg.DownloadDir = downloadDir
 
 	browserCtx, err := browser.New(cfg)
 	if err != nil {
@@ -142,201 +1180,489 @@ func run(profile string, batchSize int, execPath string, downloadDir string, dat
 	}
 	defer browserCtx.Close()
 
-	ctx := browserCtx.Ctx
+	log.Printf("=== Replaying snapshots from %s ===", dir)
+	results, err := snapshot.Replay(browserCtx.Ctx, dir)
+	if err != nil {
+		return err
+	}
+	if failed := snapshot.PrintResults(results); failed > 0 {
+		return fmt.Errorf("%d snapshot(s) failed", failed)
+	}
+	return nil
+}
 
-	// 1. Open page
-	log.Println("Opening Yandex Disk Photos...")
-	if err := browser.Navigate(ctx, yandexPhotosURL); err != nil {
+// runLogin opens the browser only to log in to Yandex, checks that the
+// session works and closes the browser cleanly, so the profile keeps the
+// session for later runs, e.g. headless or scheduled ones.
+func runLogin(profile, execPath, token string) error {
+	if browser.IsHeadlessShell(execPath) && token == "" {
+		return fmt.Errorf("%s has no window to log in with; use a regular browser with -exec, or -token", filepath.Base(execPath))
+	}
+	cfg := browser.DefaultConfig()
+	cfg.ExecPath = execPath
+	cfg.ProfilePath = profile
+
+	browserCtx, err := browser.New(cfg)
+	if err != nil {
 		return err
 	}
+	defer browserCtx.Close()
+	ctx := browserCtx.Ctx
 
-	// Configure download directory
-	if err := browser.ConfigureDownloads(ctx, downloadDir); err != nil {
-		log.Printf("⚠️ Warning: could not configure download directory: %v", err)
+	log.Println("=== Logging in to Yandex ===")
+	log.Printf("Profile: %s", profile)
+	if err := browser.Navigate(ctx, yandexPhotosURL); err != nil {
+		return err
 	}
+	browser.DismissDialogs(ctx)
+	overlay.AcceptCookies(ctx)
 
-	// 2. Check login status
-	isLoggedIn, err := auth.CheckLoginStatus(ctx)
+	loggedIn, err := auth.CheckLoginStatus(ctx)
 	if err != nil {
 		log.Printf("Warning: could not check login status: %v", err)
 	}
-
-	if !isLoggedIn {
+	if !loggedIn && token != "" {
+		if err := auth.LoginWithToken(ctx, token, yandexPhotosURL); err != nil {
+			if browser.IsHeadlessShell(execPath) {
+				return fmt.Errorf("could not log in with the OAuth token: %w", err)
+			}
+			log.Printf("⚠️ Could not log in with the OAuth token: %v", err)
+		} else {
+			loggedIn = true
+		}
+	}
+	if !loggedIn {
 		if err := auth.WaitForLogin(ctx); err != nil {
 			return err
 		}
-
-		// Navigate to photos after successful login
+		// Verify the session on the photos page itself, where exports start
 		if err := browser.Navigate(ctx, yandexPhotosURL); err != nil {
-			log.Printf("Warning: could not navigate after login: %v", err)
+			return err
+		}
+		if loggedIn, err = auth.CheckLoginStatus(ctx); err != nil {
+			return fmt.Errorf("could not verify the session: %w", err)
+		} else if !loggedIn {
+			return errors.New("logged in, but Yandex Disk Photos still asks for a login")
 		}
 	}
 
-	log.Println("✓ User is logged in")
+	if err := browserCtx.Shutdown(); err != nil {
+		log.Printf("⚠️ The browser did not close cleanly, the session may not be saved: %v", err)
+	}
+	log.Printf("✓ Logged in. The session is saved in %s; later runs with this -profile need no display", profile)
+	return nil
+}
 
-	// 3. Apply filter to show only photos from unlimited storage
-	log.Println("Applying filter for unlimited storage photos...")
-	if err := navigation.FilterByUnlimitedStorage(ctx); err != nil {
-		log.Printf("⚠️ Warning: could not apply filter: %v", err)
-		log.Println("Continuing without filter - all photos will be processed")
+// placeWindow moves and minimizes or maximizes the browser window as
+// -window-pos and -window-state ask.
+func placeWindow(ctx context.Context, opts options) {
+	if browser.IsHeadlessShell(opts.execPath) {
+		return
+	}
+	if opts.windowPos != nil {
+		if err := browser.MoveWindow(ctx, opts.windowPos.X, opts.windowPos.Y); err != nil {
+			log.Printf("⚠️ Could not move the browser window: %v", err)
+		}
+	}
+	if opts.windowState != browser.WindowNormal {
+		if err := browser.SetWindowState(ctx, opts.windowState); err != nil {
+			log.Printf("⚠️ Could not set the browser window %s: %v", opts.windowState, err)
+		}
 	}
+}
 
-	// Wait for page to update after filter
-	time.Sleep(2 * time.Second)
+// showWindow brings a window placed out of the way by placeWindow into
+// view, so the user can log in.
+func showWindow(ctx context.Context, opts options) {
+	if opts.windowPos == nil && opts.windowState != browser.WindowMinimized {
+		return
+	}
+	log.Println("🪟 Showing the browser window to log in")
+	err := browser.SetWindowState(ctx, browser.WindowNormal)
+	if err == nil && opts.windowPos != nil {
+		err = browser.MoveWindow(ctx, 50, 50)
+	}
+	if err != nil {
+		log.Printf("⚠️ Could not show the browser window: %v", err)
+	}
+}
 
-	// 4. Main loop - process one date at a time
-	emptyRounds := 0
-	consecutiveErrors := 0
-	const maxConsecutiveErrors = 3
-	var currentDateInfo string // Track current date for error reporting
+// readStorage reads the account's storage usage from the page and logs it,
+// or returns false if the page doesn't show it.
+func readStorage(ctx context.Context, when string) (quota.Usage, bool) {
+	usage, err := quota.Read(ctx)
+	if err != nil {
+		log.Printf("⚠️ Could not read the account's storage at the %s of the run: %v", when, err)
+		return quota.Usage{}, false
+	}
+	log.Printf("☁️ Storage used at the %s of the run: %s", when, usage)
+	return usage, true
+}
 
+// exportRestarting runs export, starting a new browser where the last one
+// stopped each time -on-errors restarts it, and merges the stats of the
+// browsers it went through.
+func exportRestarting(opts options) (*report.Stats, *browser.Context, error) {
+	var parts []*report.Stats
 	for {
-		// Check if browser/context is still valid
-		if browser.IsContextCanceled(ctx) {
-			log.Println("\n⚠️ Browser was closed. Exiting gracefully...")
-			break
+		stats, browserCtx, err := export(opts)
+		var restart *exporter.RestartError
+		if !errors.As(err, &restart) {
+			if err == nil && len(parts) > 0 {
+				stats = report.Merge(append(parts, stats)...)
+			}
+			return stats, browserCtx, err
 		}
+		log.Printf("🔄 Restarting the browser after %d consecutive errors...", restart.Failures)
+		parts = append(parts, stats)
+		opts.resumeScrollY = restart.ScrollY
+		opts.errorActions = restart.Actions
+	}
+}
 
-		log.Printf("\n--- Processing date %d ---", stats.DatesProcessed+1)
+// export runs the export loop in a new browser and returns the collected stats.
+// The browser is left open on success; the caller is responsible for closing it.
+func export(opts options) (stats *report.Stats, browserCtx *browser.Context, err error) {
+	// Initialize stats for final report
+	stats = report.New()
+	stats.SetDownloadDir(opts.downloadDir)
 
-		// Check for pending selection and clear it
-		selection.ClearPendingSelection(ctx)
+	// Initialize browser
+	cfg := browser.DefaultConfig()
+	cfg.ExecPath = opts.execPath
+	cfg.ProfilePath = opts.profile
+	cfg.DownloadDir = opts.downloadDir
+	if opts.windowPos != nil {
+		cfg.WindowPosition = fmt.Sprintf("%d,%d", opts.windowPos.X, opts.windowPos.Y)
+	}
+
+	photosURL := opts.photosURL
+	if opts.forceEnglish {
+		// Accept-Language alone is ignored once the account has a saved
+		// language, so also ask Yandex for English through the URL
+		cfg.Language = englishLocale
+		photosURL += "?lang=en"
+	}
 
-		// Select the FIRST visible date (always the top one)
-		dateInfo, err := selection.SelectFirstVisibleDate(ctx)
+	browserCtx, err = browser.New(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Error returns set browserCtx to nil, so the cleanup keeps its own copy
+	opened := browserCtx
+	defer func() {
 		if err != nil {
-			// Check if this is a fatal error (browser closed)
-			if browser.IsBrowserClosed(err) {
-				log.Println("\n⚠️ Browser was closed. Exiting gracefully...")
-				break
-			}
-			log.Printf("Error selecting: %v", err)
-			consecutiveErrors++
-			if consecutiveErrors >= maxConsecutiveErrors {
-				log.Printf("⚠️ Too many consecutive errors (%d). Browser may be unresponsive.", consecutiveErrors)
-				// Double-check if context is still valid
-				if browser.IsContextCanceled(ctx) {
-					log.Println("Browser context is no longer valid. Exiting...")
-					break
-				}
+			// Save evidence while the browser is still open
+			if bundle, dumpErr := opts.forensics.Dump(opened.Ctx, err); dumpErr == nil {
+				err = fmt.Errorf("%w (debug bundle: %s)", err, bundle)
 			}
-			time.Sleep(1 * time.Second)
-			continue
+			opened.Close()
+		}
+	}()
+	// A crash saves the page like an error does, and the report so far,
+	// while this browser is in use; a restart registers its own
+	unregister := crash.OnCrash(func(reason error) {
+		bundle, err := opts.forensics.Dump(opened.Ctx, reason)
+		if err != nil {
+			log.Printf("⚠️ Could not save a debug bundle: %v", err)
+			return
 		}
-		consecutiveErrors = 0 // Reset on success
+		f, err := os.Create(filepath.Join(bundle, "stats.json"))
+		if err != nil {
+			log.Printf("⚠️ Could not save the report so far: %v", err)
+			return
+		}
+		defer f.Close()
+		if err := stats.WriteJSON(f); err != nil {
+			log.Printf("⚠️ Could not save the report so far: %v", err)
+			return
+		}
+		log.Printf("🧾 Report so far saved: %s", f.Name())
+	})
+	defer unregister()
 
-		if dateInfo == nil {
-			log.Println("No date found, scrolling...")
-			if err := navigation.ScrollDown(ctx); err != nil {
-				if browser.IsBrowserClosed(err) {
-					log.Println("\n⚠️ Browser was closed. Exiting gracefully...")
-					break
-				}
-				log.Printf("Warning: scroll failed: %v", err)
-			}
-			time.Sleep(3 * time.Second)
+	ctx := browserCtx.Ctx
+	if opts.humanize != nil {
+		ctx = browser.Humanize(ctx, *opts.humanize)
+	}
+	if opts.keyboard {
+		ctx = browser.WithKeyboard(ctx)
+	}
+	var pacing *browser.Pacing
+	if opts.adaptiveDelays {
+		pacing = browser.NewPacing()
+		ctx = browser.WithPacing(ctx, pacing)
+	}
 
-			emptyRounds++
-			if emptyRounds >= 5 {
-				log.Println("End of photos!")
-				break
-			}
-			continue
+	// Record snapshots of key UI states if requested
+	if opts.recordDir != "" {
+		recorder, err := snapshot.NewRecorder(opts.recordDir)
+		if err != nil {
+			return nil, nil, err
 		}
+		ctx = snapshot.WithRecorder(ctx, recorder)
+		log.Printf("📸 Recording snapshots to: %s", opts.recordDir)
+	}
 
-		emptyRounds = 0
-		currentDateInfo = dateInfo.Text
-		log.Println("✓ Date found: " + dateInfo.Text)
+	// Keep the window out of the way before the first page shows
+	placeWindow(ctx, opts)
 
-		// Check if date is within the specified range
-		if dateRange.Enabled {
-			inRange, err := dateRange.IsInRange(dateInfo.Text)
-			if err != nil {
-				log.Printf("⚠️ Could not parse date '%s': %v", dateInfo.Text, err)
-				// Continue processing anyway if date can't be parsed
-			} else if !inRange {
-				// Check if we're past the range (dates are in reverse chronological order)
-				if dateRange.IsBeforeRange(dateInfo.Text) {
-					log.Printf("📅 Date '%s' is before the specified range. Stopping.", dateInfo.Text)
-					// Deselect before stopping
-					selection.Deselect(ctx)
-					break
-				}
-				// Date is after range, skip it and scroll
-				log.Printf("📅 Date '%s' is after the specified range. Skipping...", dateInfo.Text)
-				stats.IncrementSkippedDates()
-				selection.Deselect(ctx)
-				time.Sleep(500 * time.Millisecond)
-				if err := navigation.ScrollToPosition(ctx, dateInfo.YPosition); err != nil {
-					log.Printf("Warning: scroll failed: %v", err)
+	// 1. Open page
+	log.Println("Opening Yandex Disk Photos...")
+	err = browser.Navigate(ctx, photosURL)
+	if netcheck.IsNetworkError(err) {
+		log.Printf("📡 Could not open Yandex Disk: %v", err)
+		if err = netcheck.WaitOnline(ctx); err == nil {
+			err = browser.Navigate(ctx, photosURL)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	browser.DismissDialogs(ctx)
+	if err = navigation.WaitForService(ctx, photosURL); err != nil {
+		return nil, nil, err
+	}
+	overlay.AcceptCookies(ctx)
+
+	// Configure download directory
+	saveDir := opts.downloadDir
+	if opts.stagingDir != "" {
+		saveDir = opts.stagingDir
+	}
+	if err := browser.ConfigureDownloads(ctx, saveDir); err != nil {
+		log.Printf("⚠️ Warning: could not configure download directory: %v", err)
+	}
+	extracted := func(f download.File, res extract.Result) {
+		opts.catalog.Extracted(f.Date, res.Files)
+		// A truncated archive or a partial selection holds fewer files than selected
+		if want := opts.catalog.Items(f.Date); want > 0 && res.Entries != want {
+			log.Printf("⚠️ %s has %d files but %d items were selected for '%s'", f.Name, res.Entries, want, f.Date)
+			stats.AddMismatch(filepath.Base(f.Path), f.Date, want, res.Entries)
+		}
+		if opts.geo != nil {
+			for _, path := range res.Files {
+				if info, err := exif.Read(path); err == nil && info.HasGPS {
+					opts.geo.Add(geo.Point{File: path, Date: f.Date, Taken: info.Taken, Lat: info.Lat, Lon: info.Lon})
 				}
-				time.Sleep(1 * time.Second)
-				continue
 			}
-			log.Printf("✓ Date '%s' is within range", dateInfo.Text)
 		}
+	}
+	var extractor postProcessor
+	switch {
+	case opts.pipeline != nil:
+		extractor = pipeline.New(opts.pipeline, opts.downloadDir, opts.catalog, extracted)
+	case opts.extract != nil:
+		extractor = extract.NewWorker(*opts.extract, extracted)
+	}
+	tracker := download.NewTracker(saveDir)
+	if opts.subdirs || opts.downloadTemplate != "" || opts.stagingDir != "" || opts.sources != nil {
+		tracker.SetDateDir(opts.dateDir)
+	}
+	tracker.OnBegin(func(f download.File) {
+		opts.catalog.Began(f.Date, f.Name)
+		stats.BeginArchive(f.Name, f.Date)
+	})
+	tracker.OnFinish(func(f download.File, err error) {
+		if err != nil {
+			stats.FailArchive(f.Name, f.Date)
+			opts.catalog.Failed(f.Date, fmt.Sprintf("Download of %s: %v", f.Name, err))
+			opts.events.Emit(events.Event{Type: events.DownloadFailed, Date: f.Date, File: f.Name, Message: err.Error()})
+			return
+		}
+		opts.catalog.Exported(f.Date, f.Path, f.Bytes)
+		stats.AddArchive(f.Name, filepath.Base(f.Path), f.Date, f.Bytes)
+		opts.events.Emit(events.Event{Type: events.DownloadCompleted, Date: f.Date, File: f.Name, Bytes: f.Bytes})
+		if extractor != nil {
+			extractor.Add(f)
+		}
+	})
+	tracker.Watch(ctx)
 
-		log.Println("✓ Date selected: " + dateInfo.Text)
+	// Capture browser console output, page errors and failed requests
+	if err := opts.forensics.Watch(ctx); err != nil {
+		log.Printf("⚠️ Warning: could not capture browser console: %v", err)
+	}
 
-		// Click Download
-		time.Sleep(1500 * time.Millisecond)
-		if err := download.ClickDownloadButton(ctx); err != nil {
-			if browser.IsBrowserClosed(err) {
-				log.Println("\n⚠️ Browser was closed. Exiting gracefully...")
-				break
-			}
-			log.Printf("Download error: %v", err)
-			stats.IncrementDownloadsFailed()
-			stats.AddError(currentDateInfo, fmt.Sprintf("Download failed: %v", err))
-		} else {
-			log.Println("✓ Download started")
-			stats.IncrementDownloadsStarted()
-		}
+	// Watch for throttling signals (HTTP 429)
+	pacer := throttle.New()
+	if err := pacer.Watch(ctx); err != nil {
+		log.Printf("⚠️ Warning: could not watch for throttling: %v", err)
+	}
 
-		// Wait for download to start
-		time.Sleep(4 * time.Second)
+	// 2. Check login status
+	isLoggedIn, err := auth.CheckLoginStatus(ctx)
+	if err != nil {
+		log.Printf("Warning: could not check login status: %v", err)
+	}
 
-		// Deselect
-		for retry := 0; retry < 3; retry++ {
-			if err := selection.Deselect(ctx); err != nil {
-				if browser.IsBrowserClosed(err) {
-					log.Println("\n⚠️ Browser was closed. Exiting gracefully...")
-					break
-				}
-				log.Printf("Error deselecting (attempt %d): %v", retry+1, err)
-			}
-			time.Sleep(1 * time.Second)
+	// Open the session from the OAuth token, falling back to the login page
+	if !isLoggedIn && opts.token != "" {
+		if err := auth.LoginWithToken(ctx, opts.token, photosURL); err != nil {
+			log.Printf("⚠️ Could not log in with the OAuth token: %v", err)
+		} else {
+			isLoggedIn = true
+		}
+	}
 
-			if !selection.HasActiveSelection(ctx) {
-				break
-			}
-			log.Printf("⚠️ Selection still active, trying again...")
+	if !isLoggedIn {
+		if browser.IsHeadlessShell(opts.execPath) {
+			return nil, nil, fmt.Errorf("not logged in to Yandex, and %s has no window to log in with; log in once with a regular browser and -profile %s, or pass -token, then run again", filepath.Base(opts.execPath), opts.profile)
 		}
+		showWindow(ctx, opts)
+		if err = auth.WaitForLogin(ctx); err != nil {
+			return nil, nil, err
+		}
+		placeWindow(ctx, opts)
 
-		// Check again if browser is still open before continuing
-		if browser.IsContextCanceled(ctx) {
-			log.Println("\n⚠️ Browser was closed. Exiting gracefully...")
-			break
+		// Navigate to photos after successful login
+		if err := browser.Navigate(ctx, photosURL); err != nil {
+			log.Printf("Warning: could not navigate after login: %v", err)
 		}
-		log.Println("✓ Deselected")
+	}
 
-		// IMPORTANT: Scroll to move processed date off screen
-		if err := navigation.ScrollToPosition(ctx, dateInfo.YPosition); err != nil {
-			if browser.IsBrowserClosed(err) {
-				log.Println("\n⚠️ Browser was closed. Exiting gracefully...")
-				break
-			}
-			log.Printf("Warning: scroll to position failed: %v", err)
+	log.Println("✓ User is logged in")
+
+	// Match the labels of the interface language instead of assuming English
+	if lang, err := locale.Detect(ctx); err != nil {
+		log.Printf("⚠️ Warning: could not detect the interface language: %v", err)
+	} else if locale.Use(lang) {
+		log.Printf("Interface language: %s", lang)
+	} else {
+		log.Printf("⚠️ Interface language %q is not known; matching the labels of all known languages", lang)
+	}
+
+	if usage, ok := readStorage(ctx, "start"); ok {
+		stats.SetStorageBefore(usage.Used, usage.Total)
+	}
+
+	// 3. Apply filter to show only the photos of the selected section, and
+	// check that the timeline changed with it
+	wasActive, _, _ := navigation.FilterActive(ctx, opts.filter)
+	before, gridErr := navigation.ReadGrid(ctx)
+	filterErr := retry.Do(ctx, retry.DefaultPolicy(), "Filter", func(int) error {
+		overlay.AcceptCookies(ctx) // The consent banner covers the filter button
+		return navigation.ApplyFilter(ctx, opts.filter)
+	})
+	if filterErr != nil {
+		log.Printf("⚠️ Warning: could not apply filter: %v", filterErr)
+		log.Println("The filter is tried again before the first date; the run stops if it still cannot be applied")
+	}
+
+	// Wait for page to update after filter
+	browser.Pause(ctx, 2*time.Second)
+	if filterErr == nil && gridErr == nil {
+		navigation.VerifyFilter(ctx, opts.filter, before, wasActive)
+	}
+	snapshot.Capture(ctx, snapshot.StateTimeline)
+
+	// A restarted browser carries on where the previous one stopped
+	if opts.resumeScrollY > 0 {
+		log.Printf("Restoring scroll position (y=%.0f)...", opts.resumeScrollY)
+		if err := navigation.RestoreScrollPosition(ctx, opts.resumeScrollY); err != nil {
+			log.Printf("⚠️ Warning: could not restore the scroll position: %v", err)
 		}
-		time.Sleep(1 * time.Second)
+	}
 
-		stats.IncrementDatesProcessed()
+	// Notice a hung renderer instead of timing out step after step
+	var health *browser.Heartbeat
+	if opts.heartbeat > 0 {
+		health = browser.StartHeartbeat(ctx, opts.heartbeat)
 	}
 
-	// Print final report
-	stats.Print()
+	// Reopen the page in the tab if its renderer crashes
+	tab := browser.WatchTab(ctx)
 
-	log.Println("Browser remains open. Press Ctrl+C to exit.")
+	// 4. Main loop - process one date at a time
+	loop := exporter.New(exporter.Config{
+		PhotosURL:        photosURL,
+		Filter:           opts.filter,
+		DateRange:        opts.dateRange,
+		DateTimeout:      opts.dateTimeout,
+		ReloadEvery:      opts.reloadEvery,
+		Forensics:        opts.forensics,
+		Pacer:            pacer,
+		Stats:            stats,
+		Tracer:           opts.tracer,
+		Events:           opts.events,
+		Control:          opts.control,
+		Downloads:        tracker,
+		Health:           health,
+		Tab:              tab,
+		KeepAlive:        opts.keepAlive,
+		MaxInFlight:      opts.maxInFlight,
+		DateRetries:      opts.dateRetries,
+		ErrorThreshold:   opts.errorThreshold,
+		ErrorActions:     opts.errorActions,
+		ResumeScrollY:    opts.resumeScrollY,
+		RetryBlacklisted: opts.retryBlacklisted,
+		DateLogs:         opts.dateLogs,
+		ErrorLog:         opts.errorLog,
+		Catalog:          opts.catalog,
+	})
+	switch {
+	case opts.preview:
+		loop.PreviewOnly(filepath.Join(opts.downloadDir, "previews"))
+	case opts.inventory:
+		loop.InventoryOnly()
+	case opts.trash:
+		loop.TrashAfterVerify(opts.confirmTrash)
+	case opts.markAlbum != "":
+		loop.AddToAlbumAfterVerify(opts.markAlbum)
+	}
+	if opts.mirror != nil {
+		loop.SkipMirrored(opts.mirror)
+	}
+	if opts.skipArchived {
+		loop.SkipArchived()
+	}
+	if opts.sources != nil {
+		loop.TagSources(opts.sources.set)
+	}
+	if opts.annotations != nil {
+		loop.SaveAnnotations(opts.annotations)
+	}
+	err = loop.Run(ctx)
+	if pacing != nil {
+		log.Printf("⏱️ Page reactions: %s", pacing.Summary())
+	}
+	var restart *exporter.RestartError
+	if err != nil && !errors.As(err, &restart) {
+		return nil, nil, err
+	}
 
-	select {}
+	// Reload the page so the sidebar shows the storage after deletions
+	if opts.trash && restart == nil {
+		if err := browser.Navigate(ctx, photosURL); err != nil {
+			log.Printf("⚠️ Warning: could not reload the page: %v", err)
+		}
+	}
+	if restart == nil {
+		if usage, ok := readStorage(ctx, "end"); ok {
+			stats.SetStorageAfter(usage.Used, usage.Total)
+		}
+	}
+
+	// Extract the archives of the last dates before reporting, and let
+	// them finish before the browser is closed for a restart
+	if extractor != nil || restart != nil {
+		log.Println("⏳ Waiting for running downloads to finish...")
+		stopKeepAlive := browser.KeepAlive(ctx, opts.keepAlive)
+		running := tracker.WaitAll(ctx, exporter.ArchiveTimeout)
+		stopKeepAlive()
+		switch {
+		case running && extractor != nil:
+			log.Println("⚠️ Some downloads are still running; they will not be extracted")
+		case running:
+			log.Println("⚠️ Some downloads are still running; restarting the browser cancels them")
+		}
+	}
+	if extractor != nil {
+		files, skipped := extractor.Close()
+		stats.SetExtraction(files, skipped)
+		stats.SetDuplicates(extractor.Duplicates())
+	}
+
+	// A restart closes this browser through the deferred cleanup
+	return stats, browserCtx, err
 }
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/browser"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/events"
+	"github.com/cantalupo555/yandex-disk-photo-exporter/internal/fixture"
+)
+
+// exportArgsEnv makes the test binary run main with the arguments it holds
+// (separated by newlines), so a test can run a whole export in a process of
+// its own.
+const exportArgsEnv = "EXPORTER_TEST_ARGS"
+
+// testBrowserEnv names the browser for end-to-end tests; without it the
+// detected browser is used if it can run here.
+const testBrowserEnv = "EXPORTER_TEST_BROWSER"
+
+func TestMain(m *testing.M) {
+	if args := os.Getenv(exportArgsEnv); args != "" {
+		os.Args = append(os.Args[:1], strings.Split(args, "\n")...)
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testBrowser returns the browser to run end-to-end tests with, skipping
+// the test if there is none that can run here.
+func testBrowser(t *testing.T) string {
+	t.Helper()
+	path := os.Getenv(testBrowserEnv)
+	if path == "" {
+		if path = browser.DetectBrowser(); path == "" {
+			t.Skip("no Chrome or Chromium found; set " + testBrowserEnv)
+		}
+		if runtime.GOOS == "linux" && !browser.IsHeadlessShell(path) && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			t.Skipf("no display for %s; set %s to a chrome-headless-shell", path, testBrowserEnv)
+		}
+	}
+	// A browser missing its shared libraries exits before chromedp can
+	// report it
+	if out, err := exec.Command(path, "--version").CombinedOutput(); err != nil {
+		t.Skipf("%s does not start: %v\n%s", path, err, out)
+	}
+	return path
+}
+
+func TestFixtureExport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("end-to-end run")
+	}
+	execPath := testBrowser(t)
+	dir := t.TempDir()
+	download := filepath.Join(dir, "download")
+	args := []string{
+		"-target-url", "fixture",
+		"-exec", execPath,
+		"-profile", filepath.Join(dir, "profile"),
+		"-data-dir", filepath.Join(dir, "data"),
+		"-download", download,
+		"-extract",
+		"-output", "jsonl",
+		"-quiet",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), exportArgsEnv+"="+strings.Join(args, "\n"))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() != nil {
+		t.Fatalf("the run did not exit when finished\n%s", stderr.Bytes())
+	}
+	if err != nil {
+		t.Fatalf("run failed: %v\n%s", err, stderr.Bytes())
+	}
+
+	var finished bool
+	completed := 0
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		var ev events.Event
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatalf("stdout holds a line that is not an event: %q", sc.Text())
+		}
+		switch ev.Type {
+		case events.DownloadCompleted:
+			completed++
+		case events.Error, events.DownloadFailed:
+			t.Errorf("%s event: %s", ev.Type, ev.Message)
+		case events.RunFinished:
+			finished = true
+		}
+	}
+	if !finished {
+		t.Errorf("no %s event", events.RunFinished)
+	}
+	if completed != len(fixture.Dates) {
+		t.Errorf("%d downloads completed, want %d", completed, len(fixture.Dates))
+	}
+
+	var want, got []string
+	for _, d := range fixture.Dates {
+		for _, p := range d.Photos {
+			want = append(want, p.Name)
+		}
+	}
+	err = filepath.WalkDir(download, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".jpg") {
+			return err
+		}
+		got = append(got, d.Name())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(want)
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("extracted %v, want %v", got, want)
+	}
+}